@@ -2,10 +2,13 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"fmt"
 	"os"
 
 	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 
 	"github.com/letsencrypt/boulder/cmd"
@@ -28,15 +31,13 @@ type config struct {
 	Syslog cmd.SyslogConfig
 
 	Common struct {
-		CT struct {
-			Logs                       []cmd.LogDescription
-			IntermediateBundleFilename string
-		}
+		CT cmd.CTConfig
 	}
 }
 
 func main() {
 	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	validate := flag.Bool("validate", false, "Check the CT configuration, without starting the publisher, and exit")
 	flag.Parse()
 	if *configFile == "" {
 		flag.Usage()
@@ -46,6 +47,14 @@ func main() {
 	var c config
 	err := cmd.ReadConfigFile(*configFile, &c)
 	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	if *validate {
+		if err := publisher.ValidateCTConfig(&c.Common.CT); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("CT configuration is valid")
+		return
+	}
 	err = features.Set(c.Publisher.Features)
 	cmd.FailOnError(err, "Failed to set feature flags")
 
@@ -53,21 +62,42 @@ func main() {
 	defer logger.AuditPanic()
 	logger.Info(cmd.VersionString())
 
-	logs := make([]*publisher.Log, len(c.Common.CT.Logs))
-	for i, ld := range c.Common.CT.Logs {
-		logs[i], err = publisher.NewLog(ld.URI, ld.Key, logger)
-		cmd.FailOnError(err, "Unable to parse CT log description")
-	}
+	err = publisher.ValidateConfig(c.Common.CT)
+	cmd.FailOnError(err, "Invalid CT configuration")
+	httpClient, err := publisher.NewHTTPClient(c.Common.CT)
+	cmd.FailOnError(err, "Unable to configure the HTTP client for CT logs")
+	logs, err := publisher.NewLogs(c.Common.CT, httpClient, logger)
+	cmd.FailOnError(err, "Unable to parse CT log descriptions")
 
-	if c.Common.CT.IntermediateBundleFilename == "" {
-		logger.AuditErr("No CT submission bundle provided")
+	if c.Common.CT.IntermediateBundleFilename == "" && c.Common.CT.IssuerPath == "" {
+		logger.AuditErr("No CT submission bundle or issuer provided")
 		os.Exit(1)
 	}
-	pemBundle, err := core.LoadCertBundle(c.Common.CT.IntermediateBundleFilename)
-	cmd.FailOnError(err, "Failed to load CT submission bundle")
-	bundle := []ct.ASN1Cert{}
-	for _, cert := range pemBundle {
-		bundle = append(bundle, ct.ASN1Cert{Data: cert.Raw})
+	// Without a bundle publisher.New loads the issuer from IssuerPath
+	var bundle []ct.ASN1Cert
+	if c.Common.CT.IntermediateBundleFilename != "" {
+		pemBundle, err := core.LoadCertBundle(c.Common.CT.IntermediateBundleFilename)
+		cmd.FailOnError(err, "Failed to load CT submission bundle")
+		for _, cert := range pemBundle {
+			bundle = append(bundle, ct.ASN1Cert{Data: cert.Raw})
+		}
+	}
+
+	var additionalBundles [][]ct.ASN1Cert
+	for _, filename := range c.Common.CT.AdditionalIntermediateBundleFilenames {
+		pemBundle, err := core.LoadCertBundle(filename)
+		cmd.FailOnError(err, fmt.Sprintf("Failed to load CT submission bundle %s", filename))
+		var additional []ct.ASN1Cert
+		for _, cert := range pemBundle {
+			additional = append(additional, ct.ASN1Cert{Data: cert.Raw})
+		}
+		additionalBundles = append(additionalBundles, additional)
+	}
+
+	var crossSigns []*x509.Certificate
+	if c.Common.CT.CrossSignBundleFilename != "" {
+		crossSigns, err = core.LoadCertBundle(c.Common.CT.CrossSignBundleFilename)
+		cmd.FailOnError(err, "Failed to load CT cross-sign bundle")
 	}
 
 	var tls *tls.Config
@@ -81,12 +111,19 @@ func main() {
 	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(conn))
 
 	pubi := publisher.New(
+		c.Common.CT,
 		bundle,
+		additionalBundles,
+		crossSigns,
 		logs,
+		httpClient,
 		c.Publisher.SubmissionTimeout.Duration,
 		logger,
 		scope,
 		sac)
+	if c.Common.CT.CheckAcceptedRoots {
+		go pubi.CheckIssuerAccepted(context.Background())
+	}
 
 	var grpcSrv *grpc.Server
 	if c.Publisher.GRPC != nil {
@@ -105,6 +142,7 @@ func main() {
 		if grpcSrv != nil {
 			grpcSrv.GracefulStop()
 		}
+		_ = pubi.Close()
 	})
 
 	go cmd.DebugServer(c.Publisher.DebugAddr)
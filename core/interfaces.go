@@ -108,6 +108,7 @@ type StorageGetter interface {
 	CountRegistrationsByIPRange(ctx context.Context, ip net.IP, earliest, latest time.Time) (int, error)
 	CountPendingAuthorizations(ctx context.Context, regID int64) (int, error)
 	GetSCTReceipt(ctx context.Context, serial, logID string) (SignedCertificateTimestamp, error)
+	GetSCTSubmissionFailures(ctx context.Context) ([]SCTSubmissionFailure, error)
 	CountFQDNSets(ctx context.Context, window time.Duration, domains []string) (count int64, err error)
 	FQDNSetExists(ctx context.Context, domains []string) (exists bool, err error)
 }
@@ -122,6 +123,10 @@ type StorageAdder interface {
 	MarkCertificateRevoked(ctx context.Context, serial string, reasonCode revocation.Reason) error
 	AddCertificate(ctx context.Context, der []byte, regID int64, ocsp []byte) (digest string, err error)
 	AddSCTReceipt(ctx context.Context, sct SignedCertificateTimestamp) error
+	DeleteExpiredSCTReceipts(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	PurgeSCTReceipts(ctx context.Context, serial string) (int64, error)
+	AddSCTSubmissionFailure(ctx context.Context, failure SCTSubmissionFailure) error
+	DeleteSCTSubmissionFailure(ctx context.Context, serial, logURI string) error
 	RevokeAuthorizationsByDomain(ctx context.Context, domain AcmeIdentifier) (finalized, pending int64, err error)
 	DeactivateRegistration(ctx context.Context, id int64) error
 	DeactivateAuthorization(ctx context.Context, id string) error
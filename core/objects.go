@@ -1,12 +1,18 @@
 package core
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"net"
+	"sort"
 	"strings"
 	"time"
 
@@ -553,6 +559,11 @@ type SignedCertificateTimestamp struct {
 	Extensions []byte `db:"extensions"`
 	// The Log's signature for this SCT
 	Signature []byte `db:"signature"`
+	// The TLS HashAlgorithm and SignatureAlgorithm of the signature. They're
+	// zero for receipts stored before they were recorded, see
+	// signatureAlgorithms.
+	HashAlgorithm      uint8 `db:"hashAlgorithm"`
+	SignatureAlgorithm uint8 `db:"signatureAlgorithm"`
 
 	// The serial of the certificate this SCT is for
 	CertificateSerial string `db:"certificateSerial"`
@@ -560,6 +571,205 @@ type SignedCertificateTimestamp struct {
 	LockCol int64
 }
 
+// sctLogIDLength is the length of an SCT's log ID, a SHA-256 hash
+const sctLogIDLength = 32
+
+// The TLS HashAlgorithm and SignatureAlgorithm values, from RFC 5246 section
+// 7.4.1.4.1, that RFC 6962 logs sign SCTs with
+const (
+	sctHashSHA256     = 4
+	sctSignatureRSA   = 1
+	sctSignatureECDSA = 3
+)
+
+// signatureAlgorithms returns the SCT's hash and signature algorithms. SCT
+// receipts stored before the algorithms were recorded have neither, so they
+// are inferred from the signature instead. RFC 6962 logs always hash with
+// SHA-256 and sign with either ECDSA, giving a DER SEQUENCE of two INTEGERs,
+// or RSA, whose signatures are as long as the key's modulus and practically
+// never parse as such a SEQUENCE.
+func (sct *SignedCertificateTimestamp) signatureAlgorithms() (hash, signature uint8) {
+	if sct.HashAlgorithm != 0 || sct.SignatureAlgorithm != 0 {
+		return sct.HashAlgorithm, sct.SignatureAlgorithm
+	}
+	var ecdsaSig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(sct.Signature, &ecdsaSig); err == nil && len(rest) == 0 {
+		return sctHashSHA256, sctSignatureECDSA
+	}
+	return sctHashSHA256, sctSignatureRSA
+}
+
+// DigitallySigned returns the TLS encoding of the SCT's signature as a
+// DigitallySigned struct, a one byte hash algorithm, a one byte signature
+// algorithm and a two byte length prefixed signature, which is how logs
+// return it and how it is serialized
+func (sct *SignedCertificateTimestamp) DigitallySigned() ([]byte, error) {
+	if len(sct.Signature) == 0 {
+		return nil, fmt.Errorf("SCT has no signature")
+	}
+	if len(sct.Signature) > math.MaxUint16 {
+		return nil, fmt.Errorf("SCT signature is too long: %d bytes", len(sct.Signature))
+	}
+	hash, signature := sct.signatureAlgorithms()
+	ds := make([]byte, 4, 4+len(sct.Signature))
+	ds[0], ds[1] = hash, signature
+	binary.BigEndian.PutUint16(ds[2:4], uint16(len(sct.Signature)))
+	return append(ds, sct.Signature...), nil
+}
+
+// SetDigitallySigned sets the SCT's signature and its algorithms from the
+// TLS encoding of a DigitallySigned struct, as returned by DigitallySigned
+func (sct *SignedCertificateTimestamp) SetDigitallySigned(ds []byte) error {
+	if err := checkSignatureLength(ds); err != nil {
+		return err
+	}
+	sct.HashAlgorithm = ds[0]
+	sct.SignatureAlgorithm = ds[1]
+	sct.Signature = append([]byte{}, ds[4:]...)
+	return nil
+}
+
+// MarshalBinary encodes the SCT as the SerializedSCT structure from RFC 6962
+// section 3.3, which is how SCTs are embedded in certificates and OCSP
+// responses
+func (sct *SignedCertificateTimestamp) MarshalBinary() ([]byte, error) {
+	logID, err := base64.StdEncoding.DecodeString(sct.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding SCT log ID: %s", err)
+	}
+	if len(logID) != sctLogIDLength {
+		return nil, fmt.Errorf("SCT log ID is %d bytes, expected %d", len(logID), sctLogIDLength)
+	}
+	if len(sct.Extensions) > math.MaxUint16 {
+		return nil, fmt.Errorf("SCT extensions are too long: %d bytes", len(sct.Extensions))
+	}
+	signature, err := sct.DigitallySigned()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 1+sctLogIDLength+8+2+len(sct.Extensions)+len(signature))
+	buf = append(buf, sct.SCTVersion)
+	buf = append(buf, logID...)
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], sct.Timestamp)
+	buf = append(buf, timestamp[:]...)
+	var extLen [2]byte
+	binary.BigEndian.PutUint16(extLen[:], uint16(len(sct.Extensions)))
+	buf = append(buf, extLen[:]...)
+	buf = append(buf, sct.Extensions...)
+	buf = append(buf, signature...)
+	return buf, nil
+}
+
+// checkSignatureLength checks that a TLS encoded DigitallySigned struct has a
+// length prefix matching the bytes after it. A mismatch means the signature
+// was truncated or padded.
+func checkSignatureLength(signature []byte) error {
+	if len(signature) < 4 {
+		return fmt.Errorf("SCT signature is too short: %d bytes", len(signature))
+	}
+	if length := int(binary.BigEndian.Uint16(signature[2:4])); length != len(signature)-4 {
+		return fmt.Errorf("SCT signature length field is %d, but %d bytes follow it", length, len(signature)-4)
+	}
+	return nil
+}
+
+// UnmarshalBinary decodes an SCT in the SerializedSCT format produced by
+// MarshalBinary. The certificate serial isn't part of the encoding and is left
+// unchanged.
+func (sct *SignedCertificateTimestamp) UnmarshalBinary(data []byte) error {
+	const fixedLen = 1 + sctLogIDLength + 8 + 2
+	if len(data) < fixedLen {
+		return fmt.Errorf("serialized SCT is too short: %d bytes", len(data))
+	}
+	version := data[0]
+	logID := data[1 : 1+sctLogIDLength]
+	timestamp := binary.BigEndian.Uint64(data[1+sctLogIDLength:])
+	extLen := int(binary.BigEndian.Uint16(data[1+sctLogIDLength+8:]))
+	rest := data[fixedLen:]
+	if len(rest) < extLen {
+		return fmt.Errorf("serialized SCT extensions are truncated")
+	}
+	extensions, signature := rest[:extLen], rest[extLen:]
+	if err := sct.SetDigitallySigned(signature); err != nil {
+		return fmt.Errorf("serialized SCT has a malformed signature: %s", err)
+	}
+	sct.SCTVersion = version
+	sct.LogID = base64.StdEncoding.EncodeToString(logID)
+	sct.Timestamp = timestamp
+	sct.Extensions = append([]byte{}, extensions...)
+	return nil
+}
+
+// sctsByLogID sorts SCTs by the bytes of their log IDs. Log IDs that aren't
+// valid base64 are compared as they are.
+type sctsByLogID []SignedCertificateTimestamp
+
+func (s sctsByLogID) Len() int      { return len(s) }
+func (s sctsByLogID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sctsByLogID) Less(i, j int) bool {
+	return LogIDLess(s[i].LogID, s[j].LogID)
+}
+
+// LogIDLess reports whether the base64 encoded log ID a sorts before b when
+// comparing the decoded IDs
+func LogIDLess(a, b string) bool {
+	aID, aErr := base64.StdEncoding.DecodeString(a)
+	bID, bErr := base64.StdEncoding.DecodeString(b)
+	if aErr != nil || bErr != nil {
+		return a < b
+	}
+	return bytes.Compare(aID, bID) < 0
+}
+
+// SCTListOID is the OID of the certificate and OCSP extension holding a
+// SignedCertificateTimestampList, from RFC 6962 section 3.3
+var SCTListOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// MarshalSCTList encodes the SCTs as a SignedCertificateTimestampList from
+// RFC 6962 section 3.3, wrapped in an OCTET STRING. The result is the DER
+// value of an extension with SCTListOID. The SCTs are listed in the order of
+// their log IDs, whatever order they are given in, so that the same SCTs
+// always encode to the same bytes.
+func MarshalSCTList(scts []SignedCertificateTimestamp) ([]byte, error) {
+	if len(scts) == 0 {
+		return nil, fmt.Errorf("SCT list must contain at least one SCT")
+	}
+	scts = append([]SignedCertificateTimestamp(nil), scts...)
+	sort.Stable(sctsByLogID(scts))
+	var list []byte
+	for i := range scts {
+		serialized, err := scts[i].MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("serializing SCT from log %s: %s", scts[i].LogID, err)
+		}
+		if len(serialized) > math.MaxUint16 {
+			return nil, fmt.Errorf("serialized SCT from log %s is too long: %d bytes", scts[i].LogID, len(serialized))
+		}
+		var sctLen [2]byte
+		binary.BigEndian.PutUint16(sctLen[:], uint16(len(serialized)))
+		list = append(list, sctLen[:]...)
+		list = append(list, serialized...)
+	}
+	if len(list) > math.MaxUint16 {
+		return nil, fmt.Errorf("SCT list is too long: %d bytes, the maximum is %d", len(list), math.MaxUint16)
+	}
+	var listLen [2]byte
+	binary.BigEndian.PutUint16(listLen[:], uint16(len(list)))
+	return asn1.Marshal(append(listLen[:], list...))
+}
+
+// SCTSubmissionFailure is the last failed attempt to submit the certificate
+// with CertificateSerial to the CT log at LogURI, kept until a later attempt
+// succeeds so that the certificate can be resubmitted
+type SCTSubmissionFailure struct {
+	ID                int64     `db:"id"`
+	CertificateSerial string    `db:"certificateSerial"`
+	LogURI            string    `db:"logURI"`
+	Reason            string    `db:"reason"`
+	AttemptedAt       time.Time `db:"attemptedAt"`
+}
+
 // FQDNSet contains the SHA256 hash of the lowercased, comma joined dNSNames
 // contained in a certificate.
 type FQDNSet struct {
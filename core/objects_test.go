@@ -1,12 +1,21 @@
 package core
 
 import (
+	"bytes"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"net"
 	"testing"
 
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
 	"gopkg.in/square/go-jose.v1"
 
 	"github.com/letsencrypt/boulder/test"
@@ -92,3 +101,153 @@ func TestJSONBufferUnmarshal(t *testing.T) {
 	err := json.Unmarshal(notValidBase64, &testStruct)
 	test.Assert(t, err != nil, "Should have choked on invalid base64")
 }
+
+// pilotSCT is the SCT from Google's Pilot log embedded in the certificate for
+// www.lloydsbank.com issued on 2017-02-08, in its RFC 6962 serialization
+const pilotSCT = "00" + // Version
+	"a4b90990b418581487bb13a2cc67700a3c359804f91bdfb8e377cd0ec80ddc10" + // Log ID
+	"0000015a1e1ddc1a" + // Timestamp
+	"0000" + // Extensions
+	"0403" + // SHA-256, ECDSA
+	"0047" + pilotSCTSignature
+
+const pilotSCTSignature = "304502200e39bbda5f7a2acd1e7f0ad311c30947c28a1412149a34618985c581203bc184" +
+	"022100c035dd988924bf7949d6d77e4634f35cc0488e9f51dc88b51ae98e18a7bc22a7"
+
+func TestSCTMarshalBinary(t *testing.T) {
+	expected, err := hex.DecodeString(pilotSCT)
+	test.AssertNotError(t, err, "Failed to decode SCT")
+	sig, err := hex.DecodeString(pilotSCTSignature)
+	test.AssertNotError(t, err, "Failed to decode signature")
+	logID, err := hex.DecodeString("a4b90990b418581487bb13a2cc67700a3c359804f91bdfb8e377cd0ec80ddc10")
+	test.AssertNotError(t, err, "Failed to decode log ID")
+	// The SCT as stored, with the signature separate from its algorithms
+	sct := SignedCertificateTimestamp{
+		SCTVersion:         0,
+		LogID:              base64.StdEncoding.EncodeToString(logID),
+		Timestamp:          1486563957786,
+		Signature:          sig,
+		HashAlgorithm:      4,
+		SignatureAlgorithm: 3,
+		CertificateSerial:  "1f3e993b012393ace153dbf79f6268dea7ae72a6",
+	}
+	serialized, err := sct.MarshalBinary()
+	test.AssertNotError(t, err, "Failed to marshal SCT")
+	test.AssertByteEquals(t, serialized, expected)
+
+	// Receipts stored before the algorithms were recorded only have the
+	// signature, and their algorithms are inferred from it
+	legacy := sct
+	legacy.HashAlgorithm, legacy.SignatureAlgorithm = 0, 0
+	serialized, err = legacy.MarshalBinary()
+	test.AssertNotError(t, err, "Failed to marshal SCT stored without algorithms")
+	test.AssertByteEquals(t, serialized, expected)
+	legacy.Signature = bytes.Repeat([]byte{0x30}, 256)
+	ds, err := legacy.DigitallySigned()
+	test.AssertNotError(t, err, "Failed to encode RSA signature stored without algorithms")
+	test.AssertByteEquals(t, ds[:4], []byte{4, 1, 1, 0})
+
+	var decoded SignedCertificateTimestamp
+	err = decoded.UnmarshalBinary(expected)
+	test.AssertNotError(t, err, "Failed to unmarshal SCT")
+	test.AssertEquals(t, decoded.LogID, sct.LogID)
+	test.AssertEquals(t, decoded.Timestamp, sct.Timestamp)
+	test.AssertByteEquals(t, decoded.Signature, sct.Signature)
+	test.AssertEquals(t, decoded.HashAlgorithm, sct.HashAlgorithm)
+	test.AssertEquals(t, decoded.SignatureAlgorithm, sct.SignatureAlgorithm)
+
+	// The encoding should match the certificate-transparency-go encoding of
+	// the same SCT, including extensions
+	sct.Extensions = []byte{1, 2, 3}
+	serialized, err = sct.MarshalBinary()
+	test.AssertNotError(t, err, "Failed to marshal SCT")
+	reference, err := ctTLS.Marshal(ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256(nil)},
+		Timestamp:  sct.Timestamp,
+		Extensions: ct.CTExtensions{1, 2, 3},
+		Signature: ct.DigitallySigned{
+			Algorithm: ctTLS.SignatureAndHashAlgorithm{Hash: ctTLS.SHA256, Signature: ctTLS.ECDSA},
+			Signature: sig,
+		},
+	})
+	test.AssertNotError(t, err, "Failed to marshal reference SCT")
+	test.AssertByteEquals(t, serialized[1+32:], reference[1+32:])
+	err = decoded.UnmarshalBinary(serialized)
+	test.AssertNotError(t, err, "Failed to unmarshal SCT")
+	test.AssertByteEquals(t, decoded.Extensions, sct.Extensions)
+	reencoded, err := decoded.MarshalBinary()
+	test.AssertNotError(t, err, "Failed to re-marshal SCT")
+	test.AssertByteEquals(t, reencoded, serialized)
+
+	err = decoded.UnmarshalBinary(serialized[:len(serialized)-1])
+	test.AssertError(t, err, "Unmarshaled an SCT with a truncated signature")
+	err = decoded.UnmarshalBinary(serialized[:20])
+	test.AssertError(t, err, "Unmarshaled a truncated SCT")
+	err = decoded.UnmarshalBinary(append(serialized, 0))
+	test.AssertError(t, err, "Unmarshaled an SCT with a padded signature")
+	test.AssertEquals(t, err.Error(), fmt.Sprintf("serialized SCT has a malformed signature: SCT signature length field is %d, but %d bytes follow it",
+		len(sig), len(sig)+1))
+
+	sct.Signature = nil
+	_, err = sct.MarshalBinary()
+	test.AssertError(t, err, "Marshaled an SCT without a signature")
+	sct.Signature = sig
+	sct.LogID = "AAAA"
+	_, err = sct.MarshalBinary()
+	test.AssertError(t, err, "Marshaled an SCT with a short log ID")
+}
+
+func TestMarshalSCTList(t *testing.T) {
+	sig, err := hex.DecodeString(pilotSCTSignature)
+	test.AssertNotError(t, err, "Failed to decode signature")
+	var scts []SignedCertificateTimestamp
+	for i := 0; i < 2; i++ {
+		logID := sha256.Sum256([]byte(fmt.Sprintf("log key %d", i)))
+		scts = append(scts, SignedCertificateTimestamp{
+			LogID:              base64.StdEncoding.EncodeToString(logID[:]),
+			Timestamp:          uint64(1337 + i),
+			Signature:          sig,
+			HashAlgorithm:      4,
+			SignatureAlgorithm: 3,
+		})
+	}
+
+	value, err := MarshalSCTList(scts)
+	test.AssertNotError(t, err, "Failed to marshal SCT list")
+	// The SCTs are listed by log ID, so the order they're given in doesn't
+	// matter
+	reversed, err := MarshalSCTList([]SignedCertificateTimestamp{scts[1], scts[0]})
+	test.AssertNotError(t, err, "Failed to marshal SCT list")
+	test.AssertByteEquals(t, reversed, value)
+
+	var list []byte
+	rest, err := asn1.Unmarshal(value, &list)
+	test.AssertNotError(t, err, "SCT list isn't an OCTET STRING")
+	test.AssertEquals(t, len(rest), 0)
+	test.AssertEquals(t, int(binary.BigEndian.Uint16(list)), len(list)-2)
+	list = list[2:]
+	var logIDs [][]byte
+	for range scts {
+		sctLen := int(binary.BigEndian.Uint16(list))
+		var decoded SignedCertificateTimestamp
+		err = decoded.UnmarshalBinary(list[2 : 2+sctLen])
+		test.AssertNotError(t, err, "Failed to unmarshal SCT from list")
+		logID, err := base64.StdEncoding.DecodeString(decoded.LogID)
+		test.AssertNotError(t, err, "Failed to decode log ID")
+		logIDs = append(logIDs, logID)
+		list = list[2+sctLen:]
+	}
+	test.AssertEquals(t, len(list), 0)
+	test.Assert(t, bytes.Compare(logIDs[0], logIDs[1]) < 0, "SCTs aren't listed by log ID")
+
+	_, err = MarshalSCTList(nil)
+	test.AssertError(t, err, "Marshaled an empty SCT list")
+
+	// Two SCTs with large extensions fit individually but not together
+	for i := range scts {
+		scts[i].Extensions = make([]byte, 40000)
+	}
+	_, err = MarshalSCTList(scts)
+	test.AssertError(t, err, "Marshaled an SCT list longer than 65535 bytes")
+}
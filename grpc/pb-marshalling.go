@@ -415,26 +415,35 @@ func sctToPB(sct core.SignedCertificateTimestamp) *sapb.SignedCertificateTimesta
 	id := int64(sct.ID)
 	version := int64(sct.SCTVersion)
 	timestamp := int64(sct.Timestamp)
+	hashAlgorithm := int64(sct.HashAlgorithm)
+	signatureAlgorithm := int64(sct.SignatureAlgorithm)
 	return &sapb.SignedCertificateTimestamp{
-		Id:                &id,
-		SctVersion:        &version,
-		LogID:             &sct.LogID,
-		Timestamp:         &timestamp,
-		Extensions:        sct.Extensions,
-		Signature:         sct.Signature,
-		CertificateSerial: &sct.CertificateSerial,
+		Id:                 &id,
+		SctVersion:         &version,
+		LogID:              &sct.LogID,
+		Timestamp:          &timestamp,
+		Extensions:         sct.Extensions,
+		Signature:          sct.Signature,
+		CertificateSerial:  &sct.CertificateSerial,
+		HashAlgorithm:      &hashAlgorithm,
+		SignatureAlgorithm: &signatureAlgorithm,
 	}
 }
 
+// pbToSCT leaves the signature algorithms zero when they aren't set, so that
+// receipts from an SA that doesn't send them have them inferred as for
+// receipts stored before they were recorded
 func pbToSCT(pb *sapb.SignedCertificateTimestamp) core.SignedCertificateTimestamp {
 	return core.SignedCertificateTimestamp{
-		ID:                int(*pb.Id),
-		SCTVersion:        uint8(*pb.SctVersion),
-		LogID:             *pb.LogID,
-		Timestamp:         uint64(*pb.Timestamp),
-		Extensions:        pb.Extensions,
-		Signature:         pb.Signature,
-		CertificateSerial: *pb.CertificateSerial,
+		ID:                 int(*pb.Id),
+		SCTVersion:         uint8(*pb.SctVersion),
+		LogID:              *pb.LogID,
+		Timestamp:          uint64(*pb.Timestamp),
+		Extensions:         pb.Extensions,
+		Signature:          pb.Signature,
+		CertificateSerial:  *pb.CertificateSerial,
+		HashAlgorithm:      uint8(pb.GetHashAlgorithm()),
+		SignatureAlgorithm: uint8(pb.GetSignatureAlgorithm()),
 	}
 }
 
@@ -442,6 +451,31 @@ func sctValid(sct *sapb.SignedCertificateTimestamp) bool {
 	return !(sct.Id == nil || sct.SctVersion == nil || sct.LogID == nil || sct.Timestamp == nil || sct.Signature == nil || sct.CertificateSerial == nil)
 }
 
+func sctSubmissionFailureToPB(failure core.SCTSubmissionFailure) *sapb.SCTSubmissionFailure {
+	attemptedAt := failure.AttemptedAt.UnixNano()
+	return &sapb.SCTSubmissionFailure{
+		Id:                &failure.ID,
+		CertificateSerial: &failure.CertificateSerial,
+		LogURI:            &failure.LogURI,
+		Reason:            &failure.Reason,
+		AttemptedAt:       &attemptedAt,
+	}
+}
+
+func pbToSCTSubmissionFailure(pb *sapb.SCTSubmissionFailure) core.SCTSubmissionFailure {
+	return core.SCTSubmissionFailure{
+		ID:                *pb.Id,
+		CertificateSerial: *pb.CertificateSerial,
+		LogURI:            *pb.LogURI,
+		Reason:            *pb.Reason,
+		AttemptedAt:       time.Unix(0, *pb.AttemptedAt),
+	}
+}
+
+func sctSubmissionFailureValid(pb *sapb.SCTSubmissionFailure) bool {
+	return !(pb.Id == nil || pb.CertificateSerial == nil || pb.LogURI == nil || pb.Reason == nil || pb.AttemptedAt == nil)
+}
+
 func certToPB(cert core.Certificate) *corepb.Certificate {
 	issued, expires := cert.Issued.UnixNano(), cert.Expires.UnixNano()
 	return &corepb.Certificate{
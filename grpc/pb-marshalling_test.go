@@ -303,19 +303,46 @@ func TestAuthz(t *testing.T) {
 
 func TestSCT(t *testing.T) {
 	sct := core.SignedCertificateTimestamp{
-		ID:                10,
-		SCTVersion:        1,
-		LogID:             "logid",
-		Timestamp:         100,
-		Extensions:        []byte{255},
-		Signature:         []byte{1},
-		CertificateSerial: "serial",
+		ID:                 10,
+		SCTVersion:         1,
+		LogID:              "logid",
+		Timestamp:          100,
+		Extensions:         []byte{255},
+		Signature:          []byte{1},
+		HashAlgorithm:      4,
+		SignatureAlgorithm: 3,
+		CertificateSerial:  "serial",
 	}
 
 	sctPB := sctToPB(sct)
 	outSCT := pbToSCT(sctPB)
 
 	test.AssertDeepEquals(t, sct, outSCT)
+
+	// An SA that doesn't send the algorithms leaves them to be inferred
+	sctPB.HashAlgorithm, sctPB.SignatureAlgorithm = nil, nil
+	outSCT = pbToSCT(sctPB)
+	test.AssertEquals(t, outSCT.HashAlgorithm, uint8(0))
+	test.AssertEquals(t, outSCT.SignatureAlgorithm, uint8(0))
+}
+
+func TestSCTSubmissionFailure(t *testing.T) {
+	failure := core.SCTSubmissionFailure{
+		ID:                10,
+		CertificateSerial: "serial",
+		LogURI:            "https://log.example.com",
+		Reason:            "log down",
+		AttemptedAt:       time.Unix(0, 1486563957786000000),
+	}
+
+	failurePB := sctSubmissionFailureToPB(failure)
+	test.Assert(t, sctSubmissionFailureValid(failurePB), "Converted failure is invalid")
+	outFailure := pbToSCTSubmissionFailure(failurePB)
+
+	test.AssertDeepEquals(t, failure, outFailure)
+
+	failurePB.AttemptedAt = nil
+	test.Assert(t, !sctSubmissionFailureValid(failurePB), "Failure without a time is valid")
 }
 
 func TestCert(t *testing.T) {
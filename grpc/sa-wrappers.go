@@ -277,6 +277,26 @@ func (sac StorageAuthorityClientWrapper) GetSCTReceipt(ctx context.Context, seri
 	return pbToSCT(response), nil
 }
 
+func (sac StorageAuthorityClientWrapper) GetSCTSubmissionFailures(ctx context.Context) ([]core.SCTSubmissionFailure, error) {
+	response, err := sac.inner.GetSCTSubmissionFailures(ctx, &corepb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	if response == nil {
+		return nil, errIncompleteResponse
+	}
+
+	failures := make([]core.SCTSubmissionFailure, len(response.Failures))
+	for i, failure := range response.Failures {
+		if failure == nil || !sctSubmissionFailureValid(failure) {
+			return nil, errIncompleteResponse
+		}
+		failures[i] = pbToSCTSubmissionFailure(failure)
+	}
+	return failures, nil
+}
+
 func (sac StorageAuthorityClientWrapper) CountFQDNSets(ctx context.Context, window time.Duration, domains []string) (int64, error) {
 	windowNanos := window.Nanoseconds()
 
@@ -426,6 +446,52 @@ func (sac StorageAuthorityClientWrapper) AddSCTReceipt(ctx context.Context, sct
 	return nil
 }
 
+func (sac StorageAuthorityClientWrapper) DeleteExpiredSCTReceipts(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	cutoffNano := cutoff.UnixNano()
+	limit64 := int64(limit)
+	response, err := sac.inner.DeleteExpiredSCTReceipts(ctx, &sapb.DeleteExpiredSCTReceiptsRequest{Cutoff: &cutoffNano, Limit: &limit64})
+	if err != nil {
+		return 0, err
+	}
+
+	if response == nil || response.Count == nil {
+		return 0, errIncompleteResponse
+	}
+
+	return *response.Count, nil
+}
+
+func (sac StorageAuthorityClientWrapper) PurgeSCTReceipts(ctx context.Context, serial string) (int64, error) {
+	response, err := sac.inner.PurgeSCTReceipts(ctx, &sapb.Serial{Serial: &serial})
+	if err != nil {
+		return 0, err
+	}
+
+	if response == nil || response.Count == nil {
+		return 0, errIncompleteResponse
+	}
+
+	return *response.Count, nil
+}
+
+func (sac StorageAuthorityClientWrapper) AddSCTSubmissionFailure(ctx context.Context, failure core.SCTSubmissionFailure) error {
+	_, err := sac.inner.AddSCTSubmissionFailure(ctx, sctSubmissionFailureToPB(failure))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sac StorageAuthorityClientWrapper) DeleteSCTSubmissionFailure(ctx context.Context, serial, logURI string) error {
+	_, err := sac.inner.DeleteSCTSubmissionFailure(ctx, &sapb.DeleteSCTSubmissionFailureRequest{Serial: &serial, LogURI: &logURI})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (sac StorageAuthorityClientWrapper) RevokeAuthorizationsByDomain(ctx context.Context, domain core.AcmeIdentifier) (int64, int64, error) {
 	response, err := sac.inner.RevokeAuthorizationsByDomain(ctx, &sapb.RevokeAuthorizationsByDomainRequest{Domain: &domain.Value})
 	if err != nil {
@@ -685,6 +751,19 @@ func (sas StorageAuthorityServerWrapper) GetSCTReceipt(ctx context.Context, requ
 	return sctToPB(sct), nil
 }
 
+func (sas StorageAuthorityServerWrapper) GetSCTSubmissionFailures(ctx context.Context, request *corepb.Empty) (*sapb.SCTSubmissionFailures, error) {
+	failures, err := sas.inner.GetSCTSubmissionFailures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &sapb.SCTSubmissionFailures{}
+	for _, failure := range failures {
+		response.Failures = append(response.Failures, sctSubmissionFailureToPB(failure))
+	}
+	return response, nil
+}
+
 func (sas StorageAuthorityServerWrapper) CountFQDNSets(ctx context.Context, request *sapb.CountFQDNSetsRequest) (*sapb.Count, error) {
 	if request == nil || request.Window == nil || request.Domains == nil {
 		return nil, errIncompleteRequest
@@ -843,6 +922,58 @@ func (sas StorageAuthorityServerWrapper) AddSCTReceipt(ctx context.Context, requ
 	return &corepb.Empty{}, nil
 }
 
+func (sas StorageAuthorityServerWrapper) DeleteExpiredSCTReceipts(ctx context.Context, request *sapb.DeleteExpiredSCTReceiptsRequest) (*sapb.Count, error) {
+	if request == nil || request.Cutoff == nil || request.Limit == nil {
+		return nil, errIncompleteRequest
+	}
+
+	deleted, err := sas.inner.DeleteExpiredSCTReceipts(ctx, time.Unix(0, *request.Cutoff), int(*request.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sapb.Count{Count: &deleted}, nil
+}
+
+func (sas StorageAuthorityServerWrapper) PurgeSCTReceipts(ctx context.Context, request *sapb.Serial) (*sapb.Count, error) {
+	if request == nil || request.Serial == nil {
+		return nil, errIncompleteRequest
+	}
+
+	purged, err := sas.inner.PurgeSCTReceipts(ctx, *request.Serial)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sapb.Count{Count: &purged}, nil
+}
+
+func (sas StorageAuthorityServerWrapper) AddSCTSubmissionFailure(ctx context.Context, request *sapb.SCTSubmissionFailure) (*corepb.Empty, error) {
+	if request == nil || !sctSubmissionFailureValid(request) {
+		return nil, errIncompleteRequest
+	}
+
+	err := sas.inner.AddSCTSubmissionFailure(ctx, pbToSCTSubmissionFailure(request))
+	if err != nil {
+		return nil, err
+	}
+
+	return &corepb.Empty{}, nil
+}
+
+func (sas StorageAuthorityServerWrapper) DeleteSCTSubmissionFailure(ctx context.Context, request *sapb.DeleteSCTSubmissionFailureRequest) (*corepb.Empty, error) {
+	if request == nil || request.Serial == nil || request.LogURI == nil {
+		return nil, errIncompleteRequest
+	}
+
+	err := sas.inner.DeleteSCTSubmissionFailure(ctx, *request.Serial, *request.LogURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corepb.Empty{}, nil
+}
+
 func (sas StorageAuthorityServerWrapper) RevokeAuthorizationsByDomain(ctx context.Context, request *sapb.RevokeAuthorizationsByDomainRequest) (*sapb.RevokeAuthorizationsByDomainResponse, error) {
 	if request == nil || request.Domain == nil {
 		return nil, errIncompleteRequest
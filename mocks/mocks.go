@@ -296,6 +296,31 @@ func (sa *StorageAuthority) AddSCTReceipt(_ context.Context, sct core.SignedCert
 	return
 }
 
+// DeleteExpiredSCTReceipts is a mock
+func (sa *StorageAuthority) DeleteExpiredSCTReceipts(_ context.Context, _ time.Time, _ int) (int64, error) {
+	return 0, nil
+}
+
+// PurgeSCTReceipts is a mock
+func (sa *StorageAuthority) PurgeSCTReceipts(_ context.Context, _ string) (int64, error) {
+	return 0, nil
+}
+
+// GetSCTSubmissionFailures is a mock
+func (sa *StorageAuthority) GetSCTSubmissionFailures(_ context.Context) ([]core.SCTSubmissionFailure, error) {
+	return nil, nil
+}
+
+// AddSCTSubmissionFailure is a mock
+func (sa *StorageAuthority) AddSCTSubmissionFailure(_ context.Context, _ core.SCTSubmissionFailure) error {
+	return nil
+}
+
+// DeleteSCTSubmissionFailure is a mock
+func (sa *StorageAuthority) DeleteSCTSubmissionFailure(_ context.Context, _, _ string) error {
+	return nil
+}
+
 // CountFQDNSets is a mock
 func (sa *StorageAuthority) CountFQDNSets(_ context.Context, since time.Duration, names []string) (int64, error) {
 	return 0, nil
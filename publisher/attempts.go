@@ -0,0 +1,175 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// attempt describes a single HTTP request made to a CT log while submitting a
+// certificate. StatusCode is zero if no response was received.
+type attempt struct {
+	StatusCode int
+	Latency    time.Duration
+	// Body is the start of the response body for statuses other than 200,
+	// which logs use to explain why they refused a submission, see bodySnippet
+	Body string
+}
+
+// attemptRecorder collects the attempts made during a single submission. The
+// CT client retries internally, so the recorder is carried in the request
+// context and populated by the logTransport.
+type attemptRecorder struct {
+	sync.Mutex
+	attempts []attempt
+}
+
+func (ar *attemptRecorder) record(a attempt) {
+	ar.Lock()
+	defer ar.Unlock()
+	ar.attempts = append(ar.attempts, a)
+}
+
+// count returns the number of attempts recorded
+func (ar *attemptRecorder) count() int {
+	ar.Lock()
+	defer ar.Unlock()
+	return len(ar.attempts)
+}
+
+// all returns a copy of every attempt recorded
+func (ar *attemptRecorder) all() []attempt {
+	ar.Lock()
+	defer ar.Unlock()
+	return append([]attempt(nil), ar.attempts...)
+}
+
+// last returns the most recently recorded attempt, if any
+func (ar *attemptRecorder) last() (attempt, bool) {
+	ar.Lock()
+	defer ar.Unlock()
+	if len(ar.attempts) == 0 {
+		return attempt{}, false
+	}
+	return ar.attempts[len(ar.attempts)-1], true
+}
+
+type attemptRecorderKey struct{}
+
+func withAttemptRecorder(ctx context.Context, ar *attemptRecorder) context.Context {
+	return context.WithValue(ctx, attemptRecorderKey{}, ar)
+}
+
+// lastResponseBody returns the body recorded for the last attempt by the
+// attemptRecorder in ctx, if there is one
+func lastResponseBody(ctx context.Context) string {
+	ar, ok := ctx.Value(attemptRecorderKey{}).(*attemptRecorder)
+	if !ok {
+		return ""
+	}
+	last, _ := ar.last()
+	return last.Body
+}
+
+// maxBodySnippet is the most of a response body that is recorded by
+// bodySnippet
+const maxBodySnippet = 256
+
+// bodySnippet reads the start of resp's body for logging, and replaces the
+// body so that it can still be read in full by the CT client. Reads are
+// subject to the same limitTransport as for successful responses. Logs
+// commonly return a JSON object with the reason in an error_message or error
+// field, in which case just the reason is returned. Otherwise the body is
+// returned on a single line, truncated to maxBodySnippet bytes.
+func bodySnippet(resp *http.Response) string {
+	start := make([]byte, maxBodySnippet+1)
+	n, _ := io.ReadFull(resp.Body, start)
+	start = start[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(start), resp.Body), resp.Body}
+
+	var detail struct {
+		ErrorMessage string `json:"error_message"`
+		Error        string `json:"error"`
+	}
+	if n <= maxBodySnippet && json.Unmarshal(start, &detail) == nil {
+		if detail.ErrorMessage != "" {
+			return detail.ErrorMessage
+		}
+		if detail.Error != "" {
+			return detail.Error
+		}
+	}
+	truncated := n > maxBodySnippet
+	if truncated {
+		start = start[:maxBodySnippet]
+	}
+	snippet := strings.Join(strings.Fields(string(start)), " ")
+	if truncated {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// defaultMaxRetryAfter is the longest Retry-After a log may ask for before it
+// is clamped, unless the log description configures a different maximum
+const defaultMaxRetryAfter = 5 * time.Minute
+
+// logTransport is the http.RoundTripper used for requests to a CT log. It
+// records the status and latency of each request to the attemptRecorder in
+// the request's context, if there is one, along with the start of the body of
+// responses other than 200.
+type logTransport struct {
+	inner http.RoundTripper
+}
+
+func (lt logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := lt.inner.RoundTrip(req)
+	if ar, ok := req.Context().Value(attemptRecorderKey{}).(*attemptRecorder); ok {
+		a := attempt{Latency: time.Since(start)}
+		if err == nil {
+			a.StatusCode = resp.StatusCode
+			if resp.StatusCode != http.StatusOK {
+				a.Body = bodySnippet(resp)
+			}
+		}
+		ar.record(a)
+	}
+	return resp, err
+}
+
+// retryAfter parses a Retry-After header value from the log, which RFC 7231
+// allows to be either a number of seconds or an HTTP-date, and clamps the
+// result to the log's maxRetryAfter. It returns false if the value can't be
+// parsed, is negative or is a date that isn't after now, so that the caller's
+// own backoff applies rather than retrying without a wait.
+func (ctLog *Log) retryAfter(value string, now time.Time) (time.Duration, bool) {
+	var d time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		d = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		d = date.Sub(now)
+		if d <= 0 {
+			return 0, false
+		}
+	} else {
+		return 0, false
+	}
+	if d > ctLog.maxRetryAfter {
+		d = ctLog.maxRetryAfter
+	}
+	return d, true
+}
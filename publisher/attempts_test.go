@@ -0,0 +1,66 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestRetryAfterParsing(t *testing.T) {
+	ctLog := &Log{maxRetryAfter: time.Minute}
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"10", 10 * time.Second, true},
+		{"0", 0, true},
+		{"-5", 0, false},
+		{"100000", time.Minute, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(30 * time.Second).Format(time.RFC850), 30 * time.Second, true},
+		{now.Add(30 * time.Second).Format(time.ANSIC), 30 * time.Second, true},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, false},
+		{now.Add(24 * time.Hour).Format(http.TimeFormat), time.Minute, true},
+		{"soon", 0, false},
+	}
+	for _, tc := range testCases {
+		d, ok := ctLog.retryAfter(tc.value, now)
+		test.AssertEquals(t, ok, tc.ok)
+		test.AssertEquals(t, d, tc.expected)
+	}
+}
+
+func TestRetryAfterClamped(t *testing.T) {
+	pub, leaf, k := setup(t)
+	// The log asks for an hour between retries, which should be clamped to the
+	// configured maximum of one second
+	retryAfter := 3600
+	server := retryableLogSrv(leaf.Raw, k, 1, &retryAfter)
+	defer server.Close()
+	port, err := getPort(server)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	ctLog, err := NewLog(cmd.LogDescription{
+		URI:           fmt.Sprintf("http://localhost:%d/ct", port),
+		Key:           base64.StdEncoding.EncodeToString(der),
+		MaxRetryAfter: cmd.ConfigDuration{Duration: time.Second},
+	}, nil, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+
+	start := time.Now()
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	took := time.Since(start)
+	test.Assert(t, took >= time.Second && took < 10*time.Second,
+		fmt.Sprintf("Retry-After wasn't clamped to the configured maximum, submission took %s", took))
+}
@@ -0,0 +1,325 @@
+package publisher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	mrand "math/rand"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+const (
+	defaultBackoffBase   = time.Second
+	defaultBackoffFactor = 2.0
+	defaultBackoffMax    = 128 * time.Second
+)
+
+// backoff is the policy for waiting between retries of a submission to a CT
+// log: exponential backoff with full jitter. It also has the optional random
+// delay before the first attempt of a submission.
+type backoff struct {
+	base   time.Duration
+	factor float64
+	max    time.Duration
+	// initialMax is the longest delay before the first attempt, zero for none
+	initialMax time.Duration
+	// jitter returns a random duration in [0, d], it is replaceable for tests
+	jitter func(d time.Duration) time.Duration
+}
+
+func newBackoff(config cmd.CTConfig) backoff {
+	b := backoff{
+		base:       config.SubmissionBackoffBase.Duration,
+		factor:     config.SubmissionBackoffFactor,
+		max:        config.SubmissionBackoffMax.Duration,
+		initialMax: config.SubmissionInitialDelayMax.Duration,
+		jitter: func(d time.Duration) time.Duration {
+			return time.Duration(mrand.Int63n(int64(d) + 1))
+		},
+	}
+	if b.base == 0 {
+		b.base = defaultBackoffBase
+	}
+	if b.factor < 1 {
+		b.factor = defaultBackoffFactor
+	}
+	if b.max == 0 {
+		b.max = defaultBackoffMax
+	}
+	return b
+}
+
+// ceiling returns the longest wait before the given retry, starting at 1,
+// which grows exponentially from base up to max
+func (b backoff) ceiling(retry int) time.Duration {
+	d, max := float64(b.base), float64(b.max)
+	for ; retry > 1 && d < max; retry-- {
+		d *= b.factor
+	}
+	if d > max {
+		d = max
+	}
+	return time.Duration(d)
+}
+
+// delay returns how long to wait before the given retry. Unlike
+// core.RetryBackoff the whole delay is randomized, so that retries of many
+// certificates to a recovering log are spread out rather than bunched.
+func (b backoff) delay(retry int) time.Duration {
+	return b.jitter(b.ceiling(retry))
+}
+
+// initialDelay returns how long to wait before the first attempt of a
+// submission, with no delay unless a maximum is configured
+func (b backoff) initialDelay() time.Duration {
+	if b.initialMax <= 0 {
+		return 0
+	}
+	return b.jitter(b.initialMax)
+}
+
+// sleep waits for d on clk, returning ctx's error if it's done first
+func sleep(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	timer := clk.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// addChain submits chain to the log's add-chain endpoint, or add-pre-chain
+// for precertificates, and parses the returned SCT. Transport errors and
+// responses indicating the log is temporarily unable to accept the
+// submission, including rate limiting, are retried with backoff until ctx is
+// done. A valid Retry-After header on the response overrides the backoff, up
+// to the log's maximum, and the
+// first attempt is made after the backoff's initial delay, if any. Other
+// client error statuses are permanent and returned as ErrLogRejected, and
+// responses that are too large or aren't valid JSON, ErrMalformedResponse,
+// aren't retried either. Errors reading the response, such as the connection
+// being reset partway through the body, are transient and retried. Each retry
+// is taken from the retry budget in ctx, if there is one, and once it is
+// exhausted errRetryBudgetExhausted is returned instead.
+func (pub *Impl) addChain(ctx context.Context, ctLog *Log, entryType ct.LogEntryType, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	path := ct.AddChainPath
+	if entryType == ct.PrecertLogEntryType {
+		path = ct.AddPreChainPath
+	}
+	var req ct.AddChainRequest
+	for _, link := range chain {
+		req.Chain = append(req.Chain, link.Data)
+	}
+
+	if delay := pub.backoff.initialDelay(); delay > 0 {
+		if err := sleep(ctx, pub.clk, delay); err != nil {
+			return nil, err
+		}
+	}
+	for retry := 1; ; retry++ {
+		// The response is only decoded once its Content-Type has been checked
+		var raw json.RawMessage
+		httpResp, err := pub.doRequest(ctx, ctLog, http.MethodPost, path, nil, &req, &raw)
+		wait := pub.backoff.delay(retry)
+		switch {
+		case err != nil:
+			if _, ok := err.(ErrThrottled); ok {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if _, ok := err.(errResponseTooLarge); ok {
+				return nil, err
+			}
+			if isMalformedJSON(err) {
+				return nil, ErrMalformedResponse{LogURI: ctLog.uri, Err: err}
+			}
+			pub.log.Info(fmt.Sprintf("Request to CT log at %s failed, backing off for %s: %s", ctLog.uri, wait, err))
+		case httpResp.StatusCode == http.StatusOK:
+			return parseAddChainResponse(httpResp.Header, raw)
+		case httpResp.StatusCode == http.StatusRequestTimeout,
+			httpResp.StatusCode == http.StatusTooManyRequests,
+			httpResp.StatusCode == http.StatusServiceUnavailable:
+			if value := httpResp.Header.Get("Retry-After"); value != "" {
+				if retryAfter, ok := ctLog.retryAfter(value, pub.clk.Now()); ok {
+					wait = retryAfter
+				}
+			}
+			msg := fmt.Sprintf("CT log at %s returned HTTP status %q, backing off for %s", ctLog.uri, httpResp.Status, wait)
+			if body := lastResponseBody(ctx); body != "" {
+				msg = fmt.Sprintf("%s: %s", msg, body)
+			}
+			pub.log.Info(msg)
+		case httpResp.StatusCode >= 400 && httpResp.StatusCode < 500:
+			return nil, ErrLogRejected{LogURI: ctLog.uri, Status: httpResp.StatusCode, Body: lastResponseBody(ctx)}
+		default:
+			return nil, statusError(ctx, httpResp)
+		}
+
+		if !retryBudgetFrom(ctx).take(pub.clk.Now().Add(wait)) {
+			pub.log.Info(fmt.Sprintf("Not retrying request to CT log at %s: %s", ctLog.uri, errRetryBudgetExhausted))
+			return nil, errRetryBudgetExhausted
+		}
+		if err := sleep(ctx, pub.clk, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isMalformedJSON returns true if err is from decoding a response body that
+// was read in full but isn't valid JSON, rather than from reading the body
+func isMalformedJSON(err error) bool {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return true
+	}
+	return false
+}
+
+// addChainResponse is ct.AddChainResponse with the id and signature left
+// base64 encoded, so that they can be decoded with decodeBase64
+type addChainResponse struct {
+	SCTVersion ct.Version `json:"sct_version"`
+	ID         string     `json:"id"`
+	Timestamp  uint64     `json:"timestamp"`
+	Extensions string     `json:"extensions"`
+	Signature  string     `json:"signature"`
+}
+
+// parseAddChainResponse parses the SCT from an add-chain or add-pre-chain
+// response. Fields the log omitted are rejected here rather than leaving an
+// SCT that fails to verify for no obvious reason.
+func parseAddChainResponse(header http.Header, raw json.RawMessage) (*ct.SignedCertificateTimestamp, error) {
+	contentType := header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return nil, fmt.Errorf("response has Content-Type %q, expected application/json", contentType)
+	}
+	var resp addChainResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshaling response: %s", err)
+	}
+	// The signature of an SCT is reconstructed according to its version, so
+	// an SCT of another version can't be verified
+	if err := checkSCTVersion(resp.SCTVersion); err != nil {
+		return nil, err
+	}
+	id, err := decodeBase64(resp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding SCT id: %s", err)
+	}
+	if len(id) == 0 {
+		return nil, errMissingSCTField("id")
+	}
+	if len(id) != sha256.Size {
+		return nil, fmt.Errorf("SCT id is %d bytes, expected %d", len(id), sha256.Size)
+	}
+	signature, err := decodeBase64(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("decoding SCT signature: %s", err)
+	}
+	if len(signature) == 0 {
+		return nil, errMissingSCTField("signature")
+	}
+	var ds ct.DigitallySigned
+	rest, err := ctTLS.Unmarshal(signature, &ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data (%d bytes) after DigitallySigned", len(rest))
+	}
+	// The extensions are covered by the signature, so they are kept as the log
+	// returned them
+	extensions, err := decodeBase64(resp.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("decoding SCT extensions: %s", err)
+	}
+	if len(extensions) > math.MaxUint16 {
+		return nil, fmt.Errorf("SCT extensions are %d bytes, the maximum is %d", len(extensions), math.MaxUint16)
+	}
+	sct := &ct.SignedCertificateTimestamp{
+		SCTVersion: resp.SCTVersion,
+		Timestamp:  resp.Timestamp,
+		Extensions: ct.CTExtensions(extensions),
+		Signature:  ds,
+	}
+	copy(sct.LogID.KeyID[:], id)
+	return sct, nil
+}
+
+// base64Encodings are the encodings decodeBase64 tries, in order. RFC 6962
+// requires standard base64, but some logs use the URL-safe alphabet or leave
+// out the padding.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// decodeBase64 decodes base64 data from a log with the first of
+// base64Encodings that accepts it. Whitespace, which the encodings would
+// otherwise skip, is rejected. If no encoding accepts the data the error from
+// standard base64 is returned.
+func decodeBase64(s string) ([]byte, error) {
+	if i := strings.IndexAny(s, " \t\r\n"); i >= 0 {
+		return nil, fmt.Errorf("illegal whitespace in base64 data at input byte %d", i)
+	}
+	var stdErr error
+	for _, enc := range base64Encodings {
+		data, err := enc.DecodeString(s)
+		if err == nil {
+			return data, nil
+		}
+		if stdErr == nil {
+			stdErr = err
+		}
+	}
+	return nil, stdErr
+}
+
+// supportedSCTVersions are the SCT versions the publisher can verify the
+// signatures of
+var supportedSCTVersions = []ct.Version{ct.V1}
+
+// checkSCTVersion returns errUnsupportedSCTVersion if version isn't one of
+// supportedSCTVersions
+func checkSCTVersion(version ct.Version) error {
+	for _, supported := range supportedSCTVersions {
+		if version == supported {
+			return nil
+		}
+	}
+	return errUnsupportedSCTVersion(version)
+}
+
+// errUnsupportedSCTVersion is returned for a response with an SCT of a
+// version the publisher doesn't support
+type errUnsupportedSCTVersion ct.Version
+
+func (e errUnsupportedSCTVersion) Error() string {
+	return fmt.Sprintf("unsupported SCT version %d, supported versions are %v", uint8(e), supportedSCTVersions)
+}
+
+// errMissingSCTField is returned for a response without the named SCT field,
+// or with the field empty
+type errMissingSCTField string
+
+func (e errMissingSCTField) Error() string {
+	return fmt.Sprintf("response has no SCT %s", string(e))
+}
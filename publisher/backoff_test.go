@@ -0,0 +1,278 @@
+package publisher
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(cmd.CTConfig{})
+	test.AssertEquals(t, b.base, defaultBackoffBase)
+	test.AssertEquals(t, b.factor, defaultBackoffFactor)
+	test.AssertEquals(t, b.max, defaultBackoffMax)
+
+	b = newBackoff(cmd.CTConfig{
+		SubmissionBackoffBase:   cmd.ConfigDuration{Duration: time.Second},
+		SubmissionBackoffFactor: 3,
+		SubmissionBackoffMax:    cmd.ConfigDuration{Duration: 30 * time.Second},
+	})
+	expected := []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 27 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, ceiling := range expected {
+		test.AssertEquals(t, b.ceiling(i+1), ceiling)
+	}
+
+	var prev time.Duration
+	for retry := 1; retry < 100; retry++ {
+		ceiling := b.ceiling(retry)
+		test.Assert(t, ceiling >= prev, fmt.Sprintf("Backoff shrank from %s to %s at retry %d", prev, ceiling, retry))
+		prev = ceiling
+		for i := 0; i < 10; i++ {
+			delay := b.delay(retry)
+			test.Assert(t, delay >= 0 && delay <= ceiling && delay <= b.max,
+				fmt.Sprintf("Delay %s for retry %d is outside [0, %s]", delay, retry, ceiling))
+		}
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	pub, leaf, k := setup(t)
+	// A backoff this long would fail the test, so the log's Retry-After of
+	// zero must have been used instead
+	pub.backoff = newBackoff(cmd.CTConfig{
+		SubmissionBackoffBase: cmd.ConfigDuration{Duration: time.Hour},
+		SubmissionBackoffMax:  cmd.ConfigDuration{Duration: time.Hour},
+	})
+	pub.backoff.jitter = func(d time.Duration) time.Duration { return d }
+	retryAfter := 0
+	server := retryableLogSrv(leaf.Raw, k, 2, &retryAfter)
+	defer server.Close()
+	port, err := getPort(server)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	start := time.Now()
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	test.Assert(t, time.Since(start) < 5*time.Second, "Retry-After didn't override the backoff")
+}
+
+func TestInvalidRetryAfterKeepsBackoff(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.backoff = newBackoff(cmd.CTConfig{
+		SubmissionBackoffBase: cmd.ConfigDuration{Duration: time.Hour},
+		SubmissionBackoffMax:  cmd.ConfigDuration{Duration: time.Hour},
+	})
+	pub.backoff.jitter = func(d time.Duration) time.Duration { return d }
+	pub.submissionTimeout = 100 * time.Millisecond
+	// A negative Retry-After is ignored, so the hour long backoff outlasts the
+	// submission timeout rather than the log being retried without a wait
+	retryAfter := -5
+	server := retryableLogSrv(leaf.Raw, k, 1000, &retryAfter)
+	defer server.Close()
+	port, err := getPort(server)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Submission to a failing log succeeded")
+	test.AssertEquals(t, result.Attempts, 1)
+}
+
+func TestInitialDelay(t *testing.T) {
+	b := newBackoff(cmd.CTConfig{})
+	test.AssertEquals(t, b.initialDelay(), time.Duration(0))
+
+	max := 50 * time.Millisecond
+	b = newBackoff(cmd.CTConfig{SubmissionInitialDelayMax: cmd.ConfigDuration{Duration: max}})
+	for i := 0; i < 100; i++ {
+		delay := b.initialDelay()
+		test.Assert(t, delay >= 0 && delay <= max, fmt.Sprintf("Initial delay %s is outside [0, %s]", delay, max))
+	}
+
+	// The delay is made before the first attempt, and cut short by the
+	// submission deadline
+	pub, leaf, k := setup(t)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	pub.backoff = newBackoff(cmd.CTConfig{SubmissionInitialDelayMax: cmd.ConfigDuration{Duration: time.Hour}})
+	pub.backoff.jitter = func(d time.Duration) time.Duration { return d }
+	pub.submissionTimeout = 100 * time.Millisecond
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Submission wasn't delayed")
+	test.AssertEquals(t, result.Attempts, 0)
+}
+
+func TestParseAddChainResponse(t *testing.T) {
+	_, leaf, k := setup(t)
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	var valid map[string]interface{}
+	err := json.Unmarshal([]byte(createSignedSCT(leaf.Raw, k)), &valid)
+	test.AssertNotError(t, err, "Failed to unmarshal test SCT")
+	_, err = parseAddChainResponse(header, json.RawMessage(createSignedSCT(leaf.Raw, k)))
+	test.AssertNotError(t, err, "Failed to parse valid response")
+
+	testCases := []struct {
+		field    string
+		value    interface{}
+		expected string
+	}{
+		{"id", nil, "response has no SCT id"},
+		{"id", "", "response has no SCT id"},
+		{"id", "AQID", "SCT id is 3 bytes, expected 32"},
+		{"signature", nil, "response has no SCT signature"},
+		{"signature", "", "response has no SCT signature"},
+		{"sct_version", 1, "unsupported SCT version 1, supported versions are [V1]"},
+		{"extensions", "not base64", "decoding SCT extensions: illegal whitespace in base64 data at input byte 3"},
+		{"extensions", "abc!", "decoding SCT extensions: illegal base64 data at input byte 3"},
+		{"id", "a b", "decoding SCT id: illegal whitespace in base64 data at input byte 1"},
+		{"extensions", base64.StdEncoding.EncodeToString(make([]byte, math.MaxUint16+1)), "SCT extensions are 65536 bytes, the maximum is 65535"},
+	}
+	for _, tc := range testCases {
+		resp := make(map[string]interface{})
+		for field, value := range valid {
+			resp[field] = value
+		}
+		if tc.value == nil {
+			delete(resp, tc.field)
+		} else {
+			resp[tc.field] = tc.value
+		}
+		raw, err := json.Marshal(resp)
+		test.AssertNotError(t, err, "Failed to marshal response")
+		_, err = parseAddChainResponse(header, raw)
+		test.AssertError(t, err, fmt.Sprintf("Response with %s of %v was accepted", tc.field, tc.value))
+		test.AssertEquals(t, err.Error(), tc.expected)
+	}
+
+	// The signature's length field must match the signature that follows it
+	sig, err := base64.StdEncoding.DecodeString(valid["signature"].(string))
+	test.AssertNotError(t, err, "Failed to decode test signature")
+	for _, delta := range []int{-1, 1} {
+		bad := append([]byte{}, sig...)
+		binary.BigEndian.PutUint16(bad[2:4], uint16(len(sig)-4+delta))
+		resp := make(map[string]interface{})
+		for field, value := range valid {
+			resp[field] = value
+		}
+		resp["signature"] = base64.StdEncoding.EncodeToString(bad)
+		raw, err := json.Marshal(resp)
+		test.AssertNotError(t, err, "Failed to marshal response")
+		_, err = parseAddChainResponse(header, raw)
+		test.AssertError(t, err, fmt.Sprintf("Signature with a length field off by %d was accepted", delta))
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	// 0xfb 0xff encodes with both of the characters that differ between the
+	// standard and URL-safe alphabets, and needs padding
+	data := []byte{0xfb, 0xff}
+	for _, encoded := range []string{"+/8=", "+/8", "-_8=", "-_8"} {
+		decoded, err := decodeBase64(encoded)
+		test.AssertNotError(t, err, fmt.Sprintf("Failed to decode %q", encoded))
+		test.AssertByteEquals(t, decoded, data)
+	}
+	for _, bad := range []string{"+/8\n", " +/8=", "+_8=", "!!!!"} {
+		_, err := decodeBase64(bad)
+		test.AssertError(t, err, fmt.Sprintf("Decoded invalid base64 %q", bad))
+	}
+	_, err := decodeBase64("not base64")
+	test.AssertEquals(t, err.Error(), "illegal whitespace in base64 data at input byte 3")
+	_, err = decodeBase64("abc!")
+	test.AssertEquals(t, err.Error(), "illegal base64 data at input byte 3")
+}
+
+func TestParseAddChainResponseEncodings(t *testing.T) {
+	_, leaf, k := setup(t)
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	signed := createSignedSCT(leaf.Raw, k)
+	var valid map[string]interface{}
+	err := json.Unmarshal([]byte(signed), &valid)
+	test.AssertNotError(t, err, "Failed to unmarshal test SCT")
+	expected, err := parseAddChainResponse(header, json.RawMessage(signed))
+	test.AssertNotError(t, err, "Failed to parse valid response")
+
+	// The id and signature are accepted in each encoding some logs use
+	for _, enc := range base64Encodings[1:] {
+		resp := make(map[string]interface{})
+		for field, value := range valid {
+			resp[field] = value
+		}
+		for _, field := range []string{"id", "signature"} {
+			data, err := base64.StdEncoding.DecodeString(valid[field].(string))
+			test.AssertNotError(t, err, "Failed to decode test field")
+			resp[field] = enc.EncodeToString(data)
+		}
+		raw, err := json.Marshal(resp)
+		test.AssertNotError(t, err, "Failed to marshal response")
+		sct, err := parseAddChainResponse(header, raw)
+		test.AssertNotError(t, err, fmt.Sprintf("Failed to parse response encoded as %s", raw))
+		test.AssertDeepEquals(t, sct, expected)
+	}
+}
+
+func TestSCTExtensions(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	extensions := []byte{0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x2a}
+	sct := createSignedSCTWithExtensions(leaf.Raw, k, nowTimestamp(), extensions)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// The extensions are part of the signed data, so the SCT only verifies if
+	// they are kept
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "SCT with extensions was rejected")
+	test.AssertByteEquals(t, scts[0].Extensions, extensions)
+	stored, err := pub.storage.Load(ctx, core.SerialToString(leaf.SerialNumber))
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertByteEquals(t, stored[0].Extensions, extensions)
+
+	serialized, err := stored[0].MarshalBinary()
+	test.AssertNotError(t, err, "Failed to serialize SCT")
+	var decoded core.SignedCertificateTimestamp
+	err = decoded.UnmarshalBinary(serialized)
+	test.AssertNotError(t, err, "Failed to deserialize SCT")
+	test.AssertByteEquals(t, decoded.Extensions, extensions)
+}
+
+func TestUnsupportedSCTVersion(t *testing.T) {
+	pub, leaf, k := setup(t)
+	// The version is checked before the signature, which can only be
+	// reconstructed for a supported version
+	sct := strings.Replace(createSignedSCT(leaf.Raw, k), `"sct_version":0`, `"sct_version":1`, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	report, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertError(t, err, "SCT with unsupported version was accepted")
+	test.AssertEquals(t, report.Results[0].Err, error(errUnsupportedSCTVersion(1)))
+}
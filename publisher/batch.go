@@ -0,0 +1,148 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// batchConcurrency bounds the number of certificates SubmitBatch submits at
+// once, so that a large batch doesn't fill the submission pool's queue and
+// starve other submissions
+const batchConcurrency = 10
+
+// BatchResult is the outcome of submitting one of the certificates passed to
+// SubmitBatch: the SCTs that were obtained for it and, if its submission
+// failed, the error CollectSCTs would have returned
+type BatchResult struct {
+	Serial string
+	SCTs   []LogSCT
+	Err    error
+}
+
+// SubmitBatch submits each of certs to every configured CT log, as
+// CollectSCTs does, for jobs such as backfilling the SCTs of certificates
+// that have already been issued. Precertificates are submitted to the
+// add-pre-chain endpoint. A failure for one certificate doesn't stop the
+// others being submitted, and the results are returned in the same order as
+// certs. The returned error is only set if the batch couldn't be completed,
+// e.g. because ctx finished, in which case the certificates that weren't
+// submitted have that error as their result.
+func (pub *Impl) SubmitBatch(ctx context.Context, certs []*x509.Certificate) ([]BatchResult, error) {
+	ctx, done, err := pub.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return pub.runBatch(ctx, certs, func(ctx context.Context, cert *x509.Certificate) ([]LogSCT, error) {
+		report, err := pub.submitCert(ctx, entryTypeFor(cert), cert)
+		return report.SCTs, err
+	})
+}
+
+// BackfillForLog submits each of certs to the configured CT log with the
+// given URI only, e.g. after the log has been added to the config, so that
+// certificates issued before then get an SCT from it without being reissued.
+// The SCTs are stored as for any other submission, and certificates that
+// already have a stored SCT from the log aren't submitted again, so the
+// result for them is the stored SCT. Certificates outside the log's temporal
+// shard fail with ErrNoTemporalShard and the requests are subject to the
+// log's rate limit. The results and the returned error are as for
+// SubmitBatch, except that an error is returned without any results if the
+// log isn't configured or is disabled.
+func (pub *Impl) BackfillForLog(ctx context.Context, logURI string, certs []*x509.Certificate) ([]BatchResult, error) {
+	ctx, done, err := pub.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctLog, err := pub.logByURI(logURI)
+	if err != nil {
+		return nil, err
+	}
+	if ctLog.disabled {
+		return nil, fmt.Errorf("CT log at %s is disabled", logURI)
+	}
+	return pub.runBatch(ctx, certs, func(ctx context.Context, cert *x509.Certificate) ([]LogSCT, error) {
+		return pub.backfillCert(ctx, ctLog, cert)
+	})
+}
+
+// backfillCert submits cert to ctLog for BackfillForLog, unless an SCT for it
+// from the log has already been stored
+func (pub *Impl) backfillCert(ctx context.Context, ctLog *Log, cert *x509.Certificate) ([]LogSCT, error) {
+	serial := core.SerialToString(cert.SerialNumber)
+	stored, err := pub.storage.Load(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("loading stored SCTs for %s: %s", serial, err)
+	}
+	for _, sct := range stored {
+		if sct.LogURI == ctLog.uri {
+			return []LogSCT{sct}, nil
+		}
+	}
+	logs, err := pub.logsFor(cert, []*Log{ctLog})
+	if err == nil {
+		err = pub.checkSubmittable(cert)
+	}
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not backfilling certificate to CT log at %s: %s", ctLog.uri, err)))
+		return nil, err
+	}
+	entryType := entryTypeFor(cert)
+	if pub.dryRun {
+		pub.logDryRun(ctx, logs, entryType, cert)
+		return nil, nil
+	}
+	results := pub.submitToLogs(ctx, logs, entryType, cert)
+	pub.storeSCTs(ctx, logs, cert, results)
+	result := results[0]
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return []LogSCT{*result.SCT}, nil
+}
+
+// runBatch calls submit for each of certs, up to batchConcurrency at once,
+// and returns the results in the same order as certs, as described by
+// SubmitBatch
+func (pub *Impl) runBatch(ctx context.Context, certs []*x509.Certificate, submit func(context.Context, *x509.Certificate) ([]LogSCT, error)) ([]BatchResult, error) {
+	results := make([]BatchResult, len(certs))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, cert := range certs {
+		results[i].Serial = core.SerialToString(cert.SerialNumber)
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, cert *x509.Certificate) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].SCTs, results[i].Err = submit(ctx, cert)
+		}(i, cert)
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// entryTypeFor returns the type of log entry cert is submitted as, so that
+// precertificates are submitted to the add-pre-chain endpoint
+func entryTypeFor(cert *x509.Certificate) ct.LogEntryType {
+	if isPrecert(cert) {
+		return ct.PrecertLogEntryType
+	}
+	return ct.X509LogEntryType
+}
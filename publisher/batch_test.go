@@ -0,0 +1,107 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/publisher/testlog"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSubmitBatch(t *testing.T) {
+	pub, leaf, k := setup(t)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// The second certificate isn't from the configured issuer, which fails its
+	// submission without affecting the others
+	otherKey := testKey(t)
+	other := issueTestCert(t, "other issuer", true, &otherKey.PublicKey, nil, otherKey)
+	results, err := pub.SubmitBatch(ctx, []*x509.Certificate{leaf, other, leaf})
+	test.AssertNotError(t, err, "Batch failed")
+	test.AssertEquals(t, len(results), 3)
+	for _, i := range []int{0, 2} {
+		test.AssertNotError(t, results[i].Err, "Submission in batch failed")
+		test.AssertEquals(t, results[i].Serial, core.SerialToString(leaf.SerialNumber))
+		test.AssertEquals(t, len(results[i].SCTs), 1)
+	}
+	test.AssertError(t, results[1].Err, "Submission of a certificate from another issuer succeeded")
+	test.AssertEquals(t, results[1].Serial, core.SerialToString(other.SerialNumber))
+	test.AssertEquals(t, len(results[1].SCTs), 0)
+
+	// Once ctx is done the remaining certificates aren't submitted
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	results, err = pub.SubmitBatch(canceled, []*x509.Certificate{leaf})
+	test.AssertEquals(t, err, context.Canceled)
+	test.AssertEquals(t, results[0].Err, context.Canceled)
+}
+
+func TestBackfillForLog(t *testing.T) {
+	pub, leaf, _ := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	serial := core.SerialToString(leaf.SerialNumber)
+	existing, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer existing.Close()
+	ctLog, err := NewLog(existing.Description(), pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+	_, err = pub.SubmitBatch(ctx, []*x509.Certificate{leaf})
+	test.AssertNotError(t, err, "Batch failed")
+
+	// Only the new log is submitted to
+	added, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer added.Close()
+	ctLog, err = NewLog(added.Description(), pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+	results, err := pub.BackfillForLog(ctx, ctLog.uri, []*x509.Certificate{leaf})
+	test.AssertNotError(t, err, "Backfill failed")
+	test.AssertEquals(t, len(results), 1)
+	test.AssertNotError(t, results[0].Err, "Backfill of certificate failed")
+	test.AssertEquals(t, results[0].Serial, serial)
+	test.AssertEquals(t, len(results[0].SCTs), 1)
+	test.AssertEquals(t, results[0].SCTs[0].LogURI, ctLog.uri)
+	test.AssertEquals(t, existing.Submissions(), 1)
+	test.AssertEquals(t, added.Submissions(), 1)
+	stored, err := pub.storage.Load(ctx, serial)
+	test.AssertNotError(t, err, "Failed to load stored SCTs")
+	test.AssertEquals(t, len(stored), 2)
+
+	// A certificate with a stored SCT from the log isn't submitted again
+	results, err = pub.BackfillForLog(ctx, ctLog.uri, []*x509.Certificate{leaf})
+	test.AssertNotError(t, err, "Repeated backfill failed")
+	test.AssertNotError(t, results[0].Err, "Repeated backfill of certificate failed")
+	test.AssertEquals(t, results[0].SCTs[0].LogURI, ctLog.uri)
+	test.AssertEquals(t, added.Submissions(), 1)
+
+	// Nor is one outside the log's temporal shard
+	shard, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer shard.Close()
+	ld := shard.Description()
+	ld.TemporalInterval = &cmd.TemporalInterval{
+		StartInclusive: leaf.NotAfter.Add(time.Hour),
+		EndExclusive:   leaf.NotAfter.Add(2 * time.Hour),
+	}
+	ctLog, err = NewLog(ld, pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create temporal shard")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+	results, err = pub.BackfillForLog(ctx, ctLog.uri, []*x509.Certificate{leaf})
+	test.AssertNotError(t, err, "Backfill failed")
+	test.AssertEquals(t, results[0].Err, error(ErrNoTemporalShard{Serial: serial, NotAfter: leaf.NotAfter}))
+	test.AssertEquals(t, shard.Submissions(), 0)
+
+	_, err = pub.BackfillForLog(ctx, "https://unknown.example.com", []*x509.Certificate{leaf})
+	test.AssertError(t, err, "Backfill to a log that isn't configured succeeded")
+}
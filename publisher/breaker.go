@@ -0,0 +1,161 @@
+package publisher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultCircuitBreakerCooldown is how long a log's circuit breaker stays
+// open when no cooldown is configured
+const defaultCircuitBreakerCooldown = time.Minute
+
+// errCircuitOpen is the error wrapped in ErrLogUnavailable for a submission
+// that wasn't made because the log's circuit breaker is open
+var errCircuitOpen = errors.New("circuit breaker is open after repeated failures")
+
+// The states of a log's circuit breaker, which are also the values of the
+// ct_log_circuit_breaker_state gauge
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// The outcomes of a submission recorded by a circuit breaker. A submission is
+// abandoned when it was cut short by its caller, which says nothing either way
+// about the log.
+const (
+	breakerSuccess = iota
+	breakerFailure
+	breakerAbandoned
+)
+
+// circuitBreakers holds a circuit breaker for each log, by URI, so that a
+// log's breaker is kept when the logs are reloaded. A breaker opens after
+// threshold consecutive submissions to its log fail because the log is
+// unavailable, after which submissions fail immediately until cooldown has
+// passed. The breaker then half-opens, letting a single submission through to
+// test whether the log has recovered, which closes the breaker if it succeeds
+// and opens it again if not. A threshold of zero disables the breakers.
+type circuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+	state     *prometheus.GaugeVec
+
+	mu   sync.Mutex
+	logs map[string]*breaker
+}
+
+type breaker struct {
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreakers(threshold int, cooldown time.Duration, state *prometheus.GaugeVec) *circuitBreakers {
+	if cooldown <= 0 {
+		cooldown = defaultCircuitBreakerCooldown
+	}
+	return &circuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     state,
+		logs:      make(map[string]*breaker),
+	}
+}
+
+// get returns the breaker for the log with the given URI, the caller must
+// hold mu
+func (cb *circuitBreakers) get(uri string) *breaker {
+	b, ok := cb.logs[uri]
+	if !ok {
+		b = &breaker{}
+		cb.logs[uri] = b
+	}
+	return b
+}
+
+func (cb *circuitBreakers) setState(uri string, b *breaker, state int) {
+	b.state = state
+	cb.state.With(prometheus.Labels{"log": uri}).Set(float64(state))
+}
+
+// allow returns whether a submission to the log with the given URI may be
+// made at now. A submission that is allowed must be followed by a call to
+// record with its outcome.
+func (cb *circuitBreakers) allow(uri string, now time.Time) bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b := cb.get(uri)
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openedAt.Add(cb.cooldown)) {
+			return false
+		}
+		cb.setState(uri, b, breakerHalfOpen)
+		return true
+	case breakerHalfOpen:
+		// Only the submission testing the log is let through
+		return false
+	}
+	return true
+}
+
+// record updates the breaker for the log with the given URI with the outcome
+// of a submission made at now, returning true if the breaker opened
+func (cb *circuitBreakers) record(uri string, outcome int, now time.Time) bool {
+	if cb.threshold <= 0 {
+		return false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b := cb.get(uri)
+	switch outcome {
+	case breakerSuccess:
+		b.failures = 0
+		if b.state != breakerClosed {
+			cb.setState(uri, b, breakerClosed)
+		}
+	case breakerFailure:
+		b.failures++
+		if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= cb.threshold) {
+			b.openedAt = now
+			cb.setState(uri, b, breakerOpen)
+			return true
+		}
+	case breakerAbandoned:
+		// An abandoned test of the log is retried by the next submission
+		if b.state == breakerHalfOpen {
+			b.openedAt = now.Add(-cb.cooldown)
+			cb.setState(uri, b, breakerOpen)
+		}
+	}
+	return false
+}
+
+// breakerOutcome returns the outcome of a submission for the log's circuit
+// breaker, given its classified error and whether the submission's context
+// was done. Only failures that show the log is unavailable count against it,
+// and only a submission the log answered, by returning an SCT or rejecting
+// the certificate, shows that it is available. Other errors, such as a
+// submission throttled by the log's rate limit, an SCT with a bad timestamp
+// or a failure to store the SCT, say nothing either way, so are treated as
+// abandoned.
+func breakerOutcome(err error, abandoned bool) int {
+	switch err.(type) {
+	case nil, ErrLogRejected:
+		return breakerSuccess
+	case ErrLogUnavailable, ErrRetryExhausted:
+		if abandoned {
+			return breakerAbandoned
+		}
+		return breakerFailure
+	}
+	return breakerAbandoned
+}
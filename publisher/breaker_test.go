@@ -0,0 +1,141 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func breakerGauge(t *testing.T, cb *circuitBreakers, uri string) int {
+	ch := make(chan prometheus.Metric, 1)
+	cb.state.With(prometheus.Labels{"log": uri}).Collect(ch)
+	var m io_prometheus_client.Metric
+	test.AssertNotError(t, (<-ch).Write(&m), "Failed to read gauge")
+	return int(m.Gauge.GetValue())
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	state := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_breaker_state"}, []string{"log"})
+	cb := newCircuitBreakers(2, time.Minute, state)
+	uri := "https://log.example.com"
+	now := time.Now()
+
+	// A success resets the count of consecutive failures
+	test.Assert(t, cb.allow(uri, now), "Closed breaker refused a submission")
+	test.Assert(t, !cb.record(uri, breakerFailure, now), "Breaker opened after one failure")
+	cb.record(uri, breakerSuccess, now)
+	test.Assert(t, !cb.record(uri, breakerFailure, now), "Breaker opened after one failure")
+	test.Assert(t, cb.record(uri, breakerFailure, now), "Breaker didn't open after two failures")
+	test.AssertEquals(t, breakerGauge(t, cb, uri), breakerOpen)
+	test.Assert(t, !cb.allow(uri, now.Add(59*time.Second)), "Open breaker allowed a submission")
+
+	// After the cooldown a single submission tests the log, and failing it
+	// opens the breaker again
+	test.Assert(t, cb.allow(uri, now.Add(time.Minute)), "Breaker didn't half-open after the cooldown")
+	test.AssertEquals(t, breakerGauge(t, cb, uri), breakerHalfOpen)
+	test.Assert(t, !cb.allow(uri, now.Add(time.Minute)), "Half-open breaker allowed a second submission")
+	now = now.Add(time.Minute)
+	test.Assert(t, cb.record(uri, breakerFailure, now), "Failed test didn't open the breaker")
+	test.Assert(t, !cb.allow(uri, now.Add(time.Second)), "Reopened breaker allowed a submission")
+
+	// An abandoned test is retried straight away, and a successful one closes
+	// the breaker
+	now = now.Add(time.Minute)
+	test.Assert(t, cb.allow(uri, now), "Breaker didn't half-open after the cooldown")
+	cb.record(uri, breakerAbandoned, now)
+	test.Assert(t, cb.allow(uri, now), "Abandoned test wasn't retried")
+	cb.record(uri, breakerSuccess, now)
+	test.AssertEquals(t, breakerGauge(t, cb, uri), breakerClosed)
+	test.Assert(t, cb.allow(uri, now), "Closed breaker refused a submission")
+
+	// Other logs have their own breakers
+	test.Assert(t, cb.allow("https://other.example.com", now), "Another log's breaker was shared")
+
+	disabled := newCircuitBreakers(0, 0, state)
+	for i := 0; i < 10; i++ {
+		disabled.record(uri, breakerFailure, now)
+	}
+	test.Assert(t, disabled.allow(uri, now), "Disabled breaker refused a submission")
+}
+
+func TestBreakerOutcome(t *testing.T) {
+	test.AssertEquals(t, breakerOutcome(nil, false), breakerSuccess)
+	test.AssertEquals(t, breakerOutcome(ErrLogUnavailable{}, false), breakerFailure)
+	test.AssertEquals(t, breakerOutcome(ErrRetryExhausted{}, false), breakerFailure)
+	test.AssertEquals(t, breakerOutcome(ErrLogUnavailable{}, true), breakerAbandoned)
+	// The log responded, so it is up even though it rejected the submission
+	test.AssertEquals(t, breakerOutcome(ErrLogRejected{}, false), breakerSuccess)
+	// Errors that don't show whether the log is up leave the breaker alone
+	test.AssertEquals(t, breakerOutcome(ErrThrottled{}, false), breakerAbandoned)
+	test.AssertEquals(t, breakerOutcome(ErrBadSCTTimestamp{}, false), breakerAbandoned)
+	test.AssertEquals(t, breakerOutcome(errors.New("storing SCT: SA unavailable"), false), breakerAbandoned)
+}
+
+func TestCircuitBreakerChainFailure(t *testing.T) {
+	pub, leaf, k := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+	pub.breakers = newCircuitBreakers(1, time.Minute, pub.metrics.breakerState)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	uri := pub.ctLogs[0].uri
+	pub.breakers.record(uri, breakerFailure, fc.Now())
+	fc.Add(time.Minute)
+
+	// The submission half-opening the breaker fails before reaching the log,
+	// which mustn't leave the breaker half-open for good
+	pub.ChainFor = func(*x509.Certificate) ([][]byte, error) { return nil, errors.New("no chain") }
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Submission without a chain succeeded")
+	test.AssertEquals(t, breakerGauge(t, pub.breakers, uri), breakerOpen)
+
+	pub.ChainFor = nil
+	result = pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "Submission testing the log failed")
+	test.AssertEquals(t, breakerGauge(t, pub.breakers, uri), breakerClosed)
+}
+
+func TestCircuitBreakerSubmissions(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.breakers = newCircuitBreakers(2, time.Hour, pub.metrics.breakerState)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	for i := 0; i < 2; i++ {
+		result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+		test.AssertError(t, result.Err, "Submission to a failing log succeeded")
+	}
+	test.AssertEquals(t, requests, 2)
+	test.AssertEquals(t, len(log.GetAllMatching("Circuit breaker for CT log at .* opened")), 1)
+
+	// With the breaker open the log isn't contacted
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	unavailable, ok := result.Err.(ErrLogUnavailable)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrLogUnavailable, got %T", result.Err))
+	test.AssertEquals(t, unavailable.Err, errCircuitOpen)
+	test.AssertEquals(t, result.Attempts, 0)
+	test.AssertEquals(t, requests, 2)
+	test.AssertEquals(t, count(prometheus.Labels{"log": pub.ctLogs[0].uri, "result": "short_circuited"}, pub.metrics.submissions), 1)
+}
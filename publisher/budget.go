@@ -0,0 +1,74 @@
+package publisher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// errRetryBudgetExhausted is returned by a submission to a log that needed
+// to retry after its certificate's retry budget was used up
+var errRetryBudgetExhausted = errors.New("the submission's retry budget is exhausted")
+
+// retryBudget bounds the retries of a certificate's submissions to every log
+// together, see cmd.CTConfig.SubmissionRetryBudget and SubmissionTimeBudget.
+// A nil retryBudget allows every retry.
+type retryBudget struct {
+	mu sync.Mutex
+	// retries is the number of retries left, it is unlimited if negative
+	retries int
+	// deadline is the latest time a retry may start, zero for none
+	deadline time.Time
+}
+
+// newRetryBudget returns the retry budget for a submission starting at now,
+// or nil if the publisher has no budget configured
+func (pub *Impl) newRetryBudget(now time.Time) *retryBudget {
+	if pub.retryLimit == 0 && pub.timeBudget == 0 {
+		return nil
+	}
+	rb := &retryBudget{retries: -1}
+	if pub.retryLimit > 0 {
+		rb.retries = pub.retryLimit
+	}
+	if pub.timeBudget > 0 {
+		rb.deadline = now.Add(pub.timeBudget)
+	}
+	return rb
+}
+
+// take spends a retry from the budget for a retry starting at retryAt,
+// returning false if there are no retries left or retryAt is past the
+// deadline
+func (rb *retryBudget) take(retryAt time.Time) bool {
+	if rb == nil {
+		return true
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if !rb.deadline.IsZero() && retryAt.After(rb.deadline) {
+		return false
+	}
+	if rb.retries == 0 {
+		return false
+	}
+	if rb.retries > 0 {
+		rb.retries--
+	}
+	return true
+}
+
+type retryBudgetKey struct{}
+
+func withRetryBudget(ctx context.Context, rb *retryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, rb)
+}
+
+// retryBudgetFrom returns the retry budget carried by ctx, which is nil if
+// there is none
+func retryBudgetFrom(ctx context.Context) *retryBudget {
+	rb, _ := ctx.Value(retryBudgetKey{}).(*retryBudget)
+	return rb
+}
@@ -0,0 +1,98 @@
+package publisher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestRetryBudget(t *testing.T) {
+	var unlimited *retryBudget
+	test.Assert(t, unlimited.take(time.Now()), "Nil budget refused a retry")
+
+	now := time.Now()
+	pub := &Impl{retryLimit: 2, timeBudget: time.Minute}
+	rb := pub.newRetryBudget(now)
+	test.Assert(t, rb.take(now), "First retry refused")
+	test.Assert(t, !rb.take(now.Add(2*time.Minute)), "Retry past the deadline allowed")
+	test.Assert(t, rb.take(now.Add(time.Minute)), "Retry at the deadline refused")
+	test.Assert(t, !rb.take(now), "Retry beyond the limit allowed")
+
+	pub = &Impl{}
+	test.Assert(t, pub.newRetryBudget(now) == nil, "Budget created without a configured budget")
+}
+
+func TestSubmissionRetryBudget(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.backoff.base = time.Millisecond
+	pub.retryLimit = 2
+
+	// One log succeeds at once, the others only after three retries each,
+	// which is more than the budget allows
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	for i := 0; i < 3; i++ {
+		srv := retryableLogSrv(leaf.Raw, k, 3, nil)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	report, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission with an exhausted retry budget succeeded")
+	test.AssertEquals(t, len(report.SCTs), 1)
+	test.AssertEquals(t, report.SCTs[0].LogURI, pub.ctLogs[0].uri)
+	test.AssertEquals(t, report.Retries, 2)
+	attempts := 0
+	for _, result := range report.Results[1:] {
+		test.AssertError(t, result.Err, "Submission to retrying log succeeded")
+		attempts += result.Attempts
+	}
+	test.AssertEquals(t, attempts, 5)
+	test.AssertEquals(t, len(log.GetAllMatching("Not retrying request to CT log at .*: "+errRetryBudgetExhausted.Error())), 3)
+}
+
+func TestSubmissionTimeBudget(t *testing.T) {
+	pub, leaf, k := setup(t)
+	srv := retryableLogSrv(leaf.Raw, k, 3, nil)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+	pub.backoff = newBackoff(cmd.CTConfig{SubmissionBackoffBase: cmd.ConfigDuration{Duration: time.Minute}})
+	pub.backoff.jitter = func(d time.Duration) time.Duration { return d }
+	pub.timeBudget = 90 * time.Second
+
+	// The first retry starts a minute in, but the second would start three
+	// minutes in, past the budget
+	done := make(chan error)
+	go func() {
+		done <- pub.SubmitToCT(ctx, leaf.Raw)
+	}()
+	for finished := false; !finished; {
+		select {
+		case err = <-done:
+			finished = true
+		case <-time.After(time.Millisecond):
+			fc.Add(time.Second)
+		}
+	}
+	submissionErr, ok := err.(ErrSubmissionFailed)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrSubmissionFailed, got %T", err))
+	exhausted, ok := submissionErr.Failures[0].Err.(ErrRetryExhausted)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrRetryExhausted, got %T", submissionErr.Failures[0].Err))
+	test.AssertEquals(t, exhausted.Attempts, 2)
+	test.AssertEquals(t, exhausted.Err, errRetryBudgetExhausted)
+}
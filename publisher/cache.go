@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// defaultSubmissionCacheTTL is how long the SCTs of a submission are cached
+// when the cache is enabled without a TTL
+const defaultSubmissionCacheTTL = 5 * time.Minute
+
+// submissionCache is an LRU cache of the SCTs collected for recently
+// submitted certificates, keyed by the SHA-256 hash of the certificate, so
+// that a certificate submitted again within the TTL, e.g. when a caller
+// retries, gets the same SCTs without contacting the logs. It holds up to
+// size certificates, evicting the least recently used.
+type submissionCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	// order has the most recently used entry at the front
+	order *list.List
+}
+
+type submissionCacheEntry struct {
+	key     [sha256.Size]byte
+	scts    []LogSCT
+	expires time.Time
+}
+
+// newSubmissionCache returns a submissionCache holding up to size
+// certificates for ttl, which defaults to defaultSubmissionCacheTTL. It
+// returns nil, for no cache, if size is zero.
+func newSubmissionCache(size int, ttl time.Duration) *submissionCache {
+	if size <= 0 {
+		return nil
+	}
+	if ttl == 0 {
+		ttl = defaultSubmissionCacheTTL
+	}
+	return &submissionCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the SCTs cached for the certificate der at now, if any
+func (c *submissionCache) get(der []byte, now time.Time) ([]LogSCT, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sha256.Sum256(der)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*submissionCacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return append([]LogSCT(nil), entry.scts...), true
+}
+
+// add caches scts for the certificate der from now until the TTL has passed
+func (c *submissionCache) add(der []byte, scts []LogSCT, now time.Time) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256(der)
+	entry := &submissionCacheEntry{
+		key:     key,
+		scts:    append([]LogSCT(nil), scts...),
+		expires: now.Add(c.ttl),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*submissionCacheEntry).key)
+	}
+}
+
+// clear removes every cached certificate, e.g. when the configured logs
+// change or stored SCTs are purged. Entries are keyed by the certificate's
+// DER rather than its serial, so a single certificate can't be removed alone.
+func (c *submissionCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+	c.order.Init()
+}
@@ -0,0 +1,78 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/publisher/testlog"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSubmissionCache(t *testing.T) {
+	var disabled *submissionCache
+	disabled.add([]byte("a"), []LogSCT{{LogURI: "a"}}, time.Now())
+	_, ok := disabled.get([]byte("a"), time.Now())
+	test.Assert(t, !ok, "Nil cache returned SCTs")
+	test.Assert(t, newSubmissionCache(0, time.Minute) == nil, "Cache created without a size")
+
+	now := time.Now()
+	c := newSubmissionCache(2, time.Minute)
+	c.add([]byte("a"), []LogSCT{{LogURI: "a"}}, now)
+	c.add([]byte("b"), []LogSCT{{LogURI: "b"}}, now)
+	scts, ok := c.get([]byte("a"), now)
+	test.Assert(t, ok, "Cached certificate missing")
+	test.AssertEquals(t, scts[0].LogURI, "a")
+
+	// b is now the least recently used, so is evicted for c
+	c.add([]byte("c"), []LogSCT{{LogURI: "c"}}, now)
+	_, ok = c.get([]byte("b"), now)
+	test.Assert(t, !ok, "Least recently used certificate wasn't evicted")
+	_, ok = c.get([]byte("a"), now)
+	test.Assert(t, ok, "Recently used certificate was evicted")
+
+	_, ok = c.get([]byte("c"), now.Add(time.Minute))
+	test.Assert(t, !ok, "Expired certificate returned")
+
+	c.clear()
+	_, ok = c.get([]byte("a"), now)
+	test.Assert(t, !ok, "Cleared certificate returned")
+}
+
+func TestSubmitToCTCached(t *testing.T) {
+	pub, leaf, _ := setup(t)
+	l, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer l.Close()
+	ctLog, err := NewLog(l.Description(), pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+	pub.cache = newSubmissionCache(10, time.Minute)
+
+	first, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	test.AssertEquals(t, l.Submissions(), 1)
+
+	// The second submission is answered from the cache, without contacting
+	// the log
+	second, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Cached submission failed")
+	test.AssertEquals(t, l.Submissions(), 1)
+	test.AssertEquals(t, len(second.Results), 0)
+	test.AssertDeepEquals(t, second.SCTs, first.SCTs)
+
+	// Reloading the logs discards the cache
+	err = pub.ReloadLogs(nil)
+	test.AssertNotError(t, err, "Failed to reload logs")
+	pub.ctLogs = []*Log{ctLog}
+	_, err = pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	test.AssertEquals(t, l.Submissions(), 2)
+
+	// So does purging the certificate's SCTs
+	_, err = pub.PurgeSCTs(ctx, core.SerialToString(leaf.SerialNumber))
+	test.AssertNotError(t, err, "Failed to purge SCTs")
+	_, err = pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	test.AssertEquals(t, l.Submissions(), 3)
+}
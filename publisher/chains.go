@@ -0,0 +1,209 @@
+package publisher
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// chainFor returns the issuer chain that should accompany the given
+// certificate when it is submitted to ctLog, which is empty if the log is
+// configured to omit the issuer, see sendsIssuer. When the publisher has a
+// pool of cross-signed intermediates the log's accepted roots are looked up,
+// see cachedRoots, and the first chain that leads to one of them is used,
+// preferring the default issuer bundle. The selected chain is cached along
+// with the roots, so it is selected again when they expire. If no cross-signs
+// are configured, or an acceptable chain can't be determined, the default
+// issuer bundle is returned. An error is only returned if the publisher's
+// ChainFor fails.
+func (pub *Impl) chainFor(ctx context.Context, ctLog *Log, cert *x509.Certificate) ([]ct.ASN1Cert, error) {
+	if !ctLog.sendsIssuer(cert) {
+		return nil, nil
+	}
+	// The cross-signs are versions of the default issuer, so certificates from
+	// any other issuer always get their own bundle
+	bundle, isDefault, err := pub.bundleFor(cert)
+	if err != nil || len(pub.crossSigns) == 0 || !isDefault {
+		return bundle, err
+	}
+
+	cache, err := pub.cachedRoots(ctx, ctLog)
+	if err == nil {
+		err = cache.err
+	}
+	if err != nil {
+		pub.log.Warning(fmt.Sprintf(
+			"Failed to get accepted roots from CT log at %s, using default issuer bundle: %s",
+			ctLog.uri, err))
+		return pub.issuerBundle, nil
+	}
+	cache.selectOnce.Do(func() {
+		cache.chain, cache.chainErr = pub.selectChain(cert, cache.parsed)
+		if cache.chainErr != nil {
+			pub.log.Warning(fmt.Sprintf(
+				"No issuer chain leads to a root accepted by CT log at %s, using default issuer bundle: %s",
+				ctLog.uri, cache.chainErr))
+		}
+	})
+	if cache.chainErr != nil {
+		return pub.issuerBundle, nil
+	}
+	return cache.chain, nil
+}
+
+// sendsIssuer returns whether cert is submitted to ctLog along with its
+// issuer chain. Logs can be configured to omit it, but precertificates
+// always need their issuer.
+func (ctLog *Log) sendsIssuer(cert *x509.Certificate) bool {
+	return !ctLog.omitIssuer || isPrecert(cert)
+}
+
+// getAcceptedRoots fetches the roots ctLog accepts from its get-roots
+// endpoint
+func (pub *Impl) getAcceptedRoots(ctx context.Context, ctLog *Log) ([]ct.ASN1Cert, error) {
+	var resp ct.GetRootsResponse
+	httpResp, err := pub.doRequest(ctx, ctLog, http.MethodGet, ct.GetRootsPath, nil, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, statusError(ctx, httpResp)
+	}
+	roots := make([]ct.ASN1Cert, len(resp.Certificates))
+	for i, b64 := range resp.Certificates {
+		der, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding root %d: %s", i, err)
+		}
+		roots[i] = ct.ASN1Cert{Data: der}
+	}
+	return roots, nil
+}
+
+// selectChain picks the issuer chain whose last certificate is issued by one
+// of the provided roots. The default issuer bundle is considered first,
+// followed by each cross-signed intermediate in the order they were configured.
+func (pub *Impl) selectChain(cert *x509.Certificate, roots []*x509.Certificate) ([]ct.ASN1Cert, error) {
+	if len(pub.issuerBundle) > 0 {
+		last, err := x509.ParseCertificate(pub.issuerBundle[len(pub.issuerBundle)-1].Data)
+		if err == nil && issuedByAny(last, roots) {
+			return pub.issuerBundle, nil
+		}
+	}
+	for _, crossSign := range pub.crossSigns {
+		if cert.CheckSignatureFrom(crossSign) != nil {
+			continue
+		}
+		if issuedByAny(crossSign, roots) {
+			return []ct.ASN1Cert{{Data: crossSign.Raw}}, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the %d cross-signed intermediates chain to any of the %d accepted roots",
+		len(pub.crossSigns), len(roots))
+}
+
+// issuedByAny returns true if cert is signed by, or is itself, one of roots
+func issuedByAny(cert *x509.Certificate, roots []*x509.Certificate) bool {
+	for _, root := range roots {
+		if cert.Equal(root) || cert.CheckSignatureFrom(root) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// issuerBundle is an additional issuer bundle along with the subject key ID
+// of the issuer it starts with
+type issuerBundle struct {
+	keyID  []byte
+	bundle []ct.ASN1Cert
+}
+
+// LoadIssuer reads the PEM issuer certificate at path, see
+// cmd.CTConfig.IssuerPath, returning it as an issuer bundle. It is an error if
+// the file can't be read or doesn't hold a CA certificate.
+func LoadIssuer(path string) ([]ct.ASN1Cert, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading issuer certificate: %s", err)
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("issuer certificate %s isn't a PEM certificate", path)
+	}
+	issuer, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issuer certificate %s: %s", path, err)
+	}
+	if !issuer.BasicConstraintsValid || !issuer.IsCA {
+		return nil, fmt.Errorf("issuer certificate %s isn't a CA certificate", path)
+	}
+	return []ct.ASN1Cert{{Data: issuer.Raw}}, nil
+}
+
+// bundleFor returns the issuer bundle whose first certificate's subject key ID
+// matches the authority key ID of cert, or the default issuer bundle if none
+// of the additional bundles do. isDefault is true when the default bundle is
+// returned. When the publisher's ChainFor is set the chain it returns is used
+// instead of the configured bundles, and its error is returned.
+func (pub *Impl) bundleFor(cert *x509.Certificate) (bundle []ct.ASN1Cert, isDefault bool, err error) {
+	if pub.ChainFor != nil {
+		chainDER, err := pub.ChainFor(cert)
+		if err != nil {
+			return nil, false, fmt.Errorf("looking up issuer chain for certificate %s: %s",
+				core.SerialToString(cert.SerialNumber), err)
+		}
+		bundle = make([]ct.ASN1Cert, len(chainDER))
+		for i, der := range chainDER {
+			bundle[i] = ct.ASN1Cert{Data: der}
+		}
+		return bundle, false, nil
+	}
+	if len(cert.AuthorityKeyId) > 0 {
+		for _, issuer := range pub.additionalBundles {
+			if bytes.Equal(issuer.keyID, cert.AuthorityKeyId) {
+				return issuer.bundle, false, nil
+			}
+		}
+	}
+	return pub.issuerBundle, true, nil
+}
+
+// checkIssuer verifies that cert was signed by the first certificate of the
+// issuer bundle it would be submitted with, and that each following
+// certificate of the bundle signed the one before it, since logs reject
+// chains that don't verify. Without an issuer bundle there is nothing to
+// check.
+func (pub *Impl) checkIssuer(cert *x509.Certificate) error {
+	bundle, _, err := pub.bundleFor(cert)
+	if err != nil {
+		return err
+	}
+	child := cert
+	for i, link := range bundle {
+		issuer, err := x509.ParseCertificate(link.Data)
+		if err != nil {
+			return fmt.Errorf("parsing certificate %d of the issuer bundle for certificate %s: %s",
+				i, core.SerialToString(cert.SerialNumber), err)
+		}
+		if err := child.CheckSignatureFrom(issuer); err != nil {
+			if i == 0 {
+				return fmt.Errorf("certificate %s wasn't issued by %q, the first certificate of its issuer bundle: %s",
+					core.SerialToString(cert.SerialNumber), issuer.Subject.CommonName, err)
+			}
+			return fmt.Errorf("%q in the issuer bundle for certificate %s wasn't issued by %q, the certificate after it: %s",
+				child.Subject.CommonName, core.SerialToString(cert.SerialNumber), issuer.Subject.CommonName, err)
+		}
+		child = issuer
+	}
+	return nil
+}
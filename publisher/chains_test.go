@@ -0,0 +1,404 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func issueTestCert(t *testing.T, cn string, isCA bool, pub *ecdsa.PublicKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		// Certificates issued by this one get a matching authority key ID
+		keyHash := sha1.Sum(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+		template.SubjectKeyId = keyHash[:]
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	test.AssertNotError(t, err, "Failed to create test certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse test certificate")
+	return cert
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+	return k
+}
+
+// rootsLogSrv is a test CT log that accepts only the provided roots and
+// records the chains submitted to it
+type rootsLogSrv struct {
+	sync.Mutex
+	roots    []*x509.Certificate
+	chains   [][]string
+	getRoots int
+	// failRoots makes get-roots requests fail
+	failRoots bool
+}
+
+func (rs *rootsLogSrv) start(leaf []byte, k *ecdsa.PrivateKey) *httptest.Server {
+	sct := createSignedSCT(leaf, k)
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/ct/v1/get-roots", func(w http.ResponseWriter, r *http.Request) {
+		rs.Lock()
+		defer rs.Unlock()
+		rs.getRoots++
+		if rs.failRoots {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var resp ct.GetRootsResponse
+		for _, root := range rs.roots {
+			resp.Certificates = append(resp.Certificates, base64.StdEncoding.EncodeToString(root.Raw))
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	m.HandleFunc("/ct/ct/v1/add-chain", func(w http.ResponseWriter, r *http.Request) {
+		var jsonReq ctSubmissionRequest
+		if err := json.NewDecoder(r.Body).Decode(&jsonReq); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rs.Lock()
+		rs.chains = append(rs.chains, jsonReq.Chain)
+		rs.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	})
+	return httptest.NewServer(m)
+}
+
+func TestCrossSignSelection(t *testing.T) {
+	pub, _, k := setup(t)
+
+	rootAKey, rootBKey, intKey := testKey(t), testKey(t), testKey(t)
+	rootA := issueTestCert(t, "root A", true, &rootAKey.PublicKey, nil, rootAKey)
+	rootB := issueTestCert(t, "root B", true, &rootBKey.PublicKey, nil, rootBKey)
+	crossA := issueTestCert(t, "intermediate", true, &intKey.PublicKey, rootA, rootAKey)
+	crossB := issueTestCert(t, "intermediate", true, &intKey.PublicKey, rootB, rootBKey)
+	leaf := issueTestCert(t, "leaf", false, &testKey(t).PublicKey, crossA, intKey)
+
+	// The default bundle chains to root A, and the pool includes the cross-sign
+	// to root B
+	pub.issuerBundle = []ct.ASN1Cert{{Data: crossA.Raw}}
+	pub.crossSigns = []*x509.Certificate{crossA, crossB}
+
+	onlyB := &rootsLogSrv{roots: []*x509.Certificate{rootB}}
+	srvB := onlyB.start(leaf.Raw, k)
+	defer srvB.Close()
+	onlyA := &rootsLogSrv{roots: []*x509.Certificate{rootA}}
+	srvA := onlyA.start(leaf.Raw, k)
+	defer srvA.Close()
+	neither := &rootsLogSrv{}
+	srvNeither := neither.start(leaf.Raw, k)
+	defer srvNeither.Close()
+	for _, srv := range []*httptest.Server{srvB, srvA, srvNeither} {
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	log.Clear()
+	for i := 0; i < 2; i++ {
+		err := pub.SubmitToCT(ctx, leaf.Raw)
+		test.AssertNotError(t, err, "Certificate submission failed")
+	}
+	test.AssertEquals(t, len(log.GetAllMatching("Failed to.*")), 0)
+
+	b64 := base64.StdEncoding.EncodeToString
+	// The log accepting only root B should be sent the cross-sign from root B,
+	// and the chain selection should only have fetched the roots once
+	test.AssertEquals(t, onlyB.getRoots, 1)
+	test.AssertEquals(t, len(onlyB.chains), 2)
+	for _, chain := range onlyB.chains {
+		test.AssertDeepEquals(t, chain, []string{b64(leaf.Raw), b64(crossB.Raw)})
+	}
+	// The log accepting root A should get the default bundle
+	test.AssertEquals(t, onlyA.getRoots, 1)
+	for _, chain := range onlyA.chains {
+		test.AssertDeepEquals(t, chain, []string{b64(leaf.Raw), b64(crossA.Raw)})
+	}
+	// A log accepting neither root should fall back to the default bundle,
+	// with a warning when the chain is selected
+	test.AssertEquals(t, neither.getRoots, 1)
+	for _, chain := range neither.chains {
+		test.AssertDeepEquals(t, chain, []string{b64(leaf.Raw), b64(crossA.Raw)})
+	}
+	test.AssertEquals(t, len(log.GetAllMatching("No issuer chain leads to a root accepted by CT log")), 1)
+}
+
+func TestCrossSignSelectionCaching(t *testing.T) {
+	pub, _, k := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+
+	rootAKey, rootBKey, intKey := testKey(t), testKey(t), testKey(t)
+	rootA := issueTestCert(t, "root A", true, &rootAKey.PublicKey, nil, rootAKey)
+	rootB := issueTestCert(t, "root B", true, &rootBKey.PublicKey, nil, rootBKey)
+	crossA := issueTestCert(t, "intermediate", true, &intKey.PublicKey, rootA, rootAKey)
+	crossB := issueTestCert(t, "intermediate", true, &intKey.PublicKey, rootB, rootBKey)
+	leaf := issueTestCert(t, "leaf", false, &testKey(t).PublicKey, crossA, intKey)
+	pub.issuerBundle = []ct.ASN1Cert{{Data: crossA.Raw}}
+	pub.crossSigns = []*x509.Certificate{crossA, crossB}
+
+	srv := &rootsLogSrv{failRoots: true}
+	httpSrv := srv.start(leaf.Raw, k)
+	defer httpSrv.Close()
+	port, err := getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	ctLog := pub.ctLogs[0]
+	b64 := base64.StdEncoding.EncodeToString
+	defaultChain := []ct.ASN1Cert{{Data: crossA.Raw}}
+
+	// A failure to fetch the roots is remembered for a while, rather than
+	// each submission fetching them again
+	for i := 0; i < 2; i++ {
+		chain, err := pub.chainFor(ctx, ctLog, leaf)
+		test.AssertNotError(t, err, "chainFor failed")
+		test.AssertDeepEquals(t, chain, defaultChain)
+	}
+	test.AssertEquals(t, srv.getRoots, 1)
+
+	// Once it has passed the roots are fetched again, and the chain selected
+	// for them is cached with them
+	srv.Lock()
+	srv.failRoots = false
+	srv.roots = []*x509.Certificate{rootB}
+	srv.Unlock()
+	fc.Add(rootsRetryInterval)
+	for i := 0; i < 2; i++ {
+		chain, err := pub.chainFor(ctx, ctLog, leaf)
+		test.AssertNotError(t, err, "chainFor failed")
+		test.AssertEquals(t, len(chain), 1)
+		test.AssertEquals(t, b64(chain[0].Data), b64(crossB.Raw))
+	}
+	test.AssertEquals(t, srv.getRoots, 2)
+
+	// A change to the log's roots is picked up when the cached roots expire
+	srv.Lock()
+	srv.roots = []*x509.Certificate{rootA}
+	srv.Unlock()
+	fc.Add(defaultRootsCacheTTL)
+	chain, err := pub.chainFor(ctx, ctLog, leaf)
+	test.AssertNotError(t, err, "chainFor failed")
+	test.AssertDeepEquals(t, chain, defaultChain)
+	test.AssertEquals(t, srv.getRoots, 3)
+}
+
+func TestIssuerBundleSelection(t *testing.T) {
+	_, _, k := setup(t)
+
+	rootKey, intAKey, intBKey, intCKey := testKey(t), testKey(t), testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	intA := issueTestCert(t, "intermediate A", true, &intAKey.PublicKey, root, rootKey)
+	intB := issueTestCert(t, "intermediate B", true, &intBKey.PublicKey, root, rootKey)
+	intC := issueTestCert(t, "intermediate C", true, &intCKey.PublicKey, root, rootKey)
+	leafB := issueTestCert(t, "leaf B", false, &testKey(t).PublicKey, intB, intBKey)
+	leafC := issueTestCert(t, "leaf C", false, &testKey(t).PublicKey, intC, intCKey)
+
+	// Certificates from intermediate B get its bundle, and aren't matched
+	// against the cross-signs of the default intermediate
+	pub := New(cmd.CTConfig{}, []ct.ASN1Cert{{Data: intA.Raw}}, [][]ct.ASN1Cert{{{Data: intB.Raw}, {Data: root.Raw}}},
+		[]*x509.Certificate{intA}, nil, nil, 0, log, metrics.NewNoopScope(), nil)
+	srv := &rootsLogSrv{roots: []*x509.Certificate{root}}
+	httpSrv := srv.start(leafB.Raw, k)
+	defer httpSrv.Close()
+	port, err := getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	err = pub.SubmitToCT(ctx, leafB.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	b64 := base64.StdEncoding.EncodeToString
+	test.AssertEquals(t, len(srv.chains), 1)
+	test.AssertDeepEquals(t, srv.chains[0], []string{b64(leafB.Raw), b64(intB.Raw), b64(root.Raw)})
+	test.AssertEquals(t, srv.getRoots, 0)
+
+	// Intermediate C isn't configured, so its certificates get the default
+	// bundle, which didn't issue them and isn't submitted
+	err = pub.SubmitToCT(ctx, leafC.Raw)
+	test.AssertError(t, err, "Certificate from an unconfigured issuer was submitted")
+	test.Assert(t, strings.Contains(err.Error(), `wasn't issued by "intermediate A"`), fmt.Sprintf("Unexpected error: %s", err))
+	test.AssertEquals(t, len(srv.chains), 1)
+}
+
+func TestMultipleIntermediates(t *testing.T) {
+	pub, _, k := setup(t)
+
+	rootKey, upperKey, lowerKey, otherKey := testKey(t), testKey(t), testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	upper := issueTestCert(t, "upper intermediate", true, &upperKey.PublicKey, root, rootKey)
+	lower := issueTestCert(t, "lower intermediate", true, &lowerKey.PublicKey, upper, upperKey)
+	other := issueTestCert(t, "other intermediate", true, &otherKey.PublicKey, root, rootKey)
+	leaf := issueTestCert(t, "leaf", false, &testKey(t).PublicKey, lower, lowerKey)
+
+	srv := &rootsLogSrv{}
+	httpSrv := srv.start(leaf.Raw, k)
+	defer httpSrv.Close()
+	port, err := getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// Every intermediate is submitted, in order from the leaf
+	pub.issuerBundle = []ct.ASN1Cert{{Data: lower.Raw}, {Data: upper.Raw}}
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	b64 := base64.StdEncoding.EncodeToString
+	test.AssertEquals(t, len(srv.chains), 1)
+	test.AssertDeepEquals(t, srv.chains[0], []string{b64(leaf.Raw), b64(lower.Raw), b64(upper.Raw)})
+
+	// A bundle whose second intermediate didn't issue the first isn't
+	// submitted
+	pub.issuerBundle = []ct.ASN1Cert{{Data: lower.Raw}, {Data: other.Raw}}
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertError(t, err, "Certificate with a broken issuer bundle was submitted")
+	test.Assert(t, strings.Contains(err.Error(), `"lower intermediate" in the issuer bundle`), fmt.Sprintf("Unexpected error: %s", err))
+	test.AssertEquals(t, len(srv.chains), 1)
+}
+
+func TestLoadIssuer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	test.AssertNotError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	writePEM := func(name string, der []byte) string {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+		test.AssertNotError(t, err, "Failed to write test certificate")
+		return path
+	}
+	k := testKey(t)
+	ca := issueTestCert(t, "issuer", true, &k.PublicKey, nil, k)
+	caPath := writePEM("ca.pem", ca.Raw)
+	leafPath := writePEM("leaf.pem", issueTestCert(t, "leaf", false, &k.PublicKey, ca, k).Raw)
+	garbagePath := writePEM("garbage.pem", []byte("not a certificate"))
+
+	bundle, err := LoadIssuer(caPath)
+	test.AssertNotError(t, err, "Failed to load CA certificate")
+	test.AssertEquals(t, len(bundle), 1)
+	test.AssertByteEquals(t, bundle[0].Data, ca.Raw)
+
+	_, err = LoadIssuer(filepath.Join(dir, "missing.pem"))
+	test.AssertError(t, err, "Loaded missing issuer")
+	test.Assert(t, strings.HasPrefix(err.Error(), "reading issuer certificate: "), "Unexpected error: "+err.Error())
+	_, err = LoadIssuer(leafPath)
+	test.AssertEquals(t, err.Error(), fmt.Sprintf("issuer certificate %s isn't a CA certificate", leafPath))
+	_, err = LoadIssuer(garbagePath)
+	test.Assert(t, strings.HasPrefix(err.Error(), fmt.Sprintf("parsing issuer certificate %s: ", garbagePath)), "Unexpected error: "+err.Error())
+	err = ValidateConfig(cmd.CTConfig{Logs: []cmd.LogDescription{{URI: "https://ct.example.com"}}, IssuerPath: leafPath})
+	test.AssertEquals(t, err.Error(), fmt.Sprintf("invalid CT configuration: issuer certificate %s isn't a CA certificate", leafPath))
+
+	// Without a bundle the issuer is loaded from IssuerPath, but a bundle
+	// takes precedence
+	pub := New(cmd.CTConfig{IssuerPath: caPath}, nil, nil, nil, nil, nil, 0, log, metrics.NewNoopScope(), nil)
+	test.AssertEquals(t, len(pub.issuerBundle), 1)
+	test.AssertByteEquals(t, pub.issuerBundle[0].Data, ca.Raw)
+	other := issueTestCert(t, "other", true, &k.PublicKey, nil, k)
+	pub = New(cmd.CTConfig{IssuerPath: caPath}, []ct.ASN1Cert{{Data: other.Raw}}, nil, nil, nil, nil, 0, log, metrics.NewNoopScope(), nil)
+	test.AssertEquals(t, len(pub.issuerBundle), 1)
+	test.AssertByteEquals(t, pub.issuerBundle[0].Data, other.Raw)
+}
+
+func TestOmitIssuer(t *testing.T) {
+	pub, leaf, k := setup(t)
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	var srvs []*rootsLogSrv
+	for _, omit := range []bool{false, true} {
+		srv := &rootsLogSrv{}
+		httpSrv := srv.start(leaf.Raw, k)
+		defer httpSrv.Close()
+		ctLog, err := NewLog(cmd.LogDescription{
+			URI:        httpSrv.URL + "/ct",
+			Key:        base64.StdEncoding.EncodeToString(der),
+			OmitIssuer: omit,
+		}, pub.client, log)
+		test.AssertNotError(t, err, "Couldn't create log")
+		pub.ctLogs = append(pub.ctLogs, ctLog)
+		srvs = append(srvs, srv)
+	}
+
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	test.AssertEquals(t, len(srvs[0].chains), 1)
+	test.AssertEquals(t, len(srvs[0].chains[0]), 1+len(pub.issuerBundle))
+	test.AssertEquals(t, len(srvs[1].chains), 1)
+	test.AssertDeepEquals(t, srvs[1].chains[0], []string{base64.StdEncoding.EncodeToString(leaf.Raw)})
+}
+
+func TestChainForCallback(t *testing.T) {
+	_, _, k := setup(t)
+
+	rootKey, intAKey, intBKey := testKey(t), testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	intA := issueTestCert(t, "intermediate A", true, &intAKey.PublicKey, root, rootKey)
+	intB := issueTestCert(t, "intermediate B", true, &intBKey.PublicKey, root, rootKey)
+	leafB := issueTestCert(t, "leaf B", false, &testKey(t).PublicKey, intB, intBKey)
+
+	// The callback's chain is used rather than the default bundle
+	pub := New(cmd.CTConfig{}, []ct.ASN1Cert{{Data: intA.Raw}}, nil, nil, nil, nil, 0, log, metrics.NewNoopScope(), nil)
+	var lookedUp []*x509.Certificate
+	pub.ChainFor = func(cert *x509.Certificate) ([][]byte, error) {
+		lookedUp = append(lookedUp, cert)
+		return [][]byte{intB.Raw, root.Raw}, nil
+	}
+	srv := &rootsLogSrv{}
+	httpSrv := srv.start(leafB.Raw, k)
+	defer httpSrv.Close()
+	port, err := getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	err = pub.SubmitToCT(ctx, leafB.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	b64 := base64.StdEncoding.EncodeToString
+	test.AssertEquals(t, len(srv.chains), 1)
+	test.AssertDeepEquals(t, srv.chains[0], []string{b64(leafB.Raw), b64(intB.Raw), b64(root.Raw)})
+	test.Assert(t, len(lookedUp) > 0, "ChainFor wasn't called")
+	test.AssertByteEquals(t, lookedUp[0].Raw, leafB.Raw)
+
+	// A failed lookup fails the submission without contacting the log
+	pub.ChainFor = func(cert *x509.Certificate) ([][]byte, error) {
+		return nil, fmt.Errorf("unknown issuer")
+	}
+	err = pub.SubmitToCT(ctx, leafB.Raw)
+	test.AssertError(t, err, "Submission with a failed chain lookup succeeded")
+	test.Assert(t, strings.Contains(err.Error(), "looking up issuer chain for certificate"), fmt.Sprintf("Unexpected error: %s", err))
+	test.AssertEquals(t, len(srv.chains), 1)
+}
@@ -0,0 +1,72 @@
+package publisher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestFakeClockBackoff(t *testing.T) {
+	pub, leaf, k := setup(t)
+	srv := retryableLogSrv(leaf.Raw, k, 3, nil)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+	pub.backoff = newBackoff(cmd.CTConfig{
+		SubmissionBackoffBase: cmd.ConfigDuration{Duration: time.Minute},
+		SubmissionBackoffMax:  cmd.ConfigDuration{Duration: time.Hour},
+	})
+	pub.backoff.jitter = func(d time.Duration) time.Duration { return d }
+
+	// The backoff waits on the fake clock, so the submission only finishes
+	// as the clock is moved on
+	start := fc.Now()
+	done := make(chan SubmissionResult)
+	go func() {
+		done <- pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	}()
+	var result SubmissionResult
+	for finished := false; !finished; {
+		select {
+		case result = <-done:
+			finished = true
+		case <-time.After(time.Millisecond):
+			fc.Add(time.Second)
+		}
+	}
+	test.AssertNotError(t, result.Err, "Submission failed")
+	test.AssertEquals(t, result.Attempts, 4)
+	for _, wait := range []string{"1m0s", "2m0s", "4m0s"} {
+		test.AssertEquals(t, len(log.GetAllMatching(fmt.Sprintf("returned HTTP status .*, backing off for %s$", wait))), 1)
+	}
+	test.Assert(t, fc.Since(start) >= 7*time.Minute, fmt.Sprintf("Fake clock only moved %s", fc.Since(start)))
+}
+
+func TestFakeClockSCTTimestamp(t *testing.T) {
+	pub, leaf, k := setup(t)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// To the publisher the SCT, timestamped with the real time, is from an
+	// hour in the future
+	fc := clock.NewFake()
+	fc.Set(time.Now().Add(-time.Hour))
+	pub.clk = fc
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	badTimestamp, ok := result.Err.(ErrBadSCTTimestamp)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTTimestamp, got %T", result.Err))
+	test.AssertEquals(t, badTimestamp.Reason, "more than 10m0s in the future")
+}
@@ -0,0 +1,121 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+)
+
+// defaultCloseTimeout is how long Close waits for SCT callbacks running in
+// the background when no timeout is configured
+const defaultCloseTimeout = 10 * time.Second
+
+// ErrClosed is returned by submissions made after the publisher was closed
+var ErrClosed = errors.New("publisher is closed")
+
+// closeIdler is implemented by transports that pool connections
+type closeIdler interface {
+	CloseIdleConnections()
+}
+
+// begin registers a submission with the publisher, returning a context derived
+// from ctx that is canceled when the publisher is closed. The returned func
+// must be called once the submission is finished, including storing its SCTs.
+func (pub *Impl) begin(ctx context.Context) (context.Context, func(), error) {
+	pub.closeMu.Lock()
+	defer pub.closeMu.Unlock()
+	if pub.closed {
+		return nil, nil, ErrClosed
+	}
+	pub.inFlight.Add(1)
+	localCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-pub.closing:
+			cancel()
+		case <-localCtx.Done():
+		}
+	}()
+	return localCtx, func() {
+		cancel()
+		pub.inFlight.Done()
+	}, nil
+}
+
+// Close stops the publisher. Submissions in progress are canceled, and Close
+// waits for them to finish so that no SCT storage is in progress once it
+// returns. SCT callbacks running in the background aren't canceled, as they
+// don't take a context, so once the submissions have finished Close waits for
+// the callbacks, including any the canceled submissions started, for up to
+// the close timeout. Callbacks still running after that are abandoned with a
+// warning. Idle connections to the logs are then closed. Submissions made
+// after Close return ErrClosed. It is safe to call Close more than once.
+func (pub *Impl) Close() error {
+	pub.closeMu.Lock()
+	if pub.closed {
+		pub.closeMu.Unlock()
+		return nil
+	}
+	pub.closed = true
+	close(pub.closing)
+	pub.closeMu.Unlock()
+
+	pub.inFlight.Wait()
+	if pending := pub.callbacks.wait(pub.clk, pub.closeTimeout); pending > 0 {
+		pub.log.Warning(fmt.Sprintf("Abandoning %d SCT callbacks that didn't finish within %s of closing",
+			pending, pub.closeTimeout))
+	}
+	if transport, ok := pub.client.Transport.(closeIdler); ok {
+		transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// callbackTracker tracks the SCT callbacks running in the background so that
+// Close can wait for them
+type callbackTracker struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	pending int
+}
+
+// run calls f in the background
+func (cbt *callbackTracker) run(f func()) {
+	cbt.wg.Add(1)
+	cbt.mu.Lock()
+	cbt.pending++
+	cbt.mu.Unlock()
+	go func() {
+		defer cbt.wg.Done()
+		defer func() {
+			cbt.mu.Lock()
+			cbt.pending--
+			cbt.mu.Unlock()
+		}()
+		f()
+	}()
+}
+
+// wait waits for up to timeout for the callbacks to finish, returning the
+// number still running if it times out
+func (cbt *callbackTracker) wait(clk clock.Clock, timeout time.Duration) int {
+	done := make(chan struct{})
+	go func() {
+		cbt.wg.Wait()
+		close(done)
+	}()
+	timer := clk.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return 0
+	case <-timer.C:
+		cbt.mu.Lock()
+		defer cbt.mu.Unlock()
+		return cbt.pending
+	}
+}
@@ -0,0 +1,114 @@
+package publisher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestClose(t *testing.T) {
+	pub, leaf, k := setup(t)
+	received := make(chan struct{}, 1)
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		<-hung
+	}))
+	defer srv.Close()
+	defer close(hung)
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// Closing cancels a submission that's waiting on the log
+	errs := make(chan error)
+	go func() {
+		errs <- pub.SubmitToCT(ctx, leaf.Raw)
+	}()
+	<-received
+	start := time.Now()
+	err = pub.Close()
+	test.AssertNotError(t, err, "Failed to close publisher")
+	select {
+	case err := <-errs:
+		test.AssertError(t, err, "Submission in progress when closed succeeded")
+	case <-time.After(time.Second):
+		t.Fatal("Submission in progress wasn't canceled by Close")
+	}
+	test.Assert(t, time.Since(start) < time.Second, "Close didn't cancel the submission in progress")
+
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertEquals(t, err, ErrClosed)
+	_, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertEquals(t, err, ErrClosed)
+	err = pub.Close()
+	test.AssertNotError(t, err, "Closing twice failed")
+}
+
+func TestCloseWaitsForCallbacks(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.asyncCallbacks = true
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// The callback only finishes after the submission has returned, so Close
+	// has to wait for it
+	release := make(chan struct{})
+	var mu sync.Mutex
+	finished := 0
+	pub.OnSCT = func(serial, logURI string, sct core.SignedCertificateTimestamp) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		finished++
+		return nil
+	}
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	err = pub.Close()
+	test.AssertNotError(t, err, "Failed to close publisher")
+	mu.Lock()
+	test.AssertEquals(t, finished, 1)
+	mu.Unlock()
+}
+
+func TestCloseAbandonsSlowCallbacks(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.asyncCallbacks = true
+	pub.closeTimeout = 50 * time.Millisecond
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	hung := make(chan struct{})
+	defer close(hung)
+	pub.OnSCT = func(serial, logURI string, sct core.SignedCertificateTimestamp) error {
+		<-hung
+		return nil
+	}
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	log.Clear()
+	start := time.Now()
+	err = pub.Close()
+	test.AssertNotError(t, err, "Failed to close publisher")
+	test.Assert(t, time.Since(start) < time.Second, "Close waited past its timeout")
+	test.AssertEquals(t, len(log.GetAllMatching("Abandoning 1 SCT callbacks that didn't finish within 50ms of closing")), 1)
+}
@@ -0,0 +1,66 @@
+package publisher
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+)
+
+// approvedCurves are the curves a CT log's ECDSA key may use. Note that the
+// CT library's verifier additionally rejects keys not on P-256.
+var approvedCurves = []elliptic.Curve{elliptic.P256(), elliptic.P384()}
+
+// logKeyCurve returns the curve of a log's ECDSA public key, or nil for other
+// key types, and errors if the curve isn't one of the approved curves
+func logKeyCurve(pk crypto.PublicKey) (elliptic.Curve, error) {
+	ecKey, ok := pk.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil
+	}
+	for _, curve := range approvedCurves {
+		if ecKey.Curve == curve {
+			return curve, nil
+		}
+	}
+	return nil, fmt.Errorf("log public key uses unsupported curve %s", ecKey.Params().Name)
+}
+
+// checkECDSAValues checks that an ECDSA signature from the log is a
+// well-formed DER encoded (R, S) pair for the log's curve, i.e. that both are
+// positive and less than the curve's order. Signature verification would fail
+// for out of range values too, but not in a way that shows the log returned a
+// malformed signature rather than one made with the wrong key.
+func (l *Log) checkECDSAValues(ds ct.DigitallySigned) error {
+	if l.curve == nil || ds.Algorithm.Signature != ctTLS.ECDSA {
+		return nil
+	}
+	var sig struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(ds.Signature, &sig)
+	if err != nil {
+		return fmt.Errorf("unmarshaling ECDSA signature: %s", err)
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("%d bytes of trailing data after ECDSA signature", len(rest))
+	}
+	n := l.curve.Params().N
+	for _, v := range []struct {
+		name  string
+		value *big.Int
+	}{{"R", sig.R}, {"S", sig.S}} {
+		if v.value.Sign() <= 0 {
+			return fmt.Errorf("ECDSA signature %s is not positive", v.name)
+		}
+		if v.value.Cmp(n) >= 0 {
+			return fmt.Errorf("ECDSA signature %s is not less than the order of %s", v.name, l.curve.Params().Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLogKeyCurve(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	_, err = NewLog(cmd.LogDescription{
+		URI: "http://localhost/ct",
+		Key: base64.StdEncoding.EncodeToString(der),
+	}, nil, log)
+	test.AssertError(t, err, "Log with a P-224 key was accepted")
+	test.AssertEquals(t, err.Error(), "log public key uses unsupported curve P-224")
+
+	// Skipping verification skips the key entirely
+	_, err = NewLog(cmd.LogDescription{
+		URI:                       "http://localhost/ct",
+		Key:                       base64.StdEncoding.EncodeToString(der),
+		SkipSignatureVerification: true,
+	}, nil, log)
+	test.AssertNotError(t, err, "Unverified log was rejected")
+}
+
+func TestCheckECDSAValues(t *testing.T) {
+	ctLog := &Log{curve: elliptic.P256()}
+	n := elliptic.P256().Params().N
+	signature := func(r, s *big.Int) ct.DigitallySigned {
+		der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		test.AssertNotError(t, err, "Failed to marshal signature")
+		return ct.DigitallySigned{
+			Algorithm: ctTLS.SignatureAndHashAlgorithm{Hash: ctTLS.SHA256, Signature: ctTLS.ECDSA},
+			Signature: der,
+		}
+	}
+	one := big.NewInt(1)
+
+	test.AssertNotError(t, ctLog.checkECDSAValues(signature(one, new(big.Int).Sub(n, one))), "Valid values were rejected")
+	err := ctLog.checkECDSAValues(signature(n, one))
+	test.AssertError(t, err, "R equal to N was accepted")
+	test.AssertEquals(t, err.Error(), "ECDSA signature R is not less than the order of P-256")
+	err = ctLog.checkECDSAValues(signature(one, big.NewInt(0)))
+	test.AssertError(t, err, "Zero S was accepted")
+	test.AssertEquals(t, err.Error(), "ECDSA signature S is not positive")
+
+	malformed := signature(one, one)
+	malformed.Signature = append(malformed.Signature, 0)
+	err = ctLog.checkECDSAValues(malformed)
+	test.AssertError(t, err, "Signature with trailing data was accepted")
+
+	// Only ECDSA signatures from logs with ECDSA keys are checked
+	malformed.Algorithm.Signature = ctTLS.RSA
+	test.AssertNotError(t, ctLog.checkECDSAValues(malformed), "RSA signature was checked")
+	test.AssertNotError(t, (&Log{}).checkECDSAValues(signature(n, one)), "Log without a curve checked the signature")
+}
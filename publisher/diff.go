@@ -0,0 +1,132 @@
+package publisher
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// FieldChange describes a single configuration field whose value differs
+// between two configs
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (fc FieldChange) String() string {
+	return fmt.Sprintf("%s changed from %q to %q", fc.Field, fc.Old, fc.New)
+}
+
+// LogChange describes a log that is present in both configs but whose
+// description differs
+type LogChange struct {
+	URI     string
+	Changes []FieldChange
+}
+
+// ConfigDiff is a structured description of the differences between two
+// CTConfigs, intended to be reviewed before a config change is rolled out
+type ConfigDiff struct {
+	AddedLogs   []string
+	RemovedLogs []string
+	ChangedLogs []LogChange
+	Changes     []FieldChange
+}
+
+// Empty returns true if the two configs that were compared are equivalent
+func (d ConfigDiff) Empty() bool {
+	return len(d.AddedLogs) == 0 &&
+		len(d.RemovedLogs) == 0 &&
+		len(d.ChangedLogs) == 0 &&
+		len(d.Changes) == 0
+}
+
+// String returns a human readable summary of the diff, with one change per
+// line
+func (d ConfigDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	var lines []string
+	for _, uri := range d.RemovedLogs {
+		lines = append(lines, fmt.Sprintf("removing log %s", uri))
+	}
+	for _, uri := range d.AddedLogs {
+		lines = append(lines, fmt.Sprintf("adding log %s", uri))
+	}
+	for _, lc := range d.ChangedLogs {
+		for _, fc := range lc.Changes {
+			lines = append(lines, fmt.Sprintf("changing log %s: %s", lc.URI, fc))
+		}
+	}
+	for _, fc := range d.Changes {
+		lines = append(lines, fc.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffConfig compares two CTConfigs and returns the logs added, removed, and
+// changed between them along with any changes to the remaining settings. Logs
+// are matched by URI. A nil config is treated as an empty one.
+func DiffConfig(old, new *cmd.CTConfig) ConfigDiff {
+	if old == nil {
+		old = &cmd.CTConfig{}
+	}
+	if new == nil {
+		new = &cmd.CTConfig{}
+	}
+
+	var diff ConfigDiff
+	oldLogs := make(map[string]cmd.LogDescription, len(old.Logs))
+	for _, ld := range old.Logs {
+		oldLogs[ld.URI] = ld
+	}
+	newLogs := make(map[string]bool, len(new.Logs))
+	for _, ld := range new.Logs {
+		newLogs[ld.URI] = true
+		oldLD, present := oldLogs[ld.URI]
+		if !present {
+			diff.AddedLogs = append(diff.AddedLogs, ld.URI)
+			continue
+		}
+		if changes := diffFields(oldLD, ld, "URI"); len(changes) > 0 {
+			diff.ChangedLogs = append(diff.ChangedLogs, LogChange{URI: ld.URI, Changes: changes})
+		}
+	}
+	for _, ld := range old.Logs {
+		if !newLogs[ld.URI] {
+			diff.RemovedLogs = append(diff.RemovedLogs, ld.URI)
+		}
+	}
+	diff.Changes = diffFields(*old, *new, "Logs")
+	return diff
+}
+
+// diffFields compares each field of two structs of the same type, skipping any
+// fields named in skip, and returns a FieldChange for every field that differs
+func diffFields(old, new interface{}, skip ...string) []FieldChange {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	oldVal, newVal := reflect.ValueOf(old), reflect.ValueOf(new)
+	var changes []FieldChange
+	for i := 0; i < oldVal.NumField(); i++ {
+		name := oldVal.Type().Field(i).Name
+		if skipped[name] {
+			continue
+		}
+		o, n := oldVal.Field(i).Interface(), newVal.Field(i).Interface()
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, FieldChange{
+				Field: name,
+				Old:   fmt.Sprintf("%v", o),
+				New:   fmt.Sprintf("%v", n),
+			})
+		}
+	}
+	return changes
+}
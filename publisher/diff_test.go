@@ -0,0 +1,56 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDiffConfig(t *testing.T) {
+	old := &cmd.CTConfig{
+		Logs: []cmd.LogDescription{
+			{URI: "https://log.one.example.com", Key: "a"},
+			{URI: "https://log.two.example.com", Key: "b"},
+			{URI: "https://log.three.example.com", Key: "c"},
+		},
+		IntermediateBundleFilename: "int.pem",
+	}
+
+	diff := DiffConfig(old, old)
+	test.Assert(t, diff.Empty(), "Diff of identical configs wasn't empty")
+	test.AssertEquals(t, diff.String(), "no changes")
+
+	new := &cmd.CTConfig{
+		Logs: []cmd.LogDescription{
+			{URI: "https://log.two.example.com", Key: "b"},
+			{URI: "https://log.three.example.com", Key: "c", SkipSignatureVerification: true},
+			{URI: "https://log.four.example.com", Key: "d"},
+		},
+		IntermediateBundleFilename: "int2.pem",
+	}
+	diff = DiffConfig(old, new)
+	test.Assert(t, !diff.Empty(), "Diff of different configs was empty")
+	test.AssertDeepEquals(t, diff.AddedLogs, []string{"https://log.four.example.com"})
+	test.AssertDeepEquals(t, diff.RemovedLogs, []string{"https://log.one.example.com"})
+	test.AssertDeepEquals(t, diff.ChangedLogs, []LogChange{
+		{
+			URI: "https://log.three.example.com",
+			Changes: []FieldChange{
+				{Field: "SkipSignatureVerification", Old: "false", New: "true"},
+			},
+		},
+	})
+	test.AssertDeepEquals(t, diff.Changes, []FieldChange{
+		{Field: "IntermediateBundleFilename", Old: "int.pem", New: "int2.pem"},
+	})
+	test.AssertEquals(t, diff.String(), `removing log https://log.one.example.com
+adding log https://log.four.example.com
+changing log https://log.three.example.com: SkipSignatureVerification changed from "false" to "true"
+IntermediateBundleFilename changed from "int.pem" to "int2.pem"`)
+
+	// A nil config should be treated as empty
+	diff = DiffConfig(nil, old)
+	test.AssertEquals(t, len(diff.AddedLogs), 3)
+	test.AssertEquals(t, len(diff.RemovedLogs), 0)
+}
@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// logDryRun audit logs the submission of cert that would be made to each of
+// the logs instead of sending it. The issuer bundle for cert is always used,
+// since picking a cross-signed chain requires fetching the log's accepted
+// roots, unless the log omits the issuer.
+func (pub *Impl) logDryRun(ctx context.Context, logs []*Log, entryType ct.LogEntryType, cert *x509.Certificate) {
+	path := ct.AddChainPath
+	if entryType == ct.PrecertLogEntryType {
+		path = ct.AddPreChainPath
+	}
+	leaf := base64.StdEncoding.EncodeToString(cert.Raw)
+	bundle, _, err := pub.bundleFor(cert)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("[dry run] Not submitting certificate %s: %s",
+			core.SerialToString(cert.SerialNumber), err)))
+		return
+	}
+	for _, ctLog := range logs {
+		req := ctSubmissionRequest{Chain: []string{leaf}}
+		if ctLog.sendsIssuer(cert) {
+			for _, link := range bundle {
+				req.Chain = append(req.Chain, base64.StdEncoding.EncodeToString(link.Data))
+			}
+		}
+		body, err := json.Marshal(req)
+		if err != nil {
+			pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("[dry run] Failed to marshal submission of certificate %s: %s",
+				core.SerialToString(cert.SerialNumber), err)))
+			return
+		}
+		pub.log.AuditInfo(withRequestID(ctx, fmt.Sprintf("[dry run] Not submitting certificate %s to CT log at %s%s: %s",
+			core.SerialToString(cert.SerialNumber), ctLog.uri, path, body)))
+	}
+}
@@ -0,0 +1,34 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDryRun(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.dryRun = true
+	pub.minimumSCTCount = 1
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	log.Clear()
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Dry run submission failed")
+	test.AssertEquals(t, len(scts), 0)
+	err = pub.SubmitToSingleCT(ctx, fmt.Sprintf("http://localhost:%d/ct", port), pub.ctLogs[0].logID, leaf.Raw)
+	test.AssertNotError(t, err, "Dry run submission to a single log failed")
+	test.AssertEquals(t, atomic.LoadInt32(&hits), int32(0))
+	test.AssertEquals(t, len(log.GetAllMatching(`\[AUDIT\] \[dry run\] Not submitting certificate .* to CT log at http://localhost:\d+/ct/ct/v1/add-chain: {"chain":\[".+",".+"\]}`)), 2)
+}
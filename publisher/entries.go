@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+)
+
+// defaultMaxGetEntries is the most entries requested from a log at once when
+// the log's MaxGetEntries isn't configured
+const defaultMaxGetEntries = 256
+
+// LogEntry is an entry of a CT log, as returned by its get-entries endpoint
+// with the base64 encoded fields decoded. LeafInput is the TLS encoded
+// MerkleTreeLeaf, and ExtraData the chain the entry was submitted with.
+type LogEntry struct {
+	Index     int64
+	LeafInput []byte
+	ExtraData []byte
+}
+
+// ErrInvalidRange is returned by GetEntries for a range of entries that
+// can't be requested
+type ErrInvalidRange struct {
+	Start, End int64
+}
+
+func (e ErrInvalidRange) Error() string {
+	return fmt.Sprintf("invalid range of CT log entries [%d, %d]", e.Start, e.End)
+}
+
+// GetEntries fetches the entries from start to end, inclusive, of the
+// configured log with the given URI. Ranges larger than the log's
+// MaxGetEntries are requested in chunks, and since logs may return fewer
+// entries than requested, requests continue from the first entry that wasn't
+// returned until the range is complete. Each request is bounded by the
+// submission timeout.
+func (pub *Impl) GetEntries(ctx context.Context, logURI string, start, end int64) ([]LogEntry, error) {
+	if start < 0 || end < start {
+		return nil, ErrInvalidRange{Start: start, End: end}
+	}
+	ctLog, err := pub.logByURI(logURI)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]LogEntry, 0, end-start+1)
+	for next := start; next <= end; {
+		chunkEnd := next + int64(ctLog.maxGetEntries) - 1
+		if chunkEnd > end {
+			chunkEnd = end
+		}
+		resp, err := pub.getEntries(ctx, ctLog, next, chunkEnd)
+		if err != nil {
+			return nil, fmt.Errorf("fetching entries [%d, %d] from CT log at %s: %s", next, chunkEnd, logURI, err)
+		}
+		if len(resp.Entries) == 0 {
+			return nil, fmt.Errorf("CT log at %s returned no entries for [%d, %d]", logURI, next, chunkEnd)
+		}
+		if int64(len(resp.Entries)) > chunkEnd-next+1 {
+			return nil, fmt.Errorf("CT log at %s returned %d entries for [%d, %d]", logURI, len(resp.Entries), next, chunkEnd)
+		}
+		for _, entry := range resp.Entries {
+			entries = append(entries, LogEntry{
+				Index:     next,
+				LeafInput: entry.LeafInput,
+				ExtraData: entry.ExtraData,
+			})
+			next++
+		}
+	}
+	return entries, nil
+}
+
+func (pub *Impl) getEntries(ctx context.Context, ctLog *Log, start, end int64) (*ct.GetEntriesResponse, error) {
+	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
+	defer cancel()
+	params := map[string]string{
+		"start": strconv.FormatInt(start, 10),
+		"end":   strconv.FormatInt(end, 10),
+	}
+	var resp ct.GetEntriesResponse
+	httpResp, err := pub.doRequest(localCtx, ctLog, http.MethodGet, ct.GetEntriesPath, params, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, statusError(localCtx, httpResp)
+	}
+	return &resp, nil
+}
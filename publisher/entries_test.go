@@ -0,0 +1,119 @@
+package publisher
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// entriesLogSrv returns a log with size entries, whose leaf_input is the
+// entry's index, that returns at most max entries per get-entries request
+func entriesLogSrv(size, max int64, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		if end >= size {
+			end = size - 1
+		}
+		if end >= start+max {
+			end = start + max - 1
+		}
+		var resp ct.GetEntriesResponse
+		for i := start; i <= end; i++ {
+			resp.Entries = append(resp.Entries, ct.LeafEntry{
+				LeafInput: []byte(strconv.FormatInt(i, 10)),
+				ExtraData: []byte("chain"),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestGetEntries(t *testing.T) {
+	pub, _, k := setup(t)
+	requests := 0
+	srv := entriesLogSrv(20, 3, &requests)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	ctLog := pub.ctLogs[0]
+	ctLog.maxGetEntries = 5
+
+	// The log returns fewer entries than the configured maximum, so each
+	// request continues from the first entry the previous one didn't return
+	entries, err := pub.GetEntries(ctx, ctLog.uri, 2, 11)
+	test.AssertNotError(t, err, "GetEntries failed")
+	test.AssertEquals(t, len(entries), 10)
+	for i, entry := range entries {
+		test.AssertEquals(t, entry.Index, int64(i+2))
+		test.AssertEquals(t, string(entry.LeafInput), fmt.Sprintf("%d", i+2))
+		test.AssertEquals(t, string(entry.ExtraData), "chain")
+	}
+	test.AssertEquals(t, requests, 4)
+
+	// A range past the end of the log fails rather than returning a partial
+	// range
+	_, err = pub.GetEntries(ctx, ctLog.uri, 18, 25)
+	test.AssertError(t, err, "Range past the end of the log succeeded")
+
+	for _, r := range [][2]int64{{5, 4}, {-1, 3}} {
+		_, err = pub.GetEntries(ctx, ctLog.uri, r[0], r[1])
+		test.AssertError(t, err, "Invalid range was requested")
+		invalid, ok := err.(ErrInvalidRange)
+		test.Assert(t, ok, fmt.Sprintf("Expected ErrInvalidRange, got %T", err))
+		test.AssertEquals(t, invalid, ErrInvalidRange{Start: r[0], End: r[1]})
+	}
+
+	_, err = pub.GetEntries(ctx, "http://unknown.example.com", 0, 1)
+	test.AssertError(t, err, "Unknown log was queried")
+}
+
+func TestGzipResponses(t *testing.T) {
+	pub, _, k := setup(t)
+	entries := `{"entries":[{"leaf_input":"AQID","extra_data":"BAUG"}]}`
+	var acceptEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		if r.URL.Query().Get("start") == "1" {
+			// Compresses to far less than the limit
+			fmt.Fprintf(gz, `{"entries":[{"leaf_input":"%s"}]}`, strings.Repeat("A", 2048))
+		} else {
+			fmt.Fprint(gz, entries)
+		}
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+	client, err := NewHTTPClient(cmd.CTConfig{MaxResponseSize: 1024})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	pub.client = client
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	result, err := pub.GetEntries(ctx, pub.ctLogs[0].uri, 0, 0)
+	test.AssertNotError(t, err, "GetEntries with a gzipped response failed")
+	test.AssertEquals(t, acceptEncoding, "gzip")
+	test.AssertByteEquals(t, result[0].LeafInput, []byte{1, 2, 3})
+	test.AssertByteEquals(t, result[0].ExtraData, []byte{4, 5, 6})
+
+	// The size limit applies to the decompressed response
+	_, err = pub.GetEntries(ctx, pub.ctLogs[0].uri, 1, 1)
+	test.AssertError(t, err, "Decompressed response over the size limit was accepted")
+	test.Assert(t, strings.Contains(err.Error(), "larger than the maximum of 1024 bytes"), err.Error())
+}
@@ -0,0 +1,204 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrBadSCTSignature is returned when a log returned an SCT that doesn't
+// verify against the log's configured key, or isn't signed in a way RFC 6962
+// allows. This indicates a misbehaving or misconfigured log and should be
+// alarmed on rather than retried.
+type ErrBadSCTSignature struct {
+	LogURI string
+	Err    error
+}
+
+func (e ErrBadSCTSignature) Error() string {
+	return fmt.Sprintf("bad SCT signature from CT log at %s: %s", e.LogURI, e.Err)
+}
+
+// Unwrap returns the underlying verification error
+func (e ErrBadSCTSignature) Unwrap() error {
+	return e.Err
+}
+
+// ErrBadSCTTimestamp is returned when a log returned an SCT with a timestamp
+// further in the future than the allowed clock skew, or from before the
+// certificate was valid, which a correctly behaving log can't do. Like a bad
+// signature it isn't retried.
+type ErrBadSCTTimestamp struct {
+	LogURI    string
+	Timestamp time.Time
+	Reason    string
+}
+
+func (e ErrBadSCTTimestamp) Error() string {
+	return fmt.Sprintf("SCT from CT log at %s has timestamp %s, %s", e.LogURI, e.Timestamp.UTC(), e.Reason)
+}
+
+// ErrLogUnavailable is returned when a log couldn't be reached, or responded
+// with a status indicating it is temporarily unable to accept submissions.
+// Submitting again later may succeed.
+type ErrLogUnavailable struct {
+	LogURI string
+	// Status is the HTTP status of the log's response, or zero if there was no
+	// response
+	Status int
+	Err    error
+}
+
+func (e ErrLogUnavailable) Error() string {
+	if e.Status == 0 {
+		return fmt.Sprintf("CT log at %s is unavailable: %s", e.LogURI, e.Err)
+	}
+	return fmt.Sprintf("CT log at %s is unavailable, status %d: %s", e.LogURI, e.Status, e.Err)
+}
+
+// Unwrap returns the underlying request error
+func (e ErrLogUnavailable) Unwrap() error {
+	return e.Err
+}
+
+// ErrLogRejected is returned when a log refused a submission with a client
+// error status, e.g. because it doesn't accept the certificate's root.
+// Submitting the same certificate again won't succeed, so it isn't retried.
+// Body is the start of the log's response, which usually gives its reason.
+type ErrLogRejected struct {
+	LogURI string
+	Status int
+	Body   string
+}
+
+func (e ErrLogRejected) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("CT log at %s rejected the submission with status %d", e.LogURI, e.Status)
+	}
+	return fmt.Sprintf("CT log at %s rejected the submission with status %d: %s", e.LogURI, e.Status, e.Body)
+}
+
+// ErrMalformedResponse is returned when a log's response was read in full
+// but isn't valid JSON. Unlike a connection reset partway through the body,
+// which is a transient network error and retried, a log that sent a
+// malformed response will most likely send it again, so it isn't retried.
+type ErrMalformedResponse struct {
+	LogURI string
+	Err    error
+}
+
+func (e ErrMalformedResponse) Error() string {
+	return fmt.Sprintf("malformed response from CT log at %s: %s", e.LogURI, e.Err)
+}
+
+// Unwrap returns the JSON decoding error
+func (e ErrMalformedResponse) Unwrap() error {
+	return e.Err
+}
+
+// ErrCertExpired is returned for a certificate that had already expired when
+// it was to be submitted, unless cmd.CTConfig.SubmitExpired is set. No log
+// is contacted.
+type ErrCertExpired struct {
+	Serial   string
+	NotAfter time.Time
+}
+
+func (e ErrCertExpired) Error() string {
+	return fmt.Sprintf("certificate %s expired at %s", e.Serial, e.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// ErrNoTemporalShard is returned when every log a certificate would be
+// submitted to is temporally sharded, and none of the shards accepts the
+// certificate's NotAfter. The certificate isn't submitted at all.
+type ErrNoTemporalShard struct {
+	Serial   string
+	NotAfter time.Time
+}
+
+func (e ErrNoTemporalShard) Error() string {
+	return fmt.Sprintf("no CT log has a temporal shard covering certificate %s with NotAfter %s",
+		e.Serial, e.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// ErrRetryExhausted is returned when a log kept failing with retryable errors
+// until the submission deadline passed. Attempts is the number of HTTP
+// requests that were made to the log.
+type ErrRetryExhausted struct {
+	LogURI   string
+	Attempts int
+	Err      error
+}
+
+func (e ErrRetryExhausted) Error() string {
+	return fmt.Sprintf("gave up submitting to CT log at %s after %d attempts: %s", e.LogURI, e.Attempts, e.Err)
+}
+
+// Unwrap returns the error from the last attempt
+func (e ErrRetryExhausted) Unwrap() error {
+	return e.Err
+}
+
+// classifySubmissionError returns the error for a failed submission,
+// wrapping err in one of the error types above when the result shows why the
+// submission failed. Permanent rejections are returned as they are.
+func classifySubmissionError(result SubmissionResult, err error) error {
+	switch err.(type) {
+	case ErrBadSCTSignature, ErrBadSCTTimestamp, ErrLogRejected, ErrMalformedResponse, ErrThrottled:
+		return err
+	}
+	if result.permanentlyRejected() {
+		return err
+	}
+	if result.Attempts > 1 {
+		return ErrRetryExhausted{LogURI: result.LogURI, Attempts: result.Attempts, Err: err}
+	}
+	switch {
+	case result.FinalStatus == 0,
+		result.FinalStatus >= 500,
+		result.FinalStatus == http.StatusRequestTimeout,
+		result.FinalStatus == http.StatusTooManyRequests:
+		return ErrLogUnavailable{LogURI: result.LogURI, Status: result.FinalStatus, Err: err}
+	}
+	return err
+}
+
+// ErrSubmissionFailed is returned when too few logs returned SCTs for a
+// certificate, i.e. fewer than the minimum SCT count or, without one, fewer
+// than every configured log. The errors of the individual failures are
+// available from Failures.
+type ErrSubmissionFailed struct {
+	// Failures holds the results of the logs that didn't return an SCT, in
+	// the order the logs are configured
+	Failures        []SubmissionResult
+	Logs            int
+	SCTs            int
+	MinimumSCTCount int
+}
+
+func (e ErrSubmissionFailed) Error() string {
+	uris := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		uris[i] = failure.LogURI
+	}
+	if e.MinimumSCTCount > 0 {
+		return fmt.Sprintf("collected %d of the %d required SCTs, failed to submit certificate to CT logs at %s",
+			e.SCTs, e.MinimumSCTCount, strings.Join(uris, ", "))
+	}
+	return fmt.Sprintf("failed to submit certificate to %d of %d CT logs: %s",
+		len(e.Failures), e.Logs, strings.Join(uris, ", "))
+}
+
+// ErrInsufficientSCTs is returned when fewer SCTs are stored for a
+// certificate than are needed to assemble its SCT list. Submitting the
+// certificate again, e.g. with ResubmitMissing, may obtain the rest.
+type ErrInsufficientSCTs struct {
+	Serial   string
+	SCTs     int
+	Required int
+}
+
+func (e ErrInsufficientSCTs) Error() string {
+	return fmt.Sprintf("%d SCTs are stored for %s, %d are required", e.SCTs, e.Serial, e.Required)
+}
@@ -0,0 +1,244 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSubmissionErrorTypes(t *testing.T) {
+	pub, leaf, k := setup(t)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+
+	// The first log signs with a key other than the one it's configured with,
+	// the second always fails and the third asks for a retry more times than
+	// can fit in the deadline
+	badSigSrv := logSrv(leaf.Raw, otherKey)
+	defer badSigSrv.Close()
+	unavailableSrv := errorLogSrv()
+	defer unavailableSrv.Close()
+	retryAfter := 1
+	retrySrv := retryableLogSrv(leaf.Raw, k, 1000, &retryAfter)
+	defer retrySrv.Close()
+	for _, srv := range []*httptest.Server{badSigSrv, unavailableSrv, retrySrv} {
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+	pub.minimumSCTCount = 1
+
+	shortCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	pub.submissionTimeout = 1500 * time.Millisecond
+	_, err = pub.CollectSCTs(shortCtx, leaf.Raw)
+	test.AssertError(t, err, "Submission without any SCTs didn't error")
+	failed, ok := err.(ErrSubmissionFailed)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrSubmissionFailed, got %T", err))
+	test.AssertEquals(t, failed.Logs, 3)
+	test.AssertEquals(t, failed.SCTs, 0)
+	test.AssertEquals(t, len(failed.Failures), 3)
+
+	badSig, ok := failed.Failures[0].Err.(ErrBadSCTSignature)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTSignature, got %T", failed.Failures[0].Err))
+	test.AssertEquals(t, badSig.LogURI, pub.ctLogs[0].uri)
+
+	unavailable, ok := failed.Failures[1].Err.(ErrLogUnavailable)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrLogUnavailable, got %T", failed.Failures[1].Err))
+	test.AssertEquals(t, unavailable.LogURI, pub.ctLogs[1].uri)
+	test.AssertEquals(t, unavailable.Status, 500)
+
+	exhausted, ok := failed.Failures[2].Err.(ErrRetryExhausted)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrRetryExhausted, got %T", failed.Failures[2].Err))
+	test.AssertEquals(t, exhausted.LogURI, pub.ctLogs[2].uri)
+	test.Assert(t, exhausted.Attempts > 1, "Exhausted retries should have made several attempts")
+
+	// The audit log still names the underlying error rather than its wrapper
+	test.AssertEquals(t, len(log.GetAllMatching("Failed to submit certificate to CT log at .*: failed to verify ECDSA signature")), 1)
+}
+
+func TestSCTTimestampWindow(t *testing.T) {
+	pub, leaf, k := setup(t)
+	toTimestamp := func(t time.Time) uint64 {
+		return uint64(t.UnixNano() / int64(time.Millisecond))
+	}
+	// The first timestamp is within the allowed skew of the local clock, the
+	// others are too far in the future and from before the leaf was valid
+	timestamps := []uint64{
+		toTimestamp(time.Now().Add(time.Minute)),
+		toTimestamp(time.Now().Add(time.Hour)),
+		toTimestamp(leaf.NotBefore.Add(-time.Hour)),
+	}
+	for _, timestamp := range timestamps {
+		sct := createSignedSCTWithTimestamp(leaf.Raw, k, timestamp)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, sct)
+		}))
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "SCT within the allowed clock skew was rejected")
+
+	result = pub.submitToLog(ctx, pub.ctLogs[1], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "SCT from the future was accepted")
+	badTimestamp, ok := result.Err.(ErrBadSCTTimestamp)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTTimestamp, got %T", result.Err))
+	test.AssertEquals(t, badTimestamp.Reason, "more than 10m0s in the future")
+	test.AssertEquals(t, result.Attempts, 1)
+
+	result = pub.submitToLog(ctx, pub.ctLogs[2], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "SCT from before the certificate was valid was accepted")
+	badTimestamp, ok = result.Err.(ErrBadSCTTimestamp)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTTimestamp, got %T", result.Err))
+	test.Assert(t, strings.HasPrefix(badTimestamp.Reason, "more than 10m0s before the certificate's NotBefore"),
+		fmt.Sprintf("Unexpected reason: %s", badTimestamp.Reason))
+}
+
+// statusLogSrv returns a log that responds with status and body to the first
+// failures submissions, and with an SCT after that
+func statusLogSrv(leaf []byte, k *ecdsa.PrivateKey, status, failures int, body string) *httptest.Server {
+	hits := 0
+	sct := createSignedSCT(leaf, k)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits < failures {
+			hits++
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+}
+
+func TestClientErrorStatuses(t *testing.T) {
+	pub, leaf, k := setup(t)
+	reason := `{"error":"certificate has expired"}`
+	for _, status := range []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv := statusLogSrv(leaf.Raw, k, status, 1, reason)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	// A bad request is permanent, so it isn't retried and the log's reason is
+	// kept
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Rejected submission succeeded")
+	rejected, ok := result.Err.(ErrLogRejected)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrLogRejected, got %T", result.Err))
+	test.AssertEquals(t, rejected.Status, http.StatusBadRequest)
+	test.AssertEquals(t, rejected.Body, "certificate has expired")
+	test.AssertEquals(t, result.Attempts, 1)
+	test.AssertEquals(t, len(log.GetAllMatching("Failed to submit certificate to CT log at .*: .*certificate has expired")), 1)
+
+	// Rate limiting and unavailability are retried
+	for _, ctLog := range pub.ctLogs[1:] {
+		result = pub.submitToLog(ctx, ctLog, ct.X509LogEntryType, leaf)
+		test.AssertNotError(t, result.Err, "Retryable failure wasn't retried")
+		test.AssertEquals(t, result.Attempts, 2)
+	}
+	test.AssertEquals(t, len(log.GetAllMatching(`returned HTTP status "429 Too Many Requests", backing off for 0s: .*certificate has expired`)), 1)
+
+	// Long bodies are truncated
+	snippetSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, strings.Repeat("x", 2*maxBodySnippet))
+	}))
+	defer snippetSrv.Close()
+	port, err := getPort(snippetSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	result = pub.submitToLog(ctx, pub.ctLogs[3], ct.X509LogEntryType, leaf)
+	rejected, ok = result.Err.(ErrLogRejected)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrLogRejected, got %T", result.Err))
+	test.AssertEquals(t, rejected.Body, strings.Repeat("x", maxBodySnippet)+"...")
+}
+
+func TestErrorResponseBody(t *testing.T) {
+	pub, leaf, k := setup(t)
+	bodies := []string{
+		`{"success":false,"error_message":"log is read-only"}`,
+		"internal\n  error\n",
+	}
+	for _, body := range bodies {
+		srv := statusLogSrv(leaf.Raw, k, http.StatusInternalServerError, 1, body)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	// The reason from a JSON error is surfaced on its own, other bodies are
+	// put on one line
+	for i, expected := range []string{"log is read-only", "internal error"} {
+		result := pub.submitToLog(ctx, pub.ctLogs[i], ct.X509LogEntryType, leaf)
+		test.AssertError(t, result.Err, "Submission to a failing log succeeded")
+		unavailable, ok := result.Err.(ErrLogUnavailable)
+		test.Assert(t, ok, fmt.Sprintf("Expected ErrLogUnavailable, got %T", result.Err))
+		test.AssertEquals(t, unavailable.Err.Error(), fmt.Sprintf(`got HTTP Status "500 Internal Server Error": %s`, expected))
+		test.AssertEquals(t, len(log.GetAllMatching("Failed to submit certificate to CT log at .*: .*"+expected)), 1)
+	}
+}
+
+func TestMalformedResponses(t *testing.T) {
+	pub, leaf, k := setup(t)
+	sct := createSignedSCT(leaf.Raw, k)
+	// The first log resets the connection partway through the body of its
+	// first response, the second sends a complete body that is truncated JSON
+	resets := 0
+	resetSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if resets == 0 {
+			resets++
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			test.AssertNotError(t, err, "Failed to hijack connection")
+			fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s",
+				len(sct), sct[:len(sct)/2])
+			buf.Flush()
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+	defer resetSrv.Close()
+	truncatedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct[:len(sct)/2])
+	}))
+	defer truncatedSrv.Close()
+	for _, srv := range []*httptest.Server{resetSrv, truncatedSrv} {
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "Submission wasn't retried after the connection was reset")
+	test.AssertEquals(t, result.Attempts, 2)
+
+	result = pub.submitToLog(ctx, pub.ctLogs[1], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Truncated JSON response was accepted")
+	test.AssertEquals(t, result.Attempts, 1)
+	malformed, ok := result.Err.(ErrMalformedResponse)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrMalformedResponse, got %T: %s", result.Err, result.Err))
+	test.AssertEquals(t, malformed.LogURI, pub.ctLogs[1].uri)
+}
@@ -0,0 +1,136 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+)
+
+const (
+	defaultInclusionPollInterval = 30 * time.Second
+	defaultInclusionTimeout      = 10 * time.Minute
+)
+
+// inclusionWait is the policy for waiting for logs to include the
+// certificates submitted to them, see cmd.CTConfig.WaitForInclusion
+type inclusionWait struct {
+	enabled      bool
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+func newInclusionWait(config cmd.CTConfig) inclusionWait {
+	w := inclusionWait{
+		enabled:      config.WaitForInclusion,
+		pollInterval: config.InclusionPollInterval.Duration,
+		timeout:      config.InclusionTimeout.Duration,
+	}
+	if w.pollInterval == 0 {
+		w.pollInterval = defaultInclusionPollInterval
+	}
+	if w.timeout == 0 {
+		w.timeout = defaultInclusionTimeout
+	}
+	return w
+}
+
+// awaitInclusion waits for each of the required logs that returned an SCT in
+// results to include the certificate, see waitForInclusion, and fails the
+// results of those that don't. results are in the same order as logs. The
+// SCTs are only stored afterwards, see storeSCTs, so the failed results are
+// recorded as failed attempts and the logs are contacted again when the
+// certificate is resubmitted. Best-effort logs don't count towards the
+// submission's success, so aren't waited for.
+func (pub *Impl) awaitInclusion(ctx context.Context, logs []*Log, cert *x509.Certificate, results []SubmissionResult) {
+	// The issuer is only needed for the leaf hash of a precertificate, and
+	// checkIssuer has already parsed it successfully
+	var issuer *x509.Certificate
+	if bundle, _, _ := pub.bundleFor(cert); len(bundle) > 0 {
+		issuer, _ = x509.ParseCertificate(bundle[0].Data)
+	}
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].Err != nil || results[i].BestEffort {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pub.waitForInclusion(ctx, logs[i], cert, issuer, *results[i].SCT)
+			if err != nil {
+				pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Failed to confirm inclusion of certificate %s in CT log at %s: %s",
+					core.SerialToString(cert.SerialNumber), logs[i].uri, err)))
+				results[i].SCT = nil
+				results[i].Err = err
+				pub.recordAttempt(ctx, cert, logs[i], err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// waitForInclusion polls ctLog every inclusion poll interval until it proves
+// that it included the entry sct was issued for in its tree, verifying the
+// proof against the log's current STH. Failures to fetch or verify the STH or
+// proof are logged and polled through, since a log's frontends may lag
+// behind each other. Polling gives up with the error of the last poll once the
+// inclusion timeout has passed, or the SCT's merge deadline if that is
+// sooner, since by then the log has broken its promise to include the entry.
+func (pub *Impl) waitForInclusion(ctx context.Context, ctLog *Log, cert, issuer *x509.Certificate, sct LogSCT) error {
+	leafHash, err := LeafHash(cert, issuer, sct.SignedCertificateTimestamp)
+	if err != nil {
+		return err
+	}
+	deadline := pub.clk.Now().Add(pub.inclusion.timeout)
+	if sct.MergeDeadline.Before(deadline) {
+		deadline = sct.MergeDeadline
+	}
+	for {
+		proof, err := pub.proveInclusion(ctx, ctLog, leafHash)
+		if err == nil {
+			pub.log.Info(fmt.Sprintf("CT log at %s included certificate %s at index %d of its tree of size %d",
+				ctLog.uri, sct.CertificateSerial, proof.LeafIndex, proof.TreeSize))
+			return nil
+		}
+		if _, ok := err.(ErrNotIncorporated); !ok {
+			pub.log.Warning(fmt.Sprintf("Checking inclusion of certificate %s in CT log at %s: %s",
+				sct.CertificateSerial, ctLog.uri, err))
+		}
+		if pub.clk.Now().Add(pub.inclusion.pollInterval).After(deadline) {
+			return err
+		}
+		if err := sleep(ctx, pub.clk, pub.inclusion.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// proveInclusion fetches the current STH of ctLog and the proof that the leaf
+// with leafHash is included in the tree it signs, and verifies the proof
+// against the STH's root hash. ErrNotIncorporated is returned if the log
+// doesn't have the leaf in that tree yet.
+func (pub *Impl) proveInclusion(ctx context.Context, ctLog *Log, leafHash []byte) (*InclusionProof, error) {
+	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
+	defer cancel()
+	sth, err := pub.getSTH(localCtx, ctLog)
+	if err != nil {
+		return nil, err
+	}
+	if sth.TreeSize == 0 {
+		return nil, ErrNotIncorporated{LogURI: ctLog.uri}
+	}
+	proof, err := pub.getInclusionProof(localCtx, ctLog, leafHash, sth.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := proof.Verify(leafHash, sth.SHA256RootHash); err != nil {
+		return nil, fmt.Errorf("inclusion proof from CT log at %s doesn't verify against its STH: %s", ctLog.uri, err)
+	}
+	return proof, nil
+}
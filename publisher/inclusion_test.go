@@ -0,0 +1,135 @@
+package publisher
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestNewInclusionWait(t *testing.T) {
+	w := newInclusionWait(cmd.CTConfig{})
+	test.Assert(t, !w.enabled, "Waiting for inclusion was enabled by default")
+	test.AssertEquals(t, w.pollInterval, defaultInclusionPollInterval)
+	test.AssertEquals(t, w.timeout, defaultInclusionTimeout)
+
+	w = newInclusionWait(cmd.CTConfig{
+		WaitForInclusion:      true,
+		InclusionPollInterval: cmd.ConfigDuration{Duration: time.Second},
+		InclusionTimeout:      cmd.ConfigDuration{Duration: time.Minute},
+	})
+	test.Assert(t, w.enabled, "Waiting for inclusion wasn't enabled")
+	test.AssertEquals(t, w.pollInterval, time.Second)
+	test.AssertEquals(t, w.timeout, time.Minute)
+}
+
+// inclusionLogSrv serves a log that returns sct from add-chain and includes
+// the entry with leafHash at index 1 of a three leaf tree after the first
+// includeAfter requests for a proof. Until then its STH is for a tree of one
+// other leaf.
+func inclusionLogSrv(t *testing.T, k *ecdsa.PrivateKey, sct string, leafHash []byte, includeAfter int) *httptest.Server {
+	other := testLeaves(2)
+	leaves := [][]byte{other[0], leafHash, other[1]}
+	var mu sync.Mutex
+	proofRequests := 0
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/ct/v1/add-chain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	})
+	m.HandleFunc("/ct/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		included := proofRequests >= includeAfter
+		mu.Unlock()
+		sth := createSignedSTHWithRoot(t, k, 1, other[0])
+		if included {
+			sth = createSignedSTHWithRoot(t, k, 3, merkleTreeHash(leaves))
+		}
+		err := json.NewEncoder(w).Encode(sthResponse(t, sth))
+		test.AssertNotError(t, err, "Failed to encode get-sth response")
+	})
+	m.HandleFunc("/ct/ct/v1/get-proof-by-hash", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proofRequests++
+		mu.Unlock()
+		hash, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("hash"))
+		if err != nil || !bytes.Equal(hash, leafHash) || r.URL.Query().Get("tree_size") != "3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resp := ct.GetProofByHashResponse{LeafIndex: 1, AuditPath: auditPath(1, leaves)}
+		err = json.NewEncoder(w).Encode(resp)
+		test.AssertNotError(t, err, "Failed to encode get-proof-by-hash response")
+	})
+	return httptest.NewServer(m)
+}
+
+func TestWaitForInclusion(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.inclusion = inclusionWait{enabled: true, pollInterval: 10 * time.Millisecond, timeout: time.Second}
+	timestamp := nowTimestamp()
+	sct := createSignedSCTWithTimestamp(leaf.Raw, k, timestamp)
+	leafHash, err := LeafHash(leaf, nil, core.SignedCertificateTimestamp{Timestamp: timestamp})
+	test.AssertNotError(t, err, "Failed to compute leaf hash")
+
+	// The log only includes the certificate after a few polls
+	srv := inclusionLogSrv(t, k, sct, leafHash, 3)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	log.Clear()
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Submission to a log that included the certificate failed")
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, len(log.GetAllMatching("included certificate .* at index 1 of its tree of size 3")), 1)
+
+	// A log that doesn't include the certificate before the timeout fails
+	pub, _, _ = setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	pub.inclusion = inclusionWait{enabled: true, pollInterval: 10 * time.Millisecond, timeout: 50 * time.Millisecond}
+	srv = inclusionLogSrv(t, k, sct, leafHash, 1000)
+	defer srv.Close()
+	port, err = getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	report, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission to a log that didn't include the certificate succeeded")
+	test.AssertEquals(t, len(report.SCTs), 0)
+	test.Assert(t, report.Results[0].SCT == nil, "Result of a log that didn't include the certificate has an SCT")
+	_, ok := report.Results[0].Err.(ErrNotIncorporated)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrNotIncorporated, got %T: %s", report.Results[0].Err, report.Results[0].Err))
+	// The SCT isn't stored, so it isn't counted by the retry paths and the
+	// failure is recorded for the log to be retried
+	serial := core.SerialToString(leaf.SerialNumber)
+	stored, err := pub.storage.Load(ctx, serial)
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 0)
+	_, err = pub.SCTListForSerial(ctx, serial)
+	test.AssertError(t, err, "Got an SCT list with an SCT whose inclusion failed")
+	failures, err := pub.SubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 1)
+
+	// The SCT's merge deadline bounds the wait as well as the timeout
+	pub, _, _ = setup(t)
+	pub.inclusion = inclusionWait{enabled: true, pollInterval: 10 * time.Millisecond, timeout: time.Hour}
+	addLog(t, pub, port, &k.PublicKey)
+	pub.ctLogs[0].mmd = 50 * time.Millisecond
+	start := time.Now()
+	_, err = pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission to a log that didn't include the certificate succeeded")
+	test.Assert(t, time.Since(start) < time.Second, "Waiting for inclusion didn't stop at the merge deadline")
+}
@@ -0,0 +1,78 @@
+package publisher
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultMaxResponseSize is the largest response body that will be read from
+// a CT log when no maximum is configured. Responses from the endpoints used
+// are a few kilobytes at most.
+const defaultMaxResponseSize = 1 << 20
+
+// errResponseTooLarge is returned when reading a response body from a CT log
+// that is larger than the maximum response size
+type errResponseTooLarge struct {
+	url   string
+	limit int64
+}
+
+func (e errResponseTooLarge) Error() string {
+	return fmt.Sprintf("response from %s is larger than the maximum of %d bytes", e.url, e.limit)
+}
+
+// limitTransport is an http.RoundTripper that fails reads of response bodies
+// beyond limit bytes, so that a misbehaving log can't exhaust our memory
+type limitTransport struct {
+	inner http.RoundTripper
+	limit int64
+}
+
+func (lt limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := lt.inner.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		remaining:  lt.limit,
+		err:        errResponseTooLarge{url: req.URL.String(), limit: lt.limit},
+	}
+	return resp, nil
+}
+
+// limitedBody returns err from Read once more than the limit has been read,
+// unlike an io.LimitReader, which would silently truncate the body
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	if lb.remaining <= 0 {
+		// Anything more than the limit is an error, but a body of exactly the
+		// limit is fine
+		var probe [1]byte
+		n, err := lb.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, lb.err
+		}
+		return 0, err
+	}
+	if int64(len(p)) > lb.remaining {
+		p = p[:lb.remaining]
+	}
+	n, err := lb.ReadCloser.Read(p)
+	lb.remaining -= int64(n)
+	return n, err
+}
+
+// CloseIdleConnections closes the idle connections of the inner transport, if
+// it pools connections
+func (lt limitTransport) CloseIdleConnections() {
+	if inner, ok := lt.inner.(closeIdler); ok {
+		inner.CloseIdleConnections()
+	}
+}
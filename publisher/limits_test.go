@@ -0,0 +1,63 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestResponseSizeLimit(t *testing.T) {
+	pub, leaf, k := setup(t)
+	sct := createSignedSCT(leaf.Raw, k)
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		// Pad the SCT out with whitespace, which is still valid JSON
+		fmt.Fprint(w, sct+strings.Repeat(" ", 2048))
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+
+	pub.client, err = NewHTTPClient(cmd.CTConfig{MaxResponseSize: int64(len(sct) + 2048)})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	addLog(t, pub, port, &k.PublicKey)
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "Response of exactly the maximum size was rejected")
+
+	// An oversized response fails without being retried
+	pub.client, err = NewHTTPClient(cmd.CTConfig{MaxResponseSize: 1024})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	addLog(t, pub, port, &k.PublicKey)
+	hits = 0
+	result = pub.submitToLog(ctx, pub.ctLogs[1], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Oversized response was accepted")
+	_, ok := result.Err.(errResponseTooLarge)
+	test.Assert(t, ok, fmt.Sprintf("Expected errResponseTooLarge, got %T: %s", result.Err, result.Err))
+	test.AssertEquals(t, hits, 1)
+}
+
+func TestResponseContentType(t *testing.T) {
+	pub, leaf, k := setup(t)
+	sct := createSignedSCT(leaf.Raw, k)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, sct)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "Response with a non-JSON Content-Type was accepted")
+	test.AssertEquals(t, result.Err.Error(), `response has Content-Type "text/html", expected application/json`)
+}
@@ -1,3 +1,3 @@
 package mock_publisher
 
-//go:generate mockgen -package mock_publisher -destination ./mock_publisher.go github.com/letsencrypt/boulder/core Publisher
+//go:generate mockgen -package mock_publisher -destination ./mock_publisher.go github.com/letsencrypt/boulder/publisher Publisher
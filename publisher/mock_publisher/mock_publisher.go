@@ -1,10 +1,11 @@
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/letsencrypt/boulder/core (interfaces: Publisher)
+// Source: github.com/letsencrypt/boulder/publisher (interfaces: Publisher)
 
 package mock_publisher
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	publisher "github.com/letsencrypt/boulder/publisher"
 	context "golang.org/x/net/context"
 )
 
@@ -29,6 +30,28 @@ func (_m *MockPublisher) EXPECT() *_MockPublisherRecorder {
 	return _m.recorder
 }
 
+func (_m *MockPublisher) CollectSCTs(_param0 context.Context, _param1 []byte) ([]publisher.LogSCT, error) {
+	ret := _m.ctrl.Call(_m, "CollectSCTs", _param0, _param1)
+	ret0, _ := ret[0].([]publisher.LogSCT)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPublisherRecorder) CollectSCTs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CollectSCTs", arg0, arg1)
+}
+
+func (_m *MockPublisher) SubmitPrecertToCT(_param0 context.Context, _param1 []byte) ([]publisher.LogSCT, error) {
+	ret := _m.ctrl.Call(_m, "SubmitPrecertToCT", _param0, _param1)
+	ret0, _ := ret[0].([]publisher.LogSCT)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPublisherRecorder) SubmitPrecertToCT(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SubmitPrecertToCT", arg0, arg1)
+}
+
 func (_m *MockPublisher) SubmitToCT(_param0 context.Context, _param1 []byte) error {
 	ret := _m.ctrl.Call(_m, "SubmitToCT", _param0, _param1)
 	ret0, _ := ret[0].(error)
@@ -39,6 +62,17 @@ func (_mr *_MockPublisherRecorder) SubmitToCT(arg0, arg1 interface{}) *gomock.Ca
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SubmitToCT", arg0, arg1)
 }
 
+func (_m *MockPublisher) SubmitToCTDetailed(_param0 context.Context, _param1 []byte) (*publisher.SubmissionReport, error) {
+	ret := _m.ctrl.Call(_m, "SubmitToCTDetailed", _param0, _param1)
+	ret0, _ := ret[0].(*publisher.SubmissionReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPublisherRecorder) SubmitToCTDetailed(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SubmitToCTDetailed", arg0, arg1)
+}
+
 func (_m *MockPublisher) SubmitToSingleCT(_param0 context.Context, _param1 string, _param2 string, _param3 []byte) error {
 	ret := _m.ctrl.Call(_m, "SubmitToSingleCT", _param0, _param1, _param2, _param3)
 	ret0, _ := ret[0].(error)
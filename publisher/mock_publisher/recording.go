@@ -0,0 +1,64 @@
+package mock_publisher
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/publisher"
+)
+
+// RecordingPublisher is a publisher.Publisher for tests that records the
+// certificates submitted to it and returns SCTs and Err for every submission,
+// without the expectations MockPublisher needs set up
+type RecordingPublisher struct {
+	SCTs []publisher.LogSCT
+	Err  error
+
+	mu          sync.Mutex
+	submissions [][]byte
+}
+
+// Submissions returns the DER of every certificate submitted so far, in order
+func (p *RecordingPublisher) Submissions() [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]byte(nil), p.submissions...)
+}
+
+func (p *RecordingPublisher) record(der []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.submissions = append(p.submissions, der)
+}
+
+// SubmitToCT records der and returns Err
+func (p *RecordingPublisher) SubmitToCT(_ context.Context, der []byte) error {
+	p.record(der)
+	return p.Err
+}
+
+// SubmitToSingleCT records der and returns Err
+func (p *RecordingPublisher) SubmitToSingleCT(_ context.Context, _, _ string, der []byte) error {
+	p.record(der)
+	return p.Err
+}
+
+// SubmitToCTDetailed records der and returns a report holding SCTs, along
+// with Err
+func (p *RecordingPublisher) SubmitToCTDetailed(_ context.Context, der []byte) (*publisher.SubmissionReport, error) {
+	p.record(der)
+	return &publisher.SubmissionReport{SCTs: p.SCTs}, p.Err
+}
+
+// CollectSCTs records der and returns SCTs and Err
+func (p *RecordingPublisher) CollectSCTs(_ context.Context, der []byte) ([]publisher.LogSCT, error) {
+	p.record(der)
+	return p.SCTs, p.Err
+}
+
+// SubmitPrecertToCT records precertDER and returns SCTs and Err
+func (p *RecordingPublisher) SubmitPrecertToCT(_ context.Context, precertDER []byte) ([]publisher.LogSCT, error) {
+	p.record(precertDER)
+	return p.SCTs, p.Err
+}
@@ -0,0 +1,37 @@
+package mock_publisher
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/publisher"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var _ publisher.Publisher = &RecordingPublisher{}
+var _ publisher.Publisher = &MockPublisher{}
+
+func TestRecordingPublisher(t *testing.T) {
+	sct := publisher.LogSCT{LogURI: "http://log.example.com"}
+	pub := &RecordingPublisher{SCTs: []publisher.LogSCT{sct}}
+
+	scts, err := pub.CollectSCTs(context.Background(), []byte("first"))
+	test.AssertNotError(t, err, "CollectSCTs failed")
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, scts[0].LogURI, sct.LogURI)
+	report, err := pub.SubmitToCTDetailed(context.Background(), []byte("second"))
+	test.AssertNotError(t, err, "SubmitToCTDetailed failed")
+	test.AssertEquals(t, len(report.SCTs), 1)
+
+	pub.Err = errors.New("no logs")
+	err = pub.SubmitToCT(context.Background(), []byte("third"))
+	test.AssertEquals(t, err, pub.Err)
+
+	submissions := pub.Submissions()
+	test.AssertEquals(t, len(submissions), 3)
+	for i, expected := range []string{"first", "second", "third"} {
+		test.AssertEquals(t, string(submissions[i]), expected)
+	}
+}
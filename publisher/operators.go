@@ -0,0 +1,84 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// OperatorPolicy is a requirement on the operators of the logs that returned
+// the SCTs for a certificate, such as browser policies requiring SCTs from
+// logs run by distinct organisations. The zero value is met by any SCTs.
+type OperatorPolicy struct {
+	// Required lists the operators that must each have run the log of at
+	// least one of the SCTs
+	Required []string
+	// MinimumDistinct is the number of distinct operators the SCTs must come
+	// from
+	MinimumDistinct int
+}
+
+// ErrOperatorPolicy is returned when the SCTs collected for a certificate
+// don't meet the configured OperatorPolicy. Operators are the distinct
+// operators of the logs that did return SCTs, and Missing the required
+// operators that didn't.
+type ErrOperatorPolicy struct {
+	Operators       []string
+	Missing         []string
+	MinimumDistinct int
+}
+
+func (e ErrOperatorPolicy) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("no SCTs from logs run by %s", strings.Join(e.Missing, ", ")))
+	}
+	if len(e.Operators) < e.MinimumDistinct {
+		problems = append(problems, fmt.Sprintf("SCTs from logs of %d distinct operators, %d are required",
+			len(e.Operators), e.MinimumDistinct))
+	}
+	return fmt.Sprintf("SCTs don't meet the operator policy: %s", strings.Join(problems, " and "))
+}
+
+// Check returns an ErrOperatorPolicy if scts don't meet the policy. SCTs from
+// logs without a configured operator don't count towards it.
+func (p OperatorPolicy) Check(scts []LogSCT) error {
+	seen := make(map[string]bool)
+	var operators []string
+	for _, sct := range scts {
+		if sct.Operator != "" && !seen[sct.Operator] {
+			seen[sct.Operator] = true
+			operators = append(operators, sct.Operator)
+		}
+	}
+	var missing []string
+	for _, required := range p.Required {
+		if !seen[required] {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) == 0 && len(operators) >= p.MinimumDistinct {
+		return nil
+	}
+	sort.Strings(operators)
+	return ErrOperatorPolicy{
+		Operators:       operators,
+		Missing:         missing,
+		MinimumDistinct: p.MinimumDistinct,
+	}
+}
+
+// checkOperatorPolicy checks the SCTs collected for cert against the
+// publisher's operator policy, audit logging a failure
+func (pub *Impl) checkOperatorPolicy(ctx context.Context, cert *x509.Certificate, scts []LogSCT) error {
+	err := pub.operatorPolicy.Check(scts)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Certificate %s: %s", core.SerialToString(cert.SerialNumber), err)))
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestOperatorPolicy(t *testing.T) {
+	pub, leaf, k := setup(t)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	newLog := func(operator string) *Log {
+		ctLog, err := NewLog(cmd.LogDescription{
+			URI:      fmt.Sprintf("http://localhost:%d/ct", port),
+			Key:      base64.StdEncoding.EncodeToString(der),
+			Operator: operator,
+		}, pub.client, log)
+		test.AssertNotError(t, err, "Couldn't create log")
+		return ctLog
+	}
+	pub.operatorPolicy = OperatorPolicy{Required: []string{"Google"}, MinimumDistinct: 2}
+
+	// SCTs from a Google log and another operator's log meet the policy
+	pub.ctLogs = []*Log{newLog("Google"), newLog("Other")}
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "SCTs from two operators didn't meet the policy")
+	test.AssertEquals(t, len(scts), 2)
+	test.AssertEquals(t, scts[0].Operator, "Google")
+
+	// SCTs from two logs of the same operator don't
+	pub.ctLogs = []*Log{newLog("Other"), newLog("Other")}
+	scts, err = pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertError(t, err, "SCTs from a single operator met the policy")
+	test.AssertEquals(t, len(scts), 2)
+	policyErr, ok := err.(ErrOperatorPolicy)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrOperatorPolicy, got %T", err))
+	test.AssertDeepEquals(t, policyErr.Operators, []string{"Other"})
+	test.AssertDeepEquals(t, policyErr.Missing, []string{"Google"})
+	test.AssertEquals(t, err.Error(),
+		"SCTs don't meet the operator policy: no SCTs from logs run by Google and SCTs from logs of 1 distinct operators, 2 are required")
+
+	// Logs without an operator don't count towards the policy
+	err = OperatorPolicy{MinimumDistinct: 1}.Check([]LogSCT{{LogURI: "http://example.com/ct"}})
+	test.AssertError(t, err, "SCT from a log without an operator met the policy")
+	test.AssertNotError(t, OperatorPolicy{}.Check(nil), "Empty policy wasn't met")
+}
@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+)
+
+const (
+	defaultSubmissionConcurrency = 100
+	defaultSubmissionQueueDepth  = 1000
+)
+
+// ErrQueueFull is the error for a submission to a log that was refused
+// because too many submissions were already waiting to be made
+var ErrQueueFull = errors.New("CT submission queue is full")
+
+// submissionPool bounds the submissions to logs made at once across every
+// certificate. Submissions beyond the concurrency limit wait in a queue of
+// bounded depth, and are refused once it's full rather than piling up.
+type submissionPool struct {
+	slots chan struct{}
+
+	mu       sync.Mutex
+	queued   int
+	maxQueue int
+	depth    prometheus.Gauge
+}
+
+func newSubmissionPool(concurrency, maxQueue int, depth prometheus.Gauge) *submissionPool {
+	if concurrency <= 0 {
+		concurrency = defaultSubmissionConcurrency
+	}
+	if maxQueue <= 0 {
+		maxQueue = defaultSubmissionQueueDepth
+	}
+	return &submissionPool{
+		slots:    make(chan struct{}, concurrency),
+		maxQueue: maxQueue,
+		depth:    depth,
+	}
+}
+
+// enqueue takes a free submission slot if there is one, and otherwise
+// reserves a place in the queue, returning ErrQueueFull if there isn't one.
+// It doesn't block, so it's called before starting the goroutine for a
+// submission, which then calls wait on the returned ticket.
+func (sp *submissionPool) enqueue() (*poolTicket, error) {
+	select {
+	case sp.slots <- struct{}{}:
+		return &poolTicket{pool: sp, held: true}, nil
+	default:
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.queued >= sp.maxQueue {
+		return nil, ErrQueueFull
+	}
+	sp.queued++
+	sp.depth.Set(float64(sp.queued))
+	return &poolTicket{pool: sp}, nil
+}
+
+// poolTicket is a submission admitted to a submissionPool, either holding a
+// slot already or waiting in the queue for one
+type poolTicket struct {
+	pool *submissionPool
+	held bool
+}
+
+// wait returns once the ticket holds a submission slot, returning the func
+// that frees the slot again, or ctx's error if it's done first
+func (pt *poolTicket) wait(ctx context.Context) (func(), error) {
+	sp := pt.pool
+	release := func() { <-sp.slots }
+	if pt.held {
+		return release, nil
+	}
+	defer func() {
+		sp.mu.Lock()
+		sp.queued--
+		sp.depth.Set(float64(sp.queued))
+		sp.mu.Unlock()
+	}()
+	select {
+	case sp.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestSubmissionPool(t *testing.T) {
+	pub, leaf, k := setup(t)
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer srv.Close()
+	defer close(hung)
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	for i := 0; i < 3; i++ {
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	// With one submission at a time and room for one more to wait, the first log
+	// is submitted to, the second waits until the deadline and the third is
+	// refused straight away
+	pub.pool = newSubmissionPool(1, 1, pub.metrics.queueDepth)
+	shortCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	results := pub.submitToLogs(shortCtx, pub.ctLogs, ct.X509LogEntryType, leaf)
+	test.AssertEquals(t, len(results), 3)
+	test.AssertError(t, results[0].Err, "Submission to a hung log succeeded")
+	test.Assert(t, results[0].Attempts > 0, "First log wasn't submitted to")
+	test.AssertEquals(t, results[1].Err, context.DeadlineExceeded)
+	test.AssertEquals(t, results[1].Attempts, 0)
+	test.AssertEquals(t, results[2].Err, ErrQueueFull)
+	test.AssertEquals(t, results[2].Attempts, 0)
+
+	var m io_prometheus_client.Metric
+	err = pub.metrics.queueDepth.Write(&m)
+	test.AssertNotError(t, err, "Failed to read queue depth")
+	test.AssertEquals(t, m.Gauge.GetValue(), float64(0))
+}
@@ -0,0 +1,129 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	ctX509 "github.com/google/certificate-transparency-go/x509"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// issueTestPrecert issues a precertificate containing the CT poison extension
+func issueTestPrecert(t *testing.T, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "precert"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier(ctX509.OIDExtensionCTPoison), Critical: true, Value: []byte{0x05, 0x00}},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &testKey(t).PublicKey, issuerKey)
+	test.AssertNotError(t, err, "Failed to create test precertificate")
+	precert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse test precertificate")
+	return precert
+}
+
+// createSignedPrecertSCT returns a JSON SCT signed over the precert_entry for
+// the precertificate and issuer
+func createSignedPrecertSCT(t *testing.T, precert, issuer *x509.Certificate, k *ecdsa.PrivateKey) []byte {
+	rawKey, _ := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	pkHash := sha256.Sum256(rawKey)
+	timestamp := nowTimestamp()
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: pkHash},
+		Timestamp:  timestamp,
+	}
+	tbs, err := ctX509.RemoveCTPoison(precert.RawTBSCertificate)
+	test.AssertNotError(t, err, "Failed to remove CT poison")
+	serialized, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{
+		Leaf: ct.MerkleTreeLeaf{
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				EntryType: ct.PrecertLogEntryType,
+				PrecertEntry: &ct.PreCert{
+					IssuerKeyHash:  sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
+					TBSCertificate: tbs,
+				},
+			},
+		},
+	})
+	test.AssertNotError(t, err, "Failed to serialize precert SCT signature input")
+	hashed := sha256.Sum256(serialized)
+	var ecdsaSig struct {
+		R, S *big.Int
+	}
+	ecdsaSig.R, ecdsaSig.S, _ = ecdsa.Sign(rand.Reader, k, hashed[:])
+	sig, _ := asn1.Marshal(ecdsaSig)
+	ds := ct.DigitallySigned{
+		Algorithm: ctTLS.SignatureAndHashAlgorithm{
+			Hash:      ctTLS.SHA256,
+			Signature: ctTLS.ECDSA,
+		},
+		Signature: sig,
+	}
+	b64Sig, _ := ds.Base64String()
+	jsonSCT, _ := json.Marshal(map[string]interface{}{
+		"sct_version": ct.V1,
+		"id":          base64.StdEncoding.EncodeToString(pkHash[:]),
+		"timestamp":   timestamp,
+		"signature":   b64Sig,
+	})
+	return jsonSCT
+}
+
+func TestSubmitPrecertToCT(t *testing.T) {
+	pub, _, k := setup(t)
+	rootKey, intKey := testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	intermediate := issueTestCert(t, "intermediate", true, &intKey.PublicKey, root, rootKey)
+	precert := issueTestPrecert(t, intermediate, intKey)
+	pub.issuerBundle = []ct.ASN1Cert{{Data: intermediate.Raw}}
+
+	sct := createSignedPrecertSCT(t, precert, intermediate, k)
+	var paths []string
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var jsonReq ctSubmissionRequest
+		if err := json.NewDecoder(r.Body).Decode(&jsonReq); err != nil || len(jsonReq.Chain) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(sct)
+	})
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	scts, err := pub.SubmitPrecertToCT(ctx, precert.Raw)
+	test.AssertNotError(t, err, "Precertificate submission failed")
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, len(paths), 1)
+	test.Assert(t, strings.HasSuffix(paths[0], "/ct/v1/add-pre-chain"), "Precertificate wasn't submitted to add-pre-chain")
+
+	// The precert SCT shouldn't verify as an x509_entry SCT
+	_, err = pub.CollectSCTs(ctx, precert.Raw)
+	test.AssertError(t, err, "Precert SCT verified as an x509_entry SCT")
+}
@@ -0,0 +1,194 @@
+package publisher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	ctX509 "github.com/google/certificate-transparency-go/x509"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// InclusionProof is a CT log's proof that a leaf is included in the tree of
+// the given size, as returned by its get-proof-by-hash endpoint
+type InclusionProof struct {
+	LogURI    string
+	TreeSize  uint64
+	LeafIndex int64
+	// AuditPath is the list of Merkle tree nodes needed to compute the tree's
+	// root hash from the leaf hash
+	AuditPath [][]byte
+}
+
+// ErrNotIncorporated is returned by GetInclusionProof when the log doesn't
+// have the leaf in the tree of the requested size. This is expected until the
+// log's Maximum Merge Delay has passed, after which it indicates the log
+// broke its promise to incorporate the certificate.
+type ErrNotIncorporated struct {
+	LogURI   string
+	TreeSize uint64
+}
+
+func (e ErrNotIncorporated) Error() string {
+	return fmt.Sprintf("leaf isn't incorporated in the tree of size %d of CT log at %s", e.TreeSize, e.LogURI)
+}
+
+// GetInclusionProof fetches the proof that the leaf with the given hash is
+// included in the tree of size treeSize of the configured log with the given
+// URI. The proof's shape is checked but it isn't verified against a root hash,
+// see InclusionProof.Verify.
+func (pub *Impl) GetInclusionProof(ctx context.Context, logURI string, leafHash []byte, treeSize uint64) (*InclusionProof, error) {
+	ctLog, err := pub.logByURI(logURI)
+	if err != nil {
+		return nil, err
+	}
+	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
+	defer cancel()
+	return pub.getInclusionProof(localCtx, ctLog, leafHash, treeSize)
+}
+
+func (pub *Impl) getInclusionProof(ctx context.Context, ctLog *Log, leafHash []byte, treeSize uint64) (*InclusionProof, error) {
+	logURI := ctLog.uri
+	if len(leafHash) != sha256.Size {
+		return nil, fmt.Errorf("leaf hash is %d bytes, expected %d", len(leafHash), sha256.Size)
+	}
+	// The CT client's GetProofByHash escapes the hash twice, so the request is
+	// made directly
+	params := map[string]string{
+		"hash":      base64.StdEncoding.EncodeToString(leafHash),
+		"tree_size": strconv.FormatUint(treeSize, 10),
+	}
+	var resp ct.GetProofByHashResponse
+	httpResp, err := pub.doRequest(ctx, ctLog, http.MethodGet, ct.GetProofByHashPath, params, nil, &resp)
+	if err == nil && httpResp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotIncorporated{LogURI: logURI, TreeSize: treeSize}
+	}
+	if err == nil && httpResp.StatusCode != http.StatusOK {
+		err = statusError(ctx, httpResp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching inclusion proof from CT log at %s: %s", logURI, err)
+	}
+	if resp.LeafIndex < 0 || uint64(resp.LeafIndex) >= treeSize {
+		return nil, fmt.Errorf("malformed inclusion proof from CT log at %s: leaf_index %d is outside the tree of size %d",
+			logURI, resp.LeafIndex, treeSize)
+	}
+	for i, node := range resp.AuditPath {
+		if len(node) != sha256.Size {
+			return nil, fmt.Errorf("malformed inclusion proof from CT log at %s: audit_path node %d is %d bytes, expected %d",
+				logURI, i, len(node), sha256.Size)
+		}
+	}
+	return &InclusionProof{
+		LogURI:    logURI,
+		TreeSize:  treeSize,
+		LeafIndex: resp.LeafIndex,
+		AuditPath: resp.AuditPath,
+	}, nil
+}
+
+// Verify checks that the proof shows the leaf with leafHash is included in the
+// tree with rootHash, by recomputing the root hash from the audit path as
+// described in RFC 9162 section 2.1.3.2
+func (p *InclusionProof) Verify(leafHash, rootHash []byte) error {
+	if p.LeafIndex < 0 || uint64(p.LeafIndex) >= p.TreeSize {
+		return fmt.Errorf("leaf_index %d is outside the tree of size %d", p.LeafIndex, p.TreeSize)
+	}
+	fn, sn := uint64(p.LeafIndex), p.TreeSize-1
+	r := leafHash
+	for _, node := range p.AuditPath {
+		if sn == 0 {
+			return fmt.Errorf("audit_path has more nodes than the tree of size %d needs", p.TreeSize)
+		}
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(node, r)
+			// Skip the levels where the leaf's subtree is the last in the tree
+			// and has no sibling
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, node)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("audit_path has fewer nodes than the tree of size %d needs", p.TreeSize)
+	}
+	if !bytes.Equal(r, rootHash) {
+		return fmt.Errorf("computed root hash %x doesn't match root hash %x", r, rootHash)
+	}
+	return nil
+}
+
+// hashChildren returns the Merkle tree hash of an interior node. Interior
+// node hashes are domain separated from leaf hashes by a one byte prefix.
+func hashChildren(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// LeafHash returns the RFC 6962 Merkle tree leaf hash of the entry a log
+// added for cert when it issued sct, for use with GetInclusionProof.
+// Precertificates, which carry the CT poison extension, are hashed as
+// precert_entry leaves, which include the hash of their issuer's key. issuer
+// isn't used for certificates and may be nil.
+func LeafHash(cert, issuer *x509.Certificate, sct core.SignedCertificateTimestamp) ([]byte, error) {
+	entry := &ct.TimestampedEntry{
+		Timestamp:  sct.Timestamp,
+		EntryType:  ct.X509LogEntryType,
+		X509Entry:  &ct.ASN1Cert{Data: cert.Raw},
+		Extensions: ct.CTExtensions(sct.Extensions),
+	}
+	if isPrecert(cert) {
+		if issuer == nil {
+			return nil, fmt.Errorf("the issuer of a precertificate is needed to compute its leaf hash")
+		}
+		tbs, err := ctX509.RemoveCTPoison(cert.RawTBSCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("removing CT poison from precertificate: %s", err)
+		}
+		entry.EntryType = ct.PrecertLogEntryType
+		entry.X509Entry = nil
+		entry.PrecertEntry = &ct.PreCert{
+			IssuerKeyHash:  sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
+			TBSCertificate: tbs,
+		}
+	}
+	leaf, err := ctTLS.Marshal(ct.MerkleTreeLeaf{
+		Version:          ct.V1,
+		LeafType:         ct.TimestampedEntryLeafType,
+		TimestampedEntry: entry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("serializing Merkle tree leaf: %s", err)
+	}
+	// Leaf hashes are domain separated from interior node hashes by a zero
+	// byte prefix
+	hash := sha256.Sum256(append([]byte{0}, leaf...))
+	return hash[:], nil
+}
+
+// isPrecert returns true if cert has the critical CT poison extension
+func isPrecert(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier(ctX509.OIDExtensionCTPoison)) {
+			return true
+		}
+	}
+	return false
+}
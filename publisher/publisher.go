@@ -1,11 +1,18 @@
 package publisher
 
 import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,8 +20,13 @@ import (
 	ct "github.com/google/certificate-transparency-go"
 	ctClient "github.com/google/certificate-transparency-go/client"
 	"github.com/google/certificate-transparency-go/jsonclient"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
 
+	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -26,7 +38,45 @@ type Log struct {
 	uri      string
 	statName string
 	client   *ctClient.LogClient
+	// verifier is nil when signature verification has been disabled for this
+	// log, in which case only the structure of returned SCTs is checked
 	verifier *ct.SignatureVerifier
+	// keyDER is the log's DER encoded public key and keyID its SHA-256 hash,
+	// which SCTs from the log are expected to carry as their LogID. Both are
+	// unset when signature verification is disabled for the log.
+	keyDER []byte
+	keyID  [sha256.Size]byte
+	// curve is the curve of the log's key when it is an ECDSA key
+	curve elliptic.Curve
+	// mmd is the log's Maximum Merge Delay
+	mmd time.Duration
+	// operator is the organisation that runs the log, if configured
+	operator string
+	// disabled logs aren't submitted to, see enabledLogs
+	disabled bool
+	// tier is the log's configured tier, see bestEffort
+	tier string
+	// maxGetEntries is the most entries requested at once by GetEntries
+	maxGetEntries int
+	// maxRetryAfter is the longest Retry-After from the log that is honoured,
+	// see retryAfter
+	maxRetryAfter time.Duration
+	// limiter limits the rate of requests to the log, it is nil if the log
+	// has no rate limit
+	limiter *rateLimiter
+	// temporal is the NotAfter range of certificates the log accepts, it is
+	// nil if the log isn't temporally sharded
+	temporal *cmd.TemporalInterval
+	// omitIssuer is whether certificates are submitted to the log without
+	// their issuer chain, see sendsIssuer
+	omitIssuer bool
+
+	// roots are the roots the log accepts and the issuer chain selected for
+	// them, see cachedRoots. rootsFetching is closed when a fetch of the roots
+	// in progress finishes, and is nil when there is none.
+	rootsMu       sync.Mutex
+	roots         *rootsCache
+	rootsFetching chan struct{}
 }
 
 // logCache contains a cache of *Log's that are constructed as required by
@@ -38,7 +88,7 @@ type logCache struct {
 
 // AddLog adds a *Log to the cache by constructing the statName, client and
 // verifier for the given uri & base64 public key.
-func (c *logCache) AddLog(uri, b64PK string, logger blog.Logger) (*Log, error) {
+func (c *logCache) AddLog(uri, b64PK string, httpClient *http.Client, logger blog.Logger) (*Log, error) {
 	// Lock the mutex for reading to check the cache
 	c.RLock()
 	log, present := c.logs[b64PK]
@@ -49,12 +99,17 @@ func (c *logCache) AddLog(uri, b64PK string, logger blog.Logger) (*Log, error) {
 		return log, nil
 	}
 
-	// Lock the mutex for writing to add to the cache
+	// Lock the mutex for writing to add to the cache, and check again in case
+	// another submission added the log since, so that every submission to it
+	// shares the same rate limit and chain
 	c.Lock()
 	defer c.Unlock()
+	if log, present := c.logs[b64PK]; present {
+		return log, nil
+	}
 
 	// Construct a Log, add it to the cache, and return it to the caller
-	log, err := NewLog(uri, b64PK, logger)
+	log, err := NewLog(cmd.LogDescription{URI: uri, Key: b64PK}, httpClient, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -77,39 +132,265 @@ func (la logAdaptor) Printf(s string, args ...interface{}) {
 	la.Logger.Info(fmt.Sprintf(s, args...))
 }
 
-// NewLog returns an initialized Log struct
-func NewLog(uri, b64PK string, logger blog.Logger) (*Log, error) {
-	url, err := url.Parse(uri)
+// NewLogs returns a Log for each of the log descriptions in config, using
+// httpClient for requests to all of them. Every log URI must be an absolute
+// https URL unless the description allows plain http. All of the invalid
+// descriptions are named in the returned error, rather than only the first.
+func NewLogs(config cmd.CTConfig, httpClient *http.Client, logger blog.Logger) ([]*Log, error) {
+	var logs []*Log
+	var problems []string
+	for _, ld := range config.Logs {
+		if err := validateLogURI(ld); err != nil {
+			problems = append(problems, fmt.Sprintf("%q: %s", ld.URI, err))
+			continue
+		}
+		ctLog, err := NewLog(ld, httpClient, logger)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%q: %s", ld.URI, err))
+			continue
+		}
+		logs = append(logs, ctLog)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid CT log descriptions: %s", strings.Join(problems, "; "))
+	}
+	return logs, nil
+}
+
+func validateLogURI(ld cmd.LogDescription) error {
+	u, err := url.Parse(ld.URI)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && !(ld.AllowHTTP && u.Scheme == "http") {
+		return fmt.Errorf("URI scheme must be https, not %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URI has no host")
+	}
+	return nil
+}
+
+// defaultRequestTimeout bounds each request to a CT log when the CTConfig
+// doesn't configure a RequestTimeout
+const defaultRequestTimeout = time.Minute
+
+// NewHTTPClient returns the HTTP client used to talk to the CT logs in config.
+// It's constructed once and shared between every log's client so that
+// connections to the logs are pooled. Response bodies larger than the
+// configured maximum response size fail to read. The transport asks for gzip
+// compressed responses and decompresses them before the size limit is
+// applied, so the limit is on the decompressed body. Connections are opened
+// with config's DialContext, if set. An error is returned if the TLS settings
+// in config are invalid.
+func NewHTTPClient(config cmd.CTConfig) (*http.Client, error) {
+	timeout := config.RequestTimeout.Duration
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+	maxResponseSize := config.MaxResponseSize
+	if maxResponseSize == 0 {
+		maxResponseSize = defaultMaxResponseSize
+	}
+	idleConnTimeout := config.IdleConnTimeout.Duration
+	if idleConnTimeout == 0 {
+		idleConnTimeout = 90 * time.Second
+	}
+	// These match http.DefaultTransport, aside from the connection pooling
+	// settings taken from config
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if config.DialContext != nil {
+		transport.DialContext = config.DialContext
+	}
+	proxy, err := logProxy(config)
 	if err != nil {
 		return nil, err
 	}
-	url.Path = strings.TrimSuffix(url.Path, "/")
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	tlsConfig, err := logTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	if config.ForceHTTP2 {
+		if err := http2.ConfigureTransport(transport); err != nil {
+			return nil, fmt.Errorf("configuring HTTP/2: %s", err)
+		}
+	}
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent()
+	}
+	return &http.Client{
+		Transport: userAgentTransport{
+			inner:         limitTransport{inner: transport, limit: maxResponseSize},
+			userAgent:     userAgent,
+			sendRequestID: config.SendRequestID,
+		},
+		Timeout: timeout,
+	}, nil
+}
 
-	pemPK := fmt.Sprintf("-----BEGIN PUBLIC KEY-----\n%s\n-----END PUBLIC KEY-----",
-		b64PK)
-	opts := jsonclient.Options{
-		Logger:    logAdaptor{logger},
-		PublicKey: pemPK,
+// logProxy parses the forward proxy configured for requests to the logs,
+// returning nil if there isn't one
+func logProxy(config cmd.CTConfig) (*url.URL, error) {
+	if config.ProxyURL == "" {
+		return nil, nil
 	}
-	client, err := ctClient.New(url.String(), &http.Client{}, opts)
+	proxy, err := url.Parse(config.ProxyURL)
 	if err != nil {
-		return nil, fmt.Errorf("making CT client: %s", err)
+		// Leave out the URL, which may include credentials
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("parsing ProxyURL: %s", err)
 	}
+	if proxy.Scheme != "http" && proxy.Scheme != "https" {
+		return nil, fmt.Errorf("ProxyURL %q must be an http or https URL", redactURL(proxy))
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("ProxyURL %q has no host", redactURL(proxy))
+	}
+	return proxy, nil
+}
+
+// redactURL returns u as a string without its password, if any, so that it
+// can be included in errors
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	redacted := *u
+	redacted.User = url.User(u.User.Username())
+	return redacted.String()
+}
 
-	// TODO: Maybe this isn't necessary any more now that ctClient can check sigs?
-	pkBytes, err := base64.StdEncoding.DecodeString(b64PK)
+// checkLogDescription checks the settings of a log description that NewLog
+// doesn't otherwise need to parse
+func checkLogDescription(ld cmd.LogDescription) error {
+	switch ld.Tier {
+	case "", cmd.RequiredLogTier, cmd.BestEffortLogTier:
+	default:
+		return fmt.Errorf("unknown CT log tier %q", ld.Tier)
+	}
+	if ld.RateLimit < 0 || ld.RateBurst < 0 {
+		return fmt.Errorf("CT log at %s has a negative rate limit", ld.URI)
+	}
+	if ti := ld.TemporalInterval; ti != nil && !ti.StartInclusive.Before(ti.EndExclusive) {
+		return fmt.Errorf("CT log at %s has a temporal interval that doesn't end after it starts", ld.URI)
+	}
+	return nil
+}
+
+// parseLogKey parses a log's base64 encoded DER public key, returning the DER
+// along with the key and its curve, see logKeyCurve
+func parseLogKey(b64PK string) ([]byte, crypto.PublicKey, elliptic.Curve, error) {
+	keyDER, err := base64.StdEncoding.DecodeString(b64PK)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to decode base64 log public key")
+		return nil, nil, nil, fmt.Errorf("Failed to decode base64 log public key")
 	}
-	pk, err := x509.ParsePKIXPublicKey(pkBytes)
+	pk, err := x509.ParsePKIXPublicKey(keyDER)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse log public key")
+		return nil, nil, nil, fmt.Errorf("Failed to parse log public key")
 	}
+	curve, err := logKeyCurve(pk)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return keyDER, pk, curve, nil
+}
 
-	verifier, err := ct.NewSignatureVerifier(pk)
+// NewLog returns an initialized Log struct for the provided log description.
+// Requests to the log are made using httpClient's transport and timeout, or
+// http.DefaultClient's if it is nil.
+func NewLog(ld cmd.LogDescription, httpClient *http.Client, logger blog.Logger) (*Log, error) {
+	uri, b64PK := ld.URI, ld.Key
+	url, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkLogDescription(ld); err != nil {
+		return nil, err
+	}
+	url.Path = strings.TrimSuffix(url.Path, "/")
+	// The CT client appends the path of each endpoint to the log's base URL.
+	// Older configs included the add-chain endpoint in the URI, so strip it and
+	// use what's left as the base URL.
+	for _, endpoint := range []string{ct.AddChainPath, ct.AddPreChainPath} {
+		if strings.HasSuffix(url.Path, endpoint) {
+			url.Path = strings.TrimSuffix(url.Path, endpoint)
+			logger.Warning(fmt.Sprintf(
+				"CT log URI %s includes the %s endpoint, it should be configured as the log's base URL %s",
+				uri, endpoint, url))
+			break
+		}
+	}
+
+	opts := jsonclient.Options{
+		Logger: logAdaptor{logger},
+	}
+	var verifier *ct.SignatureVerifier
+	var keyDER []byte
+	var keyID [sha256.Size]byte
+	var curve elliptic.Curve
+	if ld.SkipSignatureVerification {
+		logger.Warning(fmt.Sprintf(
+			"Signature verification is disabled for CT log at %s, SCTs from this log will only be checked structurally",
+			uri))
+	} else {
+		// The key isn't given to the CT client, so that SCTs are verified by
+		// singleLogSubmit and verification failures can be told apart from
+		// other errors
+		var pk crypto.PublicKey
+		keyDER, pk, curve, err = parseLogKey(b64PK)
+		if err != nil {
+			return nil, err
+		}
+		keyID = sha256.Sum256(keyDER)
+
+		verifier, err = ct.NewSignatureVerifier(pk)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mmd := ld.MMD.Duration
+	if mmd == 0 {
+		mmd = defaultMMD
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	inner := httpClient.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	// Each log has its own client wrapping the shared transport, so the
+	// request timeout can be set per log
+	timeout := httpClient.Timeout
+	if ld.Timeout.Duration > 0 {
+		timeout = ld.Timeout.Duration
+	}
+	client, err := ctClient.New(url.String(), &http.Client{
+		Transport: logTransport{inner: inner},
+		Timeout:   timeout,
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("making CT client: %s", err)
+	}
 
 	// Replace slashes with dots for statsd logging
 	sanitizedPath := strings.TrimPrefix(url.Path, "/")
@@ -117,39 +398,401 @@ func NewLog(uri, b64PK string, logger blog.Logger) (*Log, error) {
 
 	sanitizedHost := strings.Replace(url.Host, ":", "_", -1)
 
-	return &Log{
-		logID:    b64PK,
-		uri:      uri,
-		statName: fmt.Sprintf("%s.%s", sanitizedHost, sanitizedPath),
-		client:   client,
-		verifier: verifier,
-	}, nil
+	ctLog := &Log{
+		logID:      b64PK,
+		uri:        uri,
+		statName:   fmt.Sprintf("%s.%s", sanitizedHost, sanitizedPath),
+		client:     client,
+		verifier:   verifier,
+		keyDER:     keyDER,
+		keyID:      keyID,
+		curve:      curve,
+		mmd:        mmd,
+		operator:   ld.Operator,
+		disabled:   ld.Enabled != nil && !*ld.Enabled,
+		tier:       ld.Tier,
+		limiter:    newRateLimiter(ld.RateLimit, ld.RateBurst),
+		temporal:   ld.TemporalInterval,
+		omitIssuer: ld.OmitIssuer,
+	}
+	ctLog.maxRetryAfter = ld.MaxRetryAfter.Duration
+	if ctLog.maxRetryAfter == 0 {
+		ctLog.maxRetryAfter = defaultMaxRetryAfter
+	}
+	ctLog.maxGetEntries = ld.MaxGetEntries
+	if ctLog.maxGetEntries <= 0 {
+		ctLog.maxGetEntries = defaultMaxGetEntries
+	}
+	return ctLog, nil
+}
+
+// LogInfo returns the DER encoded public key and log ID of the configured log
+// with the given URI, for building or verifying SCT lists elsewhere without
+// parsing the log config again. ok is false if no log is configured with the
+// URI. For a log with signature verification disabled its key isn't parsed,
+// so keyDER is nil and logID zero.
+func (pub *Impl) LogInfo(uri string) (keyDER []byte, logID [sha256.Size]byte, ok bool) {
+	ctLog, err := pub.logByURI(uri)
+	if err != nil {
+		return nil, logID, false
+	}
+	return append([]byte(nil), ctLog.keyDER...), ctLog.keyID, true
+}
+
+// bestEffort returns true if the log is in the best-effort tier, so that its
+// SCTs don't count towards the minimum SCT count and its failures are ignored
+func (l *Log) bestEffort() bool {
+	return l.tier == cmd.BestEffortLogTier
+}
+
+// defaultMMD is the Maximum Merge Delay assumed for logs that don't have one
+// configured
+const defaultMMD = 24 * time.Hour
+
+// SubmissionResult describes the outcome of submitting a certificate to a
+// single CT log, including the number of HTTP attempts it took and the status
+// and latency of the final attempt
+type SubmissionResult struct {
+	LogURI string
+	// BestEffort is set for results from best-effort logs, which don't count
+	// towards the minimum SCT count
+	BestEffort bool
+	// SCT is the SCT returned by the log, it is nil if the submission failed
+	SCT          *LogSCT
+	Attempts     int
+	FinalStatus  int
+	FinalLatency time.Duration
+	Err          error
+}
+
+// Retries returns the number of requests made to the log beyond the first
+func (r SubmissionResult) Retries() int {
+	if r.Attempts <= 1 {
+		return 0
+	}
+	return r.Attempts - 1
+}
+
+// permanentlyRejected returns true if the log refused the submission with a
+// client error status that retrying won't fix
+func (r SubmissionResult) permanentlyRejected() bool {
+	return r.Err != nil &&
+		r.FinalStatus >= 400 && r.FinalStatus < 500 &&
+		r.FinalStatus != http.StatusRequestTimeout &&
+		r.FinalStatus != http.StatusTooManyRequests
+}
+
+// ErrAllLogsRejected is returned when every configured log permanently
+// rejected a certificate. This is distinct from logs being unavailable and
+// usually indicates a systemic problem, e.g. submitting with the wrong issuer.
+type ErrAllLogsRejected struct {
+	// Reasons maps each log URI to the error it returned
+	Reasons map[string]error
+}
+
+func (e ErrAllLogsRejected) Error() string {
+	uris := make([]string, 0, len(e.Reasons))
+	for uri := range e.Reasons {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	reasons := make([]string, len(uris))
+	for i, uri := range uris {
+		reasons[i] = fmt.Sprintf("%s: %s", uri, e.Reasons[uri])
+	}
+	return fmt.Sprintf("all %d CT logs rejected the certificate: %s",
+		len(uris), strings.Join(reasons, "; "))
 }
 
 type ctSubmissionRequest struct {
 	Chain []string `json:"chain"`
 }
 
-// Impl defines a Publisher
+type pubMetrics struct {
+	submissions      *prometheus.CounterVec
+	submissionTime   *prometheus.HistogramVec
+	submissionErrors *prometheus.CounterVec
+	retries          *prometheus.CounterVec
+	retryCounts      *prometheus.HistogramVec
+	successAttempts  *prometheus.HistogramVec
+	finalLatency     *prometheus.HistogramVec
+	queueDepth       prometheus.Gauge
+	breakerState     *prometheus.GaugeVec
+	throttleWait     *prometheus.HistogramVec
+	clockSkew        *prometheus.CounterVec
+	storageOps       *prometheus.CounterVec
+	storageLatency   *prometheus.HistogramVec
+}
+
+func initMetrics(stats metrics.Scope) *pubMetrics {
+	submissions := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ct_submissions",
+			Help: "Number of certificate submissions to each CT log, by whether they were attempted, succeeded, failed, skipped because the log is disabled or short_circuited by the log's circuit breaker",
+		},
+		[]string{"log", "result"})
+	stats.MustRegister(submissions)
+	submissionTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ct_submission_time",
+			Help: "Time taken to submit a certificate to each CT log, including retries",
+		},
+		[]string{"log"})
+	stats.MustRegister(submissionTime)
+	submissionErrors := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ct_submission_errors",
+			Help: "Number of failed requests to each CT log, by HTTP status code or transport_error if there was no response",
+		},
+		[]string{"log", "status"})
+	stats.MustRegister(submissionErrors)
+	retries := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ct_submission_retries",
+			Help: "Number of requests to each CT log beyond the first made while submitting a certificate",
+		},
+		[]string{"log"})
+	stats.MustRegister(retries)
+	retryCounts := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ct_submission_retry_count",
+			Help:    "Number of retries made by each submission of a certificate to each CT log",
+			Buckets: []float64{0, 1, 2, 3, 5, 10, 20},
+		},
+		[]string{"log"})
+	stats.MustRegister(retryCounts)
+	successAttempts := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ct_successful_submission_attempts",
+			Help:    "Number of requests made by each successful submission of a certificate to each CT log, including the one that succeeded",
+			Buckets: []float64{1, 2, 3, 4, 6, 11, 21},
+		},
+		[]string{"log"})
+	stats.MustRegister(successAttempts)
+	finalLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ct_successful_attempt_latency",
+			Help: "Time taken by the request that succeeded in each successful submission of a certificate to each CT log",
+		},
+		[]string{"log"})
+	stats.MustRegister(finalLatency)
+	queueDepth := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ct_submission_queue_depth",
+			Help: "Number of submissions to CT logs waiting for one of the limited submission slots",
+		})
+	stats.MustRegister(queueDepth)
+	breakerState := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ct_log_circuit_breaker_state",
+			Help: "State of the circuit breaker for each CT log: 0 closed, 1 open or 2 half-open while testing the log",
+		},
+		[]string{"log"})
+	stats.MustRegister(breakerState)
+	throttleWait := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ct_throttle_wait",
+			Help: "Time requests to each CT log with a rate limit waited for the limit to allow them",
+		},
+		[]string{"log"})
+	stats.MustRegister(throttleWait)
+	clockSkew := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ct_log_clock_skew",
+			Help: "Number of STHs from each CT log timestamped further ahead of the local clock than the clock skew threshold",
+		},
+		[]string{"log"})
+	stats.MustRegister(clockSkew)
+	storageOps := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ct_sct_storage_operations",
+			Help: "Number of operations on the SCT storage, by operation and whether they succeeded or failed",
+		},
+		[]string{"operation", "result"})
+	stats.MustRegister(storageOps)
+	storageLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ct_sct_storage_latency",
+			Help: "Time taken by each operation on the SCT storage",
+		},
+		[]string{"operation"})
+	stats.MustRegister(storageLatency)
+
+	return &pubMetrics{
+		submissions:      submissions,
+		submissionTime:   submissionTime,
+		submissionErrors: submissionErrors,
+		retries:          retries,
+		retryCounts:      retryCounts,
+		successAttempts:  successAttempts,
+		finalLatency:     finalLatency,
+		queueDepth:       queueDepth,
+		breakerState:     breakerState,
+		throttleWait:     throttleWait,
+		clockSkew:        clockSkew,
+		storageOps:       storageOps,
+		storageLatency:   storageLatency,
+	}
+}
+
+// observe records the outcome of a single submission to a CT log, along with
+// every request that was made to the log during it. For a successful
+// submission the number of requests and the latency of the final one are
+// observed too, so that how reliably each log succeeds first time can be
+// tracked.
+func (m *pubMetrics) observe(result SubmissionResult, attempts []attempt, latency time.Duration) {
+	outcome := "succeeded"
+	if result.Err != nil {
+		outcome = "failed"
+	}
+	m.submissions.With(prometheus.Labels{"log": result.LogURI, "result": outcome}).Inc()
+	m.submissionTime.With(prometheus.Labels{"log": result.LogURI}).Observe(latency.Seconds())
+	for _, a := range attempts {
+		switch {
+		case a.StatusCode == 0:
+			m.submissionErrors.With(prometheus.Labels{"log": result.LogURI, "status": "transport_error"}).Inc()
+		case a.StatusCode != http.StatusOK:
+			m.submissionErrors.With(prometheus.Labels{"log": result.LogURI, "status": strconv.Itoa(a.StatusCode)}).Inc()
+		}
+	}
+	if len(attempts) > 1 {
+		m.retries.With(prometheus.Labels{"log": result.LogURI}).Add(float64(len(attempts) - 1))
+	}
+	if len(attempts) > 0 {
+		m.retryCounts.With(prometheus.Labels{"log": result.LogURI}).Observe(float64(len(attempts) - 1))
+	}
+	if result.Err == nil && result.Attempts > 0 {
+		m.successAttempts.With(prometheus.Labels{"log": result.LogURI}).Observe(float64(result.Attempts))
+		m.finalLatency.With(prometheus.Labels{"log": result.LogURI}).Observe(result.FinalLatency.Seconds())
+	}
+}
+
+// Publisher is the interface of the publisher. Beyond the core.Publisher
+// methods used over gRPC it has the variants that return the SCTs obtained
+// and the result from each log, so that callers in the same process can be
+// tested against a mock rather than real logs.
+type Publisher interface {
+	core.Publisher
+	SubmitToCTDetailed(ctx context.Context, der []byte) (*SubmissionReport, error)
+	CollectSCTs(ctx context.Context, der []byte) ([]LogSCT, error)
+	SubmitPrecertToCT(ctx context.Context, precertDER []byte) ([]LogSCT, error)
+}
+
+var _ Publisher = &Impl{}
+
+// Impl defines a Publisher. Its methods are safe to call concurrently, e.g.
+// SubmitToCT from many goroutines at once alongside ReloadLogs and Close. The
+// state shared between submissions, i.e. the configured logs, the per-log
+// chain, roots and rate limits, the circuit breakers, the submission cache
+// and the storage, is guarded by its own lock, and the rest is only set by
+// New. The exported callbacks, OnSCT and ChainFor, must be set before the
+// first submission and not changed after, and must themselves be safe to call
+// concurrently.
 type Impl struct {
 	log          blog.Logger
 	stats        metrics.Scope
+	metrics      *pubMetrics
 	client       *http.Client
 	issuerBundle []ct.ASN1Cert
-	ctLogsCache  logCache
+	// additionalBundles are used instead of the issuerBundle for
+	// certificates from other issuers, see bundleFor
+	additionalBundles []issuerBundle
+	// crossSigns is a pool of cross-signed versions of the issuing
+	// intermediate, used to build chains to roots accepted by each log
+	crossSigns  []*x509.Certificate
+	ctLogsCache logCache
 	// ctLogs is slightly redundant with the logCache, and should be removed. See
-	// issue https://github.com/letsencrypt/boulder/issues/2357
+	// issue https://github.com/letsencrypt/boulder/issues/2357. It is guarded
+	// by logsMu so that it can be replaced by ReloadLogs, read it with logs.
+	logsMu            sync.RWMutex
 	ctLogs            []*Log
 	submissionTimeout time.Duration
+	// healthcheckTimeout bounds the probes of the logs made by Healthcheck
+	healthcheckTimeout time.Duration
+	// healthcheckConcurrency is the number of logs Healthcheck probes at once
+	healthcheckConcurrency int
+	// minimumSCTCount is the number of SCTs that must be collected for a
+	// submission to succeed. Zero requires an SCT from every configured log.
+	minimumSCTCount int
+	backoff         backoff
+	// retryLimit and timeBudget bound the retries of each certificate's
+	// submissions, see newRetryBudget
+	retryLimit int
+	timeBudget time.Duration
+	// rootsCacheTTL is how long the roots each log accepts are cached, see
+	// acceptedRoots
+	rootsCacheTTL time.Duration
+	// inclusion is whether and how long to wait for logs to include the
+	// submitted certificates
+	inclusion inclusionWait
+	// pool bounds the concurrent submissions to logs across all certificates
+	pool *submissionPool
+	// breakers stop submissions to logs that keep failing
+	breakers *circuitBreakers
+	// operatorPolicy must be met by the SCTs collected for a submission to
+	// succeed
+	operatorPolicy OperatorPolicy
+	// maxSCTClockSkew bounds how far SCT timestamps may be out of range, see
+	// checkSCTTimestamp
+	maxSCTClockSkew time.Duration
+	// clockSkewThreshold is how far an STH timestamp may be ahead of the
+	// local clock, see checkClockSkew
+	clockSkewThreshold time.Duration
+	// cache holds the SCTs of recently submitted certificates, it is nil if
+	// caching is disabled
+	cache *submissionCache
+	// dryRun logs submissions rather than sending them
+	dryRun bool
+	// submitExpired allows submitting certificates that have expired, see
+	// checkSubmittable
+	submitExpired bool
+	// clk is used for SCT timestamp checks, the backoff between retries and
+	// the circuit breakers, so that tests can control time
+	clk clock.Clock
 
-	sa core.StorageAuthority
+	storage SCTStorage
+
+	// OnSCT, if set, is called with each SCT obtained from a log, after it has
+	// been stored. It is called synchronously from the submission to the log,
+	// unless asyncCallbacks is set, so must be safe to call concurrently for
+	// different logs. An error from it is logged but doesn't fail the
+	// submission.
+	OnSCT func(serial, logURI string, sct core.SignedCertificateTimestamp) error
+	// asyncCallbacks runs OnSCT in the background, tracked by callbacks so
+	// that Close can wait for it for up to closeTimeout
+	asyncCallbacks bool
+	callbacks      callbackTracker
+	closeTimeout   time.Duration
+
+	// ChainFor, if set, returns the DER of the issuer chain to submit cert
+	// with, starting with its issuer, in place of the configured issuer
+	// bundles and cross-signs, e.g. for deployments issuing from rotating
+	// intermediates that look the chain up by the certificate's authority key
+	// ID. It is called each time the chain is needed, which is more than once
+	// per submission, so should be cheap. An error fails the submission of the
+	// certificate.
+	ChainFor func(cert *x509.Certificate) ([][]byte, error)
+
+	// closeMu guards closed, which is set by Close. closing is closed at the
+	// same time to cancel the submissions tracked by inFlight.
+	closeMu  sync.Mutex
+	closed   bool
+	closing  chan struct{}
+	inFlight sync.WaitGroup
 }
 
-// New creates a Publisher that will submit certificates
-// to any CT logs configured in CTConfig
+// New creates a Publisher that will submit certificates to logs. bundle is
+// the chain for certificates from the default issuer, loaded from
+// config.IssuerPath if nil, and additionalBundles, each beginning with its
+// issuer, are the chains for any others. Logs that aren't configured, but are
+// submitted to with SubmitToSingleCT, use client, or a default one if nil.
 func New(
+	config cmd.CTConfig,
 	bundle []ct.ASN1Cert,
+	additionalBundles [][]ct.ASN1Cert,
+	crossSigns []*x509.Certificate,
 	logs []*Log,
+	client *http.Client,
 	submissionTimeout time.Duration,
 	logger blog.Logger,
 	stats metrics.Scope,
@@ -158,100 +801,717 @@ func New(
 	if submissionTimeout == 0 {
 		submissionTimeout = time.Hour * 12
 	}
-	return &Impl{
-		submissionTimeout: submissionTimeout,
-		issuerBundle:      bundle,
+	if client == nil {
+		// The default config has no TLS settings, so can't fail
+		client, _ = NewHTTPClient(cmd.CTConfig{})
+	}
+	if config.MaxSCTClockSkew.Duration == 0 {
+		config.MaxSCTClockSkew.Duration = defaultMaxSCTClockSkew
+	}
+	if config.ClockSkewThreshold.Duration == 0 {
+		config.ClockSkewThreshold.Duration = defaultClockSkewThreshold
+	}
+	if config.RootsCacheTTL.Duration == 0 {
+		config.RootsCacheTTL.Duration = defaultRootsCacheTTL
+	}
+	if config.CloseTimeout.Duration == 0 {
+		config.CloseTimeout.Duration = defaultCloseTimeout
+	}
+	if config.HealthcheckTimeout.Duration == 0 {
+		config.HealthcheckTimeout.Duration = defaultHealthcheckTimeout
+	}
+	if config.HealthcheckConcurrency <= 0 {
+		config.HealthcheckConcurrency = defaultHealthcheckConcurrency
+	}
+	if len(bundle) == 0 && config.IssuerPath != "" {
+		// ValidateConfig has already reported an unusable issuer
+		var err error
+		bundle, err = LoadIssuer(config.IssuerPath)
+		if err != nil {
+			logger.AuditErr(fmt.Sprintf("Ignoring issuer: %s", err))
+		}
+	}
+	pub := &Impl{
+		client:                 client,
+		submissionTimeout:      submissionTimeout,
+		minimumSCTCount:        config.MinimumSCTCount,
+		backoff:                newBackoff(config),
+		retryLimit:             config.SubmissionRetryBudget,
+		timeBudget:             config.SubmissionTimeBudget.Duration,
+		rootsCacheTTL:          config.RootsCacheTTL.Duration,
+		inclusion:              newInclusionWait(config),
+		cache:                  newSubmissionCache(config.SubmissionCacheSize, config.SubmissionCacheTTL.Duration),
+		dryRun:                 config.DryRun,
+		asyncCallbacks:         config.AsyncSCTCallbacks,
+		closeTimeout:           config.CloseTimeout.Duration,
+		submitExpired:          config.SubmitExpired,
+		maxSCTClockSkew:        config.MaxSCTClockSkew.Duration,
+		clockSkewThreshold:     config.ClockSkewThreshold.Duration,
+		healthcheckTimeout:     config.HealthcheckTimeout.Duration,
+		healthcheckConcurrency: config.HealthcheckConcurrency,
+		issuerBundle:           bundle,
+		crossSigns:             crossSigns,
 		ctLogsCache: logCache{
 			logs: make(map[string]*Log),
 		},
-		ctLogs: logs,
-		log:    logger,
-		stats:  stats,
-		sa:     sa,
+		ctLogs:  logs,
+		log:     logger,
+		stats:   stats,
+		metrics: initMetrics(stats),
+		closing: make(chan struct{}),
+		clk:     clock.New(),
+	}
+	pub.pool = newSubmissionPool(config.SubmissionConcurrency, config.SubmissionQueueDepth, pub.metrics.queueDepth)
+	pub.breakers = newCircuitBreakers(config.CircuitBreakerThreshold, config.CircuitBreakerCooldown.Duration, pub.metrics.breakerState)
+	pub.operatorPolicy = OperatorPolicy{
+		Required:        config.RequiredOperators,
+		MinimumDistinct: config.MinimumOperators,
 	}
+	for _, additional := range additionalBundles {
+		if len(additional) == 0 {
+			continue
+		}
+		// The bundles are loaded from PEM by the caller so should always parse
+		issuer, err := x509.ParseCertificate(additional[0].Data)
+		if err != nil {
+			logger.AuditErr(fmt.Sprintf("Ignoring issuer bundle with an unparseable first certificate: %s", err))
+			continue
+		}
+		pub.additionalBundles = append(pub.additionalBundles, issuerBundle{keyID: issuer.SubjectKeyId, bundle: additional})
+	}
+	// Without an SA the SCTs are only kept in memory
+	var storage SCTStorage = newMemorySCTStorage(pub.clk)
+	if sa != nil {
+		storage = saSCTStorage{
+			sa:   sa,
+			log:  logger,
+			logs: pub.logs,
+		}
+	}
+	pub.storage = newMeteredSCTStorage(storage, pub.clk, pub.metrics)
+	return pub
 }
 
-// SubmitToSingleCT will submit the certificate represented by certDER to the CT
+// SubmitToSingleCT will submit the certificate represented by der to the CT
 // log specified by log URL and public key (base64)
 func (pub *Impl) SubmitToSingleCT(
 	ctx context.Context,
 	logURL, logPublicKey string,
 	der []byte) error {
+	ctx, done, err := pub.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
 	cert, err := x509.ParseCertificate(der)
 	if err != nil {
-		pub.log.AuditErr(fmt.Sprintf("Failed to parse certificate: %s", err))
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Failed to parse certificate: %s", err)))
 		return err
 	}
 
-	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
-	defer cancel()
-	chain := append([]ct.ASN1Cert{ct.ASN1Cert{der}}, pub.issuerBundle...)
-
 	// Add a log URL/pubkey to the cache, if already present the
 	// existing *Log will be returned, otherwise one will be constructed, added
 	// and returned.
-	ctLog, err := pub.ctLogsCache.AddLog(logURL, logPublicKey, pub.log)
+	ctLog, err := pub.ctLogsCache.AddLog(logURL, logPublicKey, pub.client, pub.log)
 	if err != nil {
-		pub.log.AuditErr(fmt.Sprintf("Making Log: %s", err))
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Making Log: %s", err)))
 		return err
 	}
+	if err := pub.checkSubmittable(cert); err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not submitting certificate to CT: %s", err)))
+		return err
+	}
+	if pub.dryRun {
+		pub.logDryRun(ctx, []*Log{ctLog}, ct.X509LogEntryType, cert)
+		return nil
+	}
+
+	pub.storeSCTs(ctx, []*Log{ctLog}, cert, []SubmissionResult{pub.submitToLog(ctx, ctLog, ct.X509LogEntryType, cert)})
+	return nil
+}
+
+// SubmitToCT will submit the certificate represented by der to any CT logs
+// configured in pub.CT.Logs. See CollectSCTs for details. der is submitted to
+// the logs as is, it is parsed only once to check the issuer and the SCTs, so
+// callers holding the DER shouldn't parse it first. A certificate that has
+// already expired fails with ErrCertExpired without contacting any log,
+// unless cmd.CTConfig.SubmitExpired is set.
+// Only the error is returned, so callers that want the SCTs collected
+// when the submission fails, e.g. to store a set short of the quorum and
+// resubmit later, should use SubmitToCTDetailed or CollectSCTs instead.
+func (pub *Impl) SubmitToCT(ctx context.Context, der []byte) error {
+	_, err := pub.SubmitToCTDetailed(ctx, der)
+	return err
+}
+
+// SubmissionReport is the outcome of submitting a certificate to every enabled
+// CT log. Results holds the result from each log, in the order the logs are
+// configured, whether or not the submission as a whole succeeded, and SCTs the
+// SCTs that were obtained. Results is empty if the certificate wasn't
+// submitted, e.g. in dry run mode. Retries is the total of the results'
+// retries.
+type SubmissionReport struct {
+	Serial  string
+	Results []SubmissionResult
+	SCTs    []LogSCT
+	Retries int
+}
+
+// SubmitToCTDetailed submits the certificate represented by der as
+// CollectSCTs does, returning a report of the result from each log along with
+// the same error. The report is nil if der couldn't be parsed.
+func (pub *Impl) SubmitToCTDetailed(ctx context.Context, der []byte) (*SubmissionReport, error) {
+	return pub.submitDetailed(ctx, ct.X509LogEntryType, der)
+}
+
+// LogSCT is an SCT obtained by the publisher along with the URI of the log
+// that issued it and the expiry of the certificate it is for. Submitted is the
+// local time the SCT was obtained, as opposed to the log's timestamp in the
+// SCT, and Latency is how long the submission took, including retries.
+// MergeDeadline is the SCT's timestamp plus the log's Maximum Merge Delay,
+// the time by which the log must have incorporated the certificate into its
+// tree. Operator is the configured operator of the log, if any, and
+// BestEffort is set for SCTs from best-effort logs.
+type LogSCT struct {
+	LogURI        string
+	Operator      string
+	BestEffort    bool
+	Submitted     time.Time
+	Latency       time.Duration
+	Expires       time.Time
+	MergeDeadline time.Time
+	core.SignedCertificateTimestamp
+}
+
+// sctsByLogID sorts SCTs by their log IDs, see core.LogIDLess
+type sctsByLogID []LogSCT
+
+func (s sctsByLogID) Len() int      { return len(s) }
+func (s sctsByLogID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sctsByLogID) Less(i, j int) bool {
+	return core.LogIDLess(s[i].LogID, s[j].LogID)
+}
+
+// CollectSCTs submits the certificate represented by der to every configured
+// CT log that is enabled and returns the SCTs that were obtained. Logs are
+// submitted to concurrently through the publisher's submission pool, see
+// submitToLogs. When a minimum SCT count is configured the submission succeeds
+// once that many logs returned SCTs, otherwise an error naming every log that
+// failed is returned if any submission wasn't successful. The SCTs must also
+// meet the configured OperatorPolicy. Logs in the best-effort tier are
+// submitted to as well, but don't count towards either and their failures are
+// ignored. Temporally sharded logs are only submitted to when their shard
+// covers the certificate's NotAfter, see logsFor. When waiting for inclusion
+// is configured, a log only succeeds once it has proven that it included the
+// certificate, see awaitInclusion. The SCTs from logs that did succeed are
+// returned even when there is an error, sorted by log ID rather than in the
+// order the logs are configured or responded in, so that the same SCTs always
+// give the same SCT list. In dry run mode the submissions are only logged, and
+// no SCTs or error are returned.
+func (pub *Impl) CollectSCTs(ctx context.Context, der []byte) ([]LogSCT, error) {
+	return pub.collectSCTs(ctx, ct.X509LogEntryType, der)
+}
+
+// SubmitPrecertToCT submits the precertificate represented by precertDER to
+// every configured CT log using the add-pre-chain endpoint, returning the SCTs
+// obtained as CollectSCTs does. The issuer bundle must begin with the
+// precertificate's issuer so that the SCT signatures can be verified.
+func (pub *Impl) SubmitPrecertToCT(ctx context.Context, precertDER []byte) ([]LogSCT, error) {
+	return pub.collectSCTs(ctx, ct.PrecertLogEntryType, precertDER)
+}
+
+func (pub *Impl) collectSCTs(ctx context.Context, entryType ct.LogEntryType, der []byte) ([]LogSCT, error) {
+	report, err := pub.submitDetailed(ctx, entryType, der)
+	if report == nil {
+		return nil, err
+	}
+	return report.SCTs, err
+}
+
+func (pub *Impl) submitDetailed(ctx context.Context, entryType ct.LogEntryType, der []byte) (*SubmissionReport, error) {
+	ctx, done, err := pub.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Failed to parse certificate: %s", err)))
+		return nil, err
+	}
+	return pub.submitCert(ctx, entryType, cert)
+}
+
+// checkSubmittable returns ErrCertExpired if cert has expired, unless
+// submitting expired certificates is allowed, or the error from checkIssuer
+func (pub *Impl) checkSubmittable(cert *x509.Certificate) error {
+	if !pub.submitExpired && !pub.clk.Now().Before(cert.NotAfter) {
+		return ErrCertExpired{Serial: core.SerialToString(cert.SerialNumber), NotAfter: cert.NotAfter}
+	}
+	return pub.checkIssuer(cert)
+}
+
+// submitCert is submitDetailed for a certificate that has already been
+// parsed, in a submission that has already begun. The report is never nil.
+func (pub *Impl) submitCert(ctx context.Context, entryType ct.LogEntryType, cert *x509.Certificate) (*SubmissionReport, error) {
+	report := &SubmissionReport{Serial: core.SerialToString(cert.SerialNumber)}
+	if err := pub.checkSubmittable(cert); err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not submitting certificate to CT: %s", err)))
+		return report, err
+	}
+
+	if scts, ok := pub.cache.get(cert.Raw, pub.clk.Now()); ok {
+		pub.log.Info(fmt.Sprintf("Returning cached SCTs for recently submitted certificate %s", report.Serial))
+		report.SCTs = scts
+		return report, nil
+	}
+
+	enabled, disabled := splitDisabled(pub.logs())
+	logs, err := pub.logsFor(cert, enabled)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not submitting certificate to CT: %s", err)))
+		return report, err
+	}
+	if pub.dryRun {
+		pub.logDryRun(ctx, logs, entryType, cert)
+		return report, nil
+	}
+
+	pub.countSkipped(disabled)
+	report.Results = pub.submitToLogs(ctx, logs, entryType, cert)
+	if pub.inclusion.enabled {
+		pub.awaitInclusion(ctx, logs, cert, report.Results)
+	}
+	pub.storeSCTs(ctx, logs, cert, report.Results)
+	for _, result := range report.Results {
+		report.Retries += result.Retries()
+	}
+	report.SCTs, err = pub.checkResults(ctx, cert, nil, report.Results)
+	if err == nil {
+		pub.cache.add(cert.Raw, report.SCTs, pub.clk.Now())
+	}
+	return report, err
+}
+
+// ResubmitMissing submits the certificate represented by der to each
+// configured CT log that no SCT for it has been stored from, and returns the
+// stored SCTs along with any that were obtained. The stored SCTs count towards
+// the minimum SCT count. Logs that already provided an SCT aren't contacted,
+// so it is safe to call repeatedly until every log has returned an SCT.
+func (pub *Impl) ResubmitMissing(ctx context.Context, der []byte) ([]LogSCT, error) {
+	ctx, done, err := pub.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Failed to parse certificate: %s", err)))
+		return nil, err
+	}
+	serial := core.SerialToString(cert.SerialNumber)
+	stored, err := pub.storage.Load(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("loading stored SCTs for %s: %s", serial, err)
+	}
+	sort.Stable(sctsByLogID(stored))
+	have := make(map[string]bool, len(stored))
+	for _, sct := range stored {
+		have[sct.LogURI] = true
+	}
+	enabled, disabled := splitDisabled(pub.logs())
+	logs, err := pub.logsFor(cert, enabled)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not submitting certificate to CT: %s", err)))
+		return stored, err
+	}
+	var missing []*Log
+	for _, ctLog := range logs {
+		if !have[ctLog.uri] {
+			missing = append(missing, ctLog)
+		}
+	}
+	if len(missing) == 0 {
+		return stored, nil
+	}
+	if err := pub.checkSubmittable(cert); err != nil {
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Not submitting certificate to CT: %s", err)))
+		return stored, err
+	}
+	if pub.dryRun {
+		pub.logDryRun(ctx, missing, ct.X509LogEntryType, cert)
+		return stored, nil
+	}
+	pub.log.Info(fmt.Sprintf("Resubmitting certificate %s to %d CT logs without a stored SCT", serial, len(missing)))
+
+	pub.countSkipped(disabled)
+	results := pub.submitToLogs(ctx, missing, ct.X509LogEntryType, cert)
+	if pub.inclusion.enabled {
+		pub.awaitInclusion(ctx, missing, cert, results)
+	}
+	pub.storeSCTs(ctx, missing, cert, results)
+	return pub.checkResults(ctx, cert, stored, results)
+}
+
+// checkResults returns the SCTs from previous and the successful results,
+// sorted by log ID, with an error if too few logs returned SCTs as described
+// by CollectSCTs. SCTs from best-effort logs are returned, but only those from
+// required logs count towards the minimum SCT count and operator policy, and
+// failures of best-effort logs are ignored.
+func (pub *Impl) checkResults(ctx context.Context, cert *x509.Certificate, previous []LogSCT, results []SubmissionResult) ([]LogSCT, error) {
+	scts := previous
+	var required []LogSCT
+	for _, sct := range previous {
+		if !sct.BestEffort {
+			required = append(required, sct)
+		}
+	}
+	requiredLogs := len(required)
+	var failed []SubmissionResult
+	var inFlight []string
+	rejections := make(map[string]error)
+	for _, result := range results {
+		if !result.BestEffort {
+			requiredLogs++
+		}
+		if result.Err == nil {
+			scts = append(scts, *result.SCT)
+			if !result.BestEffort {
+				required = append(required, *result.SCT)
+			}
+			continue
+		}
+		if result.BestEffort {
+			pub.log.Info(fmt.Sprintf("Ignoring failed submission to best-effort CT log at %s: %s", result.LogURI, result.Err))
+			continue
+		}
+		failed = append(failed, result)
+		if result.permanentlyRejected() {
+			rejections[result.LogURI] = result.Err
+		}
+		if ctx.Err() != nil {
+			inFlight = append(inFlight, result.LogURI)
+		}
+	}
+	sort.Stable(sctsByLogID(scts))
+	// With a quorum configured, a failure from some of the logs doesn't fail
+	// the submission as long as enough of the others returned SCTs
+	if pub.minimumSCTCount > 0 && len(required) >= pub.minimumSCTCount {
+		return scts, pub.checkOperatorPolicy(ctx, cert, required)
+	}
+	if len(inFlight) > 0 {
+		return scts, fmt.Errorf("submitting to CT logs at %s: %s", strings.Join(inFlight, ", "), ctx.Err())
+	}
+	if len(previous) == 0 && requiredLogs > 0 && len(rejections) == requiredLogs {
+		err := ErrAllLogsRejected{Reasons: rejections}
+		pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Certificate %s rejected by every CT log: %s",
+			core.SerialToString(cert.SerialNumber), err)))
+		pub.stats.Inc("AllLogsRejected", 1)
+		return scts, err
+	}
+	if len(failed) > 0 || pub.minimumSCTCount > 0 {
+		return scts, ErrSubmissionFailed{
+			Failures:        failed,
+			Logs:            requiredLogs,
+			SCTs:            len(required),
+			MinimumSCTCount: pub.minimumSCTCount,
+		}
+	}
+	return scts, pub.checkOperatorPolicy(ctx, cert, required)
+}
+
+// submitToLogs submits the certificate to each of the provided logs
+// concurrently and returns their results in the same order as logs. The
+// submissions share the publisher's pool with those of every other
+// certificate, so a log is failed with ErrQueueFull if too many submissions
+// are already waiting. Logs that haven't been started by the time ctx is
+// finished are not submitted to. The submissions to the logs share a retry
+// budget, if one is configured.
+func (pub *Impl) submitToLogs(ctx context.Context, logs []*Log, entryType ct.LogEntryType, cert *x509.Certificate) []SubmissionResult {
+	ctx = withRetryBudget(ctx, pub.newRetryBudget(pub.clk.Now()))
+	results := make([]SubmissionResult, len(logs))
+	var wg sync.WaitGroup
+	for i, ctLog := range logs {
+		ticket, err := pub.pool.enqueue()
+		if err != nil {
+			pub.log.Warning(fmt.Sprintf("Not submitting certificate to CT log at %s: %s", ctLog.uri, err))
+			results[i] = SubmissionResult{LogURI: ctLog.uri, BestEffort: ctLog.bestEffort(), Err: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ctLog *Log, ticket *poolTicket) {
+			defer wg.Done()
+			release, err := ticket.wait(ctx)
+			if err != nil {
+				results[i] = SubmissionResult{LogURI: ctLog.uri, BestEffort: ctLog.bestEffort(), Err: err}
+				return
+			}
+			defer release()
+			results[i] = pub.submitToLog(ctx, ctLog, entryType, cert)
+		}(i, ctLog, ticket)
+	}
+	wg.Wait()
+	return results
+}
+
+// submitToLog submits the certificate to the provided log, recording stats and
+// audit logging any failure. The outcome is recorded in the storage, see
+// SubmissionFailures. Logs configured at startup are submitted to
+// directly rather than through the logCache so that their per-log settings are
+// preserved. While the log's circuit breaker is open the submission fails
+// immediately with ErrLogUnavailable.
+func (pub *Impl) submitToLog(ctx context.Context, ctLog *Log, entryType ct.LogEntryType, cert *x509.Certificate) SubmissionResult {
+	if !pub.breakers.allow(ctLog.uri, pub.clk.Now()) {
+		pub.metrics.submissions.With(prometheus.Labels{"log": ctLog.uri, "result": "short_circuited"}).Inc()
+		return SubmissionResult{
+			LogURI:     ctLog.uri,
+			BestEffort: ctLog.bestEffort(),
+			Err:        ErrLogUnavailable{LogURI: ctLog.uri, Err: errCircuitOpen},
+		}
+	}
+	recorder := &attemptRecorder{}
+	localCtx, cancel := context.WithTimeout(withAttemptRecorder(ctx, recorder), pub.submissionTimeout)
+	defer cancel()
+	issuerChain, err := pub.chainFor(localCtx, ctLog, cert)
+	if err != nil {
+		pub.log.AuditErr(withRequestID(ctx,
+			fmt.Sprintf("Not submitting certificate to CT log at %s: %s", ctLog.uri, err)))
+		// Nothing was sent to the log, so a test of the log by a half-open
+		// breaker is left to the next submission
+		pub.breakers.record(ctLog.uri, breakerAbandoned, pub.clk.Now())
+		return SubmissionResult{LogURI: ctLog.uri, BestEffort: ctLog.bestEffort(), Err: err}
+	}
+	chain := append([]ct.ASN1Cert{ct.ASN1Cert{cert.Raw}}, issuerChain...)
 
 	stats := pub.stats.NewScope(ctLog.statName)
 	stats.Inc("Submits", 1)
-	start := time.Now()
-	err = pub.singleLogSubmit(
+	pub.metrics.submissions.With(prometheus.Labels{"log": ctLog.uri, "result": "attempted"}).Inc()
+	start := pub.clk.Now()
+	sct, err := pub.singleLogSubmit(
 		localCtx,
+		entryType,
 		chain,
-		core.SerialToString(cert.SerialNumber),
+		cert,
 		ctLog)
-	stats.TimingDuration("SubmitLatency", time.Now().Sub(start))
+	latency := pub.clk.Since(start)
+	stats.TimingDuration("SubmitLatency", latency)
+
+	result := SubmissionResult{
+		LogURI:     ctLog.uri,
+		BestEffort: ctLog.bestEffort(),
+		SCT:        sct,
+		Attempts:   recorder.count(),
+		Err:        err,
+	}
+	if final, ok := recorder.last(); ok {
+		result.FinalStatus = final.StatusCode
+		result.FinalLatency = final.Latency
+	}
+	pub.metrics.observe(result, recorder.all(), latency)
 	if err != nil {
-		pub.log.AuditErr(
-			fmt.Sprintf("Failed to submit certificate to CT log at %s: %s", ctLog.uri, err))
+		cause := err
+		if badSig, ok := err.(ErrBadSCTSignature); ok {
+			cause = badSig.Err
+		}
+		pub.log.AuditErr(withRequestID(ctx,
+			fmt.Sprintf("Failed to submit certificate to CT log at %s: %s", ctLog.uri, cause)))
 		stats.Inc("Errors", 1)
+		result.Err = classifySubmissionError(result, err)
+		pub.recordAttempt(ctx, cert, ctLog, result.Err)
+		if pub.breakers.record(ctLog.uri, breakerOutcome(result.Err, ctx.Err() != nil), pub.clk.Now()) {
+			pub.log.Warning(fmt.Sprintf("Circuit breaker for CT log at %s opened, submissions to it will fail for %s",
+				ctLog.uri, pub.breakers.cooldown))
+		}
+		return result
 	}
+	pub.breakers.record(ctLog.uri, breakerSuccess, pub.clk.Now())
+	pub.recordAttempt(ctx, cert, ctLog, nil)
+	pub.log.Info(fmt.Sprintf(
+		"Submitted certificate to CT log at %s after %d attempt(s), final attempt returned status %d in %s",
+		ctLog.uri, result.Attempts, result.FinalStatus, result.FinalLatency))
+	event := newSCTEvent(sct, result.Attempts)
+	event.RequestID = RequestID(ctx)
+	pub.log.AuditObject("SCT obtained", event)
+	if pub.OnSCT != nil {
+		if pub.asyncCallbacks {
+			pub.callbacks.run(func() { pub.callOnSCT(ctLog, sct) })
+		} else {
+			pub.callOnSCT(ctLog, sct)
+		}
+	}
+	return result
+}
 
-	return nil
+// callOnSCT calls OnSCT with sct from ctLog, logging any error
+func (pub *Impl) callOnSCT(ctLog *Log, sct *LogSCT) {
+	if err := pub.OnSCT(sct.CertificateSerial, ctLog.uri, sct.SignedCertificateTimestamp); err != nil {
+		pub.log.Warning(fmt.Sprintf("SCT callback failed for SCT from CT log at %s: %s", ctLog.uri, err))
+	}
 }
 
-// SubmitToCT will submit the certificate represented by certDER to any CT
-// logs configured in pub.CT.Logs.
-func (pub *Impl) SubmitToCT(ctx context.Context, der []byte) error {
-	for _, ctLog := range pub.ctLogs {
-		err := pub.SubmitToSingleCT(ctx, ctLog.uri, ctLog.logID, der)
-		if err != nil {
-			return err
-		}
+// sctEvent is the audit event logged for each SCT obtained. Log pipelines
+// index its fields, so they should be added to rather than changed. LogID is
+// hex encoded and Timestamp is the SCT's, in milliseconds since the epoch.
+// RequestID is the submission's request ID, if it has one.
+type sctEvent struct {
+	Serial    string
+	LogURI    string
+	LogID     string
+	Timestamp uint64
+	Retries   int
+	RequestID string `json:",omitempty"`
+}
+
+func newSCTEvent(sct *LogSCT, attempts int) sctEvent {
+	// The LogID is always encoded by sctToInternal, so decodes
+	logID, _ := base64.StdEncoding.DecodeString(sct.LogID)
+	retries := attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+	return sctEvent{
+		Serial:    sct.CertificateSerial,
+		LogURI:    sct.LogURI,
+		LogID:     hex.EncodeToString(logID),
+		Timestamp: sct.Timestamp,
+		Retries:   retries,
 	}
-	return nil
 }
 
 func (pub *Impl) singleLogSubmit(
 	ctx context.Context,
+	entryType ct.LogEntryType,
 	chain []ct.ASN1Cert,
-	serial string,
-	ctLog *Log) error {
+	cert *x509.Certificate,
+	ctLog *Log) (*LogSCT, error) {
 
-	sct, err := ctLog.client.AddChain(ctx, chain)
+	start := pub.clk.Now()
+	sct, err := pub.addChain(ctx, ctLog, entryType, chain)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = ctLog.verifier.VerifySCTSignature(*sct, ct.LogEntry{
-		Leaf: ct.MerkleTreeLeaf{
-			LeafType: ct.TimestampedEntryLeafType,
-			TimestampedEntry: &ct.TimestampedEntry{
-				X509Entry: &chain[0],
-				EntryType: ct.X509LogEntryType,
-			},
-		},
-	})
-	if err != nil {
-		return err
+	if err := checkSignatureAlgorithm(sct.Signature.Algorithm); err != nil {
+		return nil, ErrBadSCTSignature{LogURI: ctLog.uri, Err: fmt.Errorf("SCT signature %s", err)}
+	}
+	if ctLog.verifier == nil {
+		// Without a key the log ID can't be checked, but logging it lets
+		// operators spot SCTs from a log other than the one configured
+		pub.log.Warning(fmt.Sprintf(
+			"Accepting unverified SCT with log ID %s from CT log at %s, signature verification is disabled for this log",
+			hex.EncodeToString(sct.LogID.KeyID[:]), ctLog.uri))
+	} else {
+		// The CT client reconstructs the signed x509_entry or precert_entry
+		// data for the entry type using the submitted chain
+		if err := ctLog.checkECDSAValues(sct.Signature); err != nil {
+			return nil, ErrBadSCTSignature{LogURI: ctLog.uri, Err: err}
+		}
+		verifyingClient := ctClient.LogClient{JSONClient: jsonclient.JSONClient{Verifier: ctLog.verifier}}
+		err = verifyingClient.VerifySCTSignature(*sct, entryType, chain)
+		if err != nil {
+			return nil, ErrBadSCTSignature{LogURI: ctLog.uri, Err: err}
+		}
+		// The log ID isn't covered by the SCT signature, so it's checked
+		// separately to catch responses that were routed to the wrong log
+		if sct.LogID.KeyID != ctLog.keyID {
+			return nil, ErrBadSCTSignature{LogURI: ctLog.uri, Err: fmt.Errorf(
+				"SCT log ID %s doesn't match the ID of the configured log key %s",
+				hex.EncodeToString(sct.LogID.KeyID[:]), hex.EncodeToString(ctLog.keyID[:]))}
+		}
+	}
+
+	if err := pub.checkSCTTimestamp(ctLog, sct.Timestamp, cert, pub.clk.Now()); err != nil {
+		return nil, err
+	}
+	timestamp := time.Unix(0, int64(sct.Timestamp)*int64(time.Millisecond))
+
+	internalSCT := sctToInternal(sct, core.SerialToString(cert.SerialNumber))
+	submitted := pub.clk.Now()
+	logSCT := &LogSCT{
+		LogURI:                     ctLog.uri,
+		Operator:                   ctLog.operator,
+		BestEffort:                 ctLog.bestEffort(),
+		Submitted:                  submitted,
+		Latency:                    submitted.Sub(start),
+		Expires:                    cert.NotAfter,
+		MergeDeadline:              timestamp.Add(ctLog.mmd),
+		SignedCertificateTimestamp: internalSCT,
+	}
+	return logSCT, nil
+}
+
+// storeSCTs stores the SCTs of the successful results, which are in the same
+// order as logs, and fails the results whose SCT couldn't be stored. It is
+// called once the results are final, after awaitInclusion when waiting for
+// inclusion, so that an SCT whose inclusion wasn't confirmed isn't stored and
+// then counted by ResubmitMissing or SCTListForSerial.
+func (pub *Impl) storeSCTs(ctx context.Context, logs []*Log, cert *x509.Certificate, results []SubmissionResult) {
+	for i, result := range results {
+		if result.Err != nil || result.SCT == nil {
+			continue
+		}
+		if err := pub.storage.Store(ctx, *result.SCT); err != nil {
+			pub.log.AuditErr(withRequestID(ctx, fmt.Sprintf("Failed to store SCT for certificate %s from CT log at %s: %s",
+				result.SCT.CertificateSerial, result.LogURI, err)))
+			results[i].SCT = nil
+			results[i].Err = err
+			pub.recordAttempt(ctx, cert, logs[i], err)
+		}
 	}
+}
 
-	err = pub.sa.AddSCTReceipt(ctx, sctToInternal(sct, serial))
+// defaultMaxSCTClockSkew is the default tolerance for SCT timestamps. Logs
+// are expected to keep accurate time, so this only needs to cover the
+// difference between their clocks and ours.
+const defaultMaxSCTClockSkew = 10 * time.Minute
+
+// checkSCTTimestamp checks that an SCT's timestamp, in milliseconds since
+// the epoch, isn't more than the allowed clock skew ahead of now or before
+// the certificate's validity period began. The certificate can't have been
+// logged before it was valid since NotBefore is never after issuance.
+func (pub *Impl) checkSCTTimestamp(ctLog *Log, timestamp uint64, cert *x509.Certificate, now time.Time) error {
+	t := time.Unix(0, int64(timestamp)*int64(time.Millisecond))
+	if t.After(now.Add(pub.maxSCTClockSkew)) {
+		return ErrBadSCTTimestamp{LogURI: ctLog.uri, Timestamp: t, Reason: fmt.Sprintf(
+			"more than %s in the future", pub.maxSCTClockSkew)}
+	}
+	if t.Before(cert.NotBefore.Add(-pub.maxSCTClockSkew)) {
+		return ErrBadSCTTimestamp{LogURI: ctLog.uri, Timestamp: t, Reason: fmt.Sprintf(
+			"more than %s before the certificate's NotBefore of %s", pub.maxSCTClockSkew, cert.NotBefore.UTC())}
+	}
+	return nil
+}
+
+// checkSignatureAlgorithm checks that a signature from a CT log is one that
+// RFC 6962 allows, i.e. ECDSA or RSA (PKCS#1 v1.5) over a SHA-256 hash
+func checkSignatureAlgorithm(alg ctTLS.SignatureAndHashAlgorithm) error {
+	if alg.Hash != ctTLS.SHA256 {
+		return fmt.Errorf("uses unsupported hash algorithm %s", alg.Hash)
+	}
+	if alg.Signature != ctTLS.ECDSA && alg.Signature != ctTLS.RSA {
+		return fmt.Errorf("uses unsupported signature algorithm %s", alg.Signature)
+	}
+	return nil
+}
+
+// verifySTH checks that the tree head signature of the provided STH was made
+// by the public key configured for the given log. An STH that fails
+// verification is as serious as a badly signed SCT and is alarmed on.
+func (pub *Impl) verifySTH(ctLog *Log, sth ct.SignedTreeHead) error {
+	if ctLog.verifier == nil {
+		pub.log.Warning(fmt.Sprintf(
+			"Accepting unverified STH from CT log at %s, signature verification is disabled for this log",
+			ctLog.uri))
+		return nil
+	}
+	err := ctLog.checkECDSAValues(sth.TreeHeadSignature)
+	if err == nil {
+		err = ctLog.verifier.VerifySTHSignature(sth)
+	}
 	if err != nil {
+		pub.log.AuditErr(
+			fmt.Sprintf("Failed to verify STH signature from CT log at %s: %s", ctLog.uri, err))
+		pub.stats.NewScope(ctLog.statName).Inc("BadSTHSignatures", 1)
 		return err
 	}
 	return nil
@@ -259,11 +1519,13 @@ func (pub *Impl) singleLogSubmit(
 
 func sctToInternal(sct *ct.SignedCertificateTimestamp, serial string) core.SignedCertificateTimestamp {
 	return core.SignedCertificateTimestamp{
-		CertificateSerial: serial,
-		SCTVersion:        uint8(sct.SCTVersion),
-		LogID:             base64.StdEncoding.EncodeToString(sct.LogID.KeyID[:]),
-		Timestamp:         sct.Timestamp,
-		Extensions:        sct.Extensions,
-		Signature:         sct.Signature.Signature,
+		CertificateSerial:  serial,
+		SCTVersion:         uint8(sct.SCTVersion),
+		LogID:              base64.StdEncoding.EncodeToString(sct.LogID.KeyID[:]),
+		Timestamp:          sct.Timestamp,
+		Extensions:         sct.Extensions,
+		Signature:          sct.Signature.Signature,
+		HashAlgorithm:      uint8(sct.Signature.Algorithm.Hash),
+		SignatureAlgorithm: uint8(sct.Signature.Algorithm.Signature),
 	}
 }
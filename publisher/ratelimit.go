@@ -0,0 +1,99 @@
+package publisher
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+)
+
+// rateLimiter is a token bucket limiting the rate of requests to a CT log,
+// so that the publisher stays within the limits logs publish. The bucket
+// holds up to burst tokens and refills at rate tokens per second. Each request
+// takes a token, waiting for one to be refilled if the bucket is empty.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	// last is when tokens was last refilled, the bucket starts full when it
+	// is zero
+	last time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing rate requests per second with
+// the given burst, which defaults to the rate rounded up. It returns nil, for
+// no limit, if rate is zero.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate == 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst)}
+}
+
+// reserve takes a token at now and returns how long the caller has to wait
+// before it can use it
+func (rl *rateLimiter) reserve(now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.last.IsZero() {
+		rl.tokens = rl.burst
+	} else if elapsed := now.Sub(rl.last); elapsed > 0 {
+		rl.tokens = math.Min(rl.burst, rl.tokens+elapsed.Seconds()*rl.rate)
+	}
+	if now.After(rl.last) {
+		rl.last = now
+	}
+	rl.tokens--
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that won't be used
+func (rl *rateLimiter) cancel() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens = math.Min(rl.burst, rl.tokens+1)
+}
+
+// ErrThrottled is returned for a request to a log that would have had to wait
+// for the log's configured rate limit beyond the deadline of its context
+type ErrThrottled struct {
+	LogURI string
+	Wait   time.Duration
+}
+
+func (e ErrThrottled) Error() string {
+	return fmt.Sprintf("request to CT log at %s would wait %s for its rate limit, past the deadline", e.LogURI, e.Wait)
+}
+
+// throttle waits until ctLog's rate limit, if it has one, allows a request.
+// Rather than waiting past the deadline of ctx, ErrThrottled is returned
+// immediately. The time waited is observed in the ct_throttle_wait histogram.
+func (pub *Impl) throttle(ctx context.Context, ctLog *Log) error {
+	if ctLog.limiter == nil {
+		return nil
+	}
+	wait := ctLog.limiter.reserve(pub.clk.Now())
+	if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+		ctLog.limiter.cancel()
+		return ErrThrottled{LogURI: ctLog.uri, Wait: wait}
+	}
+	if wait > 0 {
+		if err := sleep(ctx, pub.clk, wait); err != nil {
+			ctLog.limiter.cancel()
+			return err
+		}
+	}
+	pub.metrics.throttleWait.With(prometheus.Labels{"log": ctLog.uri}).Observe(wait.Seconds())
+	return nil
+}
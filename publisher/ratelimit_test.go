@@ -0,0 +1,86 @@
+package publisher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestRateLimiter(t *testing.T) {
+	test.Assert(t, newRateLimiter(0, 5) == nil, "Rate limiter created without a rate")
+
+	// The burst defaults to the rate rounded up
+	rl := newRateLimiter(1.5, 0)
+	test.AssertEquals(t, rl.burst, float64(2))
+
+	now := time.Now()
+	test.AssertEquals(t, rl.reserve(now), time.Duration(0))
+	test.AssertEquals(t, rl.reserve(now), time.Duration(0))
+	test.AssertEquals(t, rl.reserve(now), time.Second*2/3)
+	wait := rl.reserve(now)
+	// A cancelled reservation is handed to the next request
+	rl.cancel()
+	test.AssertEquals(t, rl.reserve(now), wait)
+
+	// The bucket refills, but never beyond the burst
+	now = now.Add(time.Hour)
+	test.AssertEquals(t, rl.reserve(now), time.Duration(0))
+	test.AssertEquals(t, rl.reserve(now), time.Duration(0))
+	test.Assert(t, rl.reserve(now) > 0, "Bucket refilled beyond its burst")
+}
+
+func TestThrottle(t *testing.T) {
+	pub, _, _ := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+
+	_, err := NewLog(cmd.LogDescription{URI: "http://localhost/ct", SkipSignatureVerification: true, RateLimit: -1}, nil, log)
+	test.AssertError(t, err, "Created log with a negative rate limit")
+
+	ctLog, err := NewLog(cmd.LogDescription{
+		URI:                       "http://localhost/ct",
+		SkipSignatureVerification: true,
+		RateLimit:                 1,
+		RateBurst:                 1,
+	}, nil, log)
+	test.AssertNotError(t, err, "Failed to create log")
+	err = pub.throttle(ctx, ctLog)
+	test.AssertNotError(t, err, "First request was throttled")
+
+	// Waiting for the next token would take the request past its deadline
+	shortCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err = pub.throttle(shortCtx, ctLog)
+	throttled, ok := err.(ErrThrottled)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrThrottled, got %T", err))
+	test.AssertEquals(t, throttled.Wait, time.Second)
+
+	// Without a deadline the request waits for the token on the clock
+	start := fc.Now()
+	done := make(chan error)
+	go func() {
+		done <- pub.throttle(ctx, ctLog)
+	}()
+	for finished := false; !finished; {
+		select {
+		case err = <-done:
+			finished = true
+		case <-time.After(time.Millisecond):
+			fc.Add(100 * time.Millisecond)
+		}
+	}
+	test.AssertNotError(t, err, "Throttled request failed")
+	test.Assert(t, fc.Since(start) >= time.Second, "Request didn't wait for its token")
+
+	// A log without a rate limit is never throttled
+	ctLog.limiter = nil
+	err = pub.throttle(shortCtx, ctLog)
+	test.AssertNotError(t, err, "Request to log without a rate limit was throttled")
+}
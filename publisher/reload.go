@@ -0,0 +1,65 @@
+package publisher
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// logs returns the configured logs. The slice is replaced rather than
+// modified by ReloadLogs, so callers can keep using it for the rest of a
+// submission.
+func (pub *Impl) logs() []*Log {
+	pub.logsMu.RLock()
+	defer pub.logsMu.RUnlock()
+	return pub.ctLogs
+}
+
+// enabledLogs returns the configured logs that are enabled for submission
+func (pub *Impl) enabledLogs() []*Log {
+	enabled, _ := splitDisabled(pub.logs())
+	return enabled
+}
+
+// splitDisabled splits logs into those that are enabled for submission and
+// those that are disabled, so that a submission sees both from the same
+// snapshot of the configured logs
+func splitDisabled(logs []*Log) (enabled, disabled []*Log) {
+	for _, ctLog := range logs {
+		if ctLog.disabled {
+			disabled = append(disabled, ctLog)
+		} else {
+			enabled = append(enabled, ctLog)
+		}
+	}
+	return enabled, disabled
+}
+
+// countSkipped counts a skipped submission to each of the disabled logs, for
+// a submission that is being made to the enabled ones
+func (pub *Impl) countSkipped(disabled []*Log) {
+	for _, ctLog := range disabled {
+		pub.metrics.submissions.With(prometheus.Labels{"log": ctLog.uri, "result": "skipped"}).Inc()
+	}
+}
+
+// ReloadLogs replaces the configured logs with those described by logs, e.g.
+// after the config file has changed. Every description is validated in the
+// same way as at startup first, and if any is invalid the configured logs are
+// left as they were. Submissions already in progress finish against the logs
+// they started with. Cached SCTs are discarded, since they may be from logs
+// that are no longer configured.
+func (pub *Impl) ReloadLogs(logs []cmd.LogDescription) error {
+	newLogs, err := NewLogs(cmd.CTConfig{Logs: logs}, pub.client, pub.log)
+	if err != nil {
+		return err
+	}
+	pub.logsMu.Lock()
+	defer pub.logsMu.Unlock()
+	pub.ctLogs = newLogs
+	pub.cache.clear()
+	pub.log.Info(fmt.Sprintf("Reloaded CT logs, %d configured", len(newLogs)))
+	return nil
+}
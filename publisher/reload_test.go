@@ -0,0 +1,128 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestReloadLogs(t *testing.T) {
+	pub, leaf, k := setup(t)
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	key := base64.StdEncoding.EncodeToString(der)
+
+	// The original log holds its first submission until the logs have been
+	// reloaded
+	received := make(chan struct{})
+	reloaded := make(chan struct{})
+	sct := createSignedSCT(leaf.Raw, k)
+	oldSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-reloaded
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+	defer oldSrv.Close()
+	port, err := getPort(oldSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	newSrv := logSrv(leaf.Raw, k)
+	defer newSrv.Close()
+	newPort, err := getPort(newSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	newURI := fmt.Sprintf("http://localhost:%d/ct", newPort)
+
+	errs := make(chan error)
+	go func() {
+		errs <- pub.SubmitToCT(ctx, leaf.Raw)
+	}()
+	<-received
+
+	// An invalid description leaves the configured logs as they were
+	oldURI := pub.logs()[0].uri
+	err = pub.ReloadLogs([]cmd.LogDescription{
+		{URI: newURI, Key: key, AllowHTTP: true},
+		{URI: "https://ct.example.com", Key: "not base64"},
+	})
+	test.AssertError(t, err, "Reloading with an invalid log description succeeded")
+	test.AssertEquals(t, len(pub.logs()), 1)
+	test.AssertEquals(t, pub.logs()[0].uri, oldURI)
+
+	err = pub.ReloadLogs([]cmd.LogDescription{{URI: newURI, Key: key, AllowHTTP: true}})
+	test.AssertNotError(t, err, "Failed to reload logs")
+	test.AssertEquals(t, len(pub.logs()), 1)
+	test.AssertEquals(t, pub.logs()[0].uri, newURI)
+
+	// The submission in progress still finishes against the original log,
+	// while new ones go to the reloaded log
+	close(reloaded)
+	test.AssertNotError(t, <-errs, "Submission in progress during reload failed")
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Submission to reloaded logs failed")
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, scts[0].LogURI, newURI)
+}
+
+func TestDisabledLogs(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	key := base64.StdEncoding.EncodeToString(der)
+	enabledSrv := logSrv(leaf.Raw, k)
+	defer enabledSrv.Close()
+	disabledSrv := logSrv(leaf.Raw, k)
+	defer disabledSrv.Close()
+	var uris []string
+	for _, srv := range []*httptest.Server{enabledSrv, disabledSrv} {
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		uris = append(uris, fmt.Sprintf("http://localhost:%d/ct", port))
+	}
+	disabled := false
+	err = pub.ReloadLogs([]cmd.LogDescription{
+		{URI: uris[0], Key: key, AllowHTTP: true},
+		{URI: uris[1], Key: key, AllowHTTP: true, Enabled: &disabled},
+	})
+	test.AssertNotError(t, err, "Failed to reload logs")
+
+	// Only the enabled log is submitted to, and is enough for the submission
+	// to succeed
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Submission with a disabled log failed")
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, scts[0].LogURI, uris[0])
+	test.AssertEquals(t, count(prometheus.Labels{"log": uris[1], "result": "skipped"}, pub.metrics.submissions), 1)
+	test.AssertEquals(t, count(prometheus.Labels{"log": uris[1], "result": "attempted"}, pub.metrics.submissions), 0)
+
+	// Assembling an SCT list from stored SCTs isn't a submission, so it
+	// doesn't count as skipping the disabled log
+	_, err = pub.SCTListForSerial(ctx, core.SerialToString(leaf.SerialNumber))
+	test.AssertNotError(t, err, "Failed to assemble SCT list")
+	test.AssertEquals(t, count(prometheus.Labels{"log": uris[1], "result": "skipped"}, pub.metrics.submissions), 1)
+
+	// Neither is a resubmission with no log missing an SCT, nor a dry run
+	_, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Failed to resubmit certificate")
+	pub.cache.clear()
+	pub.dryRun = true
+	_, err = pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Dry run failed")
+	pub.dryRun = false
+	test.AssertEquals(t, count(prometheus.Labels{"log": uris[1], "result": "skipped"}, pub.metrics.submissions), 1)
+
+	// The disabled log is still health checked
+	results := pub.Healthcheck(ctx)
+	_, checked := results[uris[1]]
+	test.Assert(t, checked, "Disabled log wasn't health checked")
+}
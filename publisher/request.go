@@ -0,0 +1,46 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// doRequest makes a request to the endpoint at path of ctLog, once the log's
+// rate limit allows it, and is used for every endpoint the publisher calls. A
+// POST sends body as JSON and a GET sends params in the query string. The
+// response to either is decoded into respObj if it has status 200, otherwise
+// the response is returned with a nil error, as from the CT client's
+// PostAndParse, for the caller to handle the status, see statusError. The
+// User-Agent and other headers, the response size limit and the recording of
+// attempts are applied by the transports of the publisher's HTTP client, so are
+// the same for every endpoint. An error is returned if the request couldn't
+// be made or its response couldn't be read or decoded.
+func (pub *Impl) doRequest(ctx context.Context, ctLog *Log, method, path string, params map[string]string, body, respObj interface{}) (*http.Response, error) {
+	if err := pub.throttle(ctx, ctLog); err != nil {
+		return nil, err
+	}
+	switch method {
+	case http.MethodGet:
+		httpResp, err := ctLog.client.GetAndParse(ctx, path, params, respObj)
+		// Unlike PostAndParse, GetAndParse fails responses with other statuses
+		if httpResp != nil && httpResp.StatusCode != http.StatusOK {
+			return httpResp, nil
+		}
+		return httpResp, err
+	case http.MethodPost:
+		return ctLog.client.PostAndParse(ctx, path, body, respObj)
+	}
+	return nil, fmt.Errorf("unsupported method %s for CT log request", method)
+}
+
+// statusError returns the error for a response from doRequest with a status
+// other than 200, including the start of the response body if it was
+// recorded, see lastResponseBody
+func statusError(ctx context.Context, httpResp *http.Response) error {
+	if body := lastResponseBody(ctx); body != "" {
+		return fmt.Errorf("got HTTP Status %q: %s", httpResp.Status, body)
+	}
+	return fmt.Errorf("got HTTP Status %q", httpResp.Status)
+}
@@ -0,0 +1,75 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDoRequest(t *testing.T) {
+	pub, _, _ := setup(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch path.Base(r.URL.Path) {
+		case "echo":
+			var body map[string]string
+			if r.Method == http.MethodPost {
+				data, _ := ioutil.ReadAll(r.Body)
+				_ = json.Unmarshal(data, &body)
+			} else {
+				body = map[string]string{"start": r.URL.Query().Get("start")}
+			}
+			data, _ := json.Marshal(body)
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("come back later"))
+		}
+	}))
+	defer srv.Close()
+	ctLog, err := NewLog(cmd.LogDescription{URI: srv.URL + "/ct", SkipSignatureVerification: true}, pub.client, log)
+	test.AssertNotError(t, err, "Failed to create log")
+
+	var resp map[string]string
+	httpResp, err := pub.doRequest(ctx, ctLog, http.MethodGet, "/ct/v1/echo", map[string]string{"start": "5"}, nil, &resp)
+	test.AssertNotError(t, err, "GET failed")
+	test.AssertEquals(t, httpResp.StatusCode, http.StatusOK)
+	test.AssertEquals(t, resp["start"], "5")
+
+	resp = nil
+	httpResp, err = pub.doRequest(ctx, ctLog, http.MethodPost, "/ct/v1/echo", nil, map[string]string{"chain": "abc"}, &resp)
+	test.AssertNotError(t, err, "POST failed")
+	test.AssertEquals(t, httpResp.StatusCode, http.StatusOK)
+	test.AssertEquals(t, resp["chain"], "abc")
+
+	// Other statuses are left to the caller, for either method
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		httpResp, err = pub.doRequest(ctx, ctLog, method, "/ct/v1/busy", nil, nil, &resp)
+		test.AssertNotError(t, err, fmt.Sprintf("%s of unavailable endpoint failed", method))
+		test.AssertEquals(t, httpResp.StatusCode, http.StatusServiceUnavailable)
+		test.AssertEquals(t, statusError(ctx, httpResp).Error(), `got HTTP Status "503 Service Unavailable"`)
+	}
+
+	_, err = pub.doRequest(ctx, ctLog, http.MethodPut, "/ct/v1/echo", nil, nil, &resp)
+	test.AssertEquals(t, err.Error(), "unsupported method PUT for CT log request")
+
+	// Every endpoint is subject to the log's rate limit
+	ctLog.limiter = newRateLimiter(1, 1)
+	_, err = pub.doRequest(ctx, ctLog, http.MethodGet, "/ct/v1/echo", nil, nil, &resp)
+	test.AssertNotError(t, err, "First request was throttled")
+	shortCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = pub.doRequest(shortCtx, ctLog, http.MethodGet, "/ct/v1/echo", nil, nil, &resp)
+	_, ok := err.(ErrThrottled)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrThrottled, got %T", err))
+}
@@ -0,0 +1,35 @@
+package publisher
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// requestIDHeader is the header a submission's request ID is sent to logs in
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id as the request ID of the
+// submissions made with it. The ID is included in the audit log lines of
+// those submissions and, when SendRequestID is configured, sent to the logs,
+// so that an issuance can be correlated with its CT submissions.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID appends the request ID carried by ctx, if any, to the log
+// message msg
+func withRequestID(ctx context.Context, msg string) string {
+	if id := RequestID(ctx); id != "" {
+		return fmt.Sprintf("%s [RequestID=%s]", msg, id)
+	}
+	return msg
+}
@@ -0,0 +1,50 @@
+package publisher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestRequestID(t *testing.T) {
+	test.AssertEquals(t, RequestID(ctx), "")
+	test.AssertEquals(t, RequestID(WithRequestID(ctx, "issuance-1")), "issuance-1")
+
+	var requestIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+
+	// The ID is only sent to the log when configured, but is audit logged
+	// either way
+	for _, send := range []bool{false, true} {
+		pub, leaf, k := setup(t)
+		pub.client, err = NewHTTPClient(cmd.CTConfig{SendRequestID: send})
+		test.AssertNotError(t, err, "Failed to create HTTP client")
+		addLog(t, pub, port, &k.PublicKey)
+		log.Clear()
+		result := pub.submitToLog(WithRequestID(ctx, "issuance-1"), pub.ctLogs[0], ct.X509LogEntryType, leaf)
+		test.AssertError(t, result.Err, "Submission to a failing log succeeded")
+		test.AssertEquals(t, len(log.GetAllMatching(`Failed to submit certificate .*\[RequestID=issuance-1\]`)), 1)
+	}
+	test.AssertDeepEquals(t, requestIDs, []string{"", "issuance-1"})
+
+	// Submissions without an ID don't send the header
+	pub, leaf, k := setup(t)
+	pub.client, err = NewHTTPClient(cmd.CTConfig{SendRequestID: true})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	addLog(t, pub, port, &k.PublicKey)
+	log.Clear()
+	pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertEquals(t, requestIDs[2], "")
+	test.AssertEquals(t, len(log.GetAllMatching(`RequestID`)), 0)
+}
@@ -0,0 +1,212 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"golang.org/x/net/context"
+)
+
+// defaultRootsCacheTTL is how long the roots a log accepts are cached when no
+// TTL is configured. Logs rarely change their roots, so this mostly bounds
+// how long a change goes unnoticed.
+const defaultRootsCacheTTL = time.Hour
+
+// rootsRetryInterval is how long a failure to fetch the roots a log accepts
+// is remembered before they are fetched again, so that while a log's get-roots
+// is failing each submission to it doesn't make its own request
+const rootsRetryInterval = time.Minute
+
+// rootsCache holds the roots a log accepts, as last fetched from its
+// get-roots endpoint, or the error fetching them, until expires. It also holds
+// the issuer chain chainFor selected for those roots, so that the selection is
+// redone whenever the roots are fetched again.
+type rootsCache struct {
+	roots []ct.ASN1Cert
+	// parsed are the roots the standard library can parse. Logs occasionally
+	// include roots it can't, which can't be used for chain building.
+	parsed  []*x509.Certificate
+	err     error
+	expires time.Time
+
+	selectOnce sync.Once
+	chain      []ct.ASN1Cert
+	chainErr   error
+}
+
+// cachedRoots returns the roots cache of ctLog, fetching the roots with
+// getAcceptedRoots if they haven't been fetched within the roots cache TTL, or
+// within rootsRetryInterval if the last fetch failed. The returned error is
+// only that of ctx, the error of a failed fetch is in the cache. The log's
+// lock isn't held while fetching, and only one fetch is made at a time: while
+// one is in progress the previous cache is returned if there is one, and
+// otherwise the fetch is waited for.
+func (pub *Impl) cachedRoots(ctx context.Context, ctLog *Log) (*rootsCache, error) {
+	ctLog.rootsMu.Lock()
+	for {
+		cache := ctLog.roots
+		if cache != nil && (pub.clk.Now().Before(cache.expires) || ctLog.rootsFetching != nil) {
+			ctLog.rootsMu.Unlock()
+			return cache, nil
+		}
+		if ctLog.rootsFetching == nil {
+			break
+		}
+		fetching := ctLog.rootsFetching
+		ctLog.rootsMu.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		ctLog.rootsMu.Lock()
+	}
+	fetching := make(chan struct{})
+	ctLog.rootsFetching = fetching
+	ctLog.rootsMu.Unlock()
+
+	cache := pub.fetchRoots(ctx, ctLog)
+
+	ctLog.rootsMu.Lock()
+	// A fetch cut short by the caller's context says nothing about the log,
+	// so it isn't cached
+	if ctx.Err() == nil {
+		ctLog.roots = cache
+	}
+	ctLog.rootsFetching = nil
+	ctLog.rootsMu.Unlock()
+	close(fetching)
+	return cache, nil
+}
+
+// fetchRoots fetches the roots ctLog accepts into a new roots cache
+func (pub *Impl) fetchRoots(ctx context.Context, ctLog *Log) *rootsCache {
+	now := pub.clk.Now()
+	roots, err := pub.getAcceptedRoots(ctx, ctLog)
+	if err != nil {
+		retry := rootsRetryInterval
+		if pub.rootsCacheTTL < retry {
+			retry = pub.rootsCacheTTL
+		}
+		return &rootsCache{err: err, expires: now.Add(retry)}
+	}
+	cache := &rootsCache{roots: roots, expires: now.Add(pub.rootsCacheTTL)}
+	for _, rootDER := range roots {
+		if root, err := x509.ParseCertificate(rootDER.Data); err == nil {
+			cache.parsed = append(cache.parsed, root)
+		}
+	}
+	return cache
+}
+
+// acceptedRoots returns the roots ctLog accepts, see cachedRoots
+func (pub *Impl) acceptedRoots(ctx context.Context, ctLog *Log) ([]ct.ASN1Cert, error) {
+	cache, err := pub.cachedRoots(ctx, ctLog)
+	if err != nil {
+		return nil, err
+	}
+	return cache.roots, cache.err
+}
+
+// GetRoots returns the DER encoded roots accepted by the configured log with
+// the given URI, from its get-roots endpoint. The roots are cached for the
+// roots cache TTL, so this may not reflect a change the log made since.
+func (pub *Impl) GetRoots(ctx context.Context, logURI string) ([][]byte, error) {
+	ctLog, err := pub.logByURI(logURI)
+	if err != nil {
+		return nil, err
+	}
+	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
+	defer cancel()
+	roots, err := pub.acceptedRoots(localCtx, ctLog)
+	if err != nil {
+		return nil, fmt.Errorf("fetching roots from CT log at %s: %s", logURI, err)
+	}
+	der := make([][]byte, len(roots))
+	for i, root := range roots {
+		der[i] = root.Data
+	}
+	return der, nil
+}
+
+// CheckIssuerAccepted fetches the roots accepted by each configured log and
+// warns about the logs that the default issuer bundle, or any of the
+// cross-signed intermediates, doesn't chain to, since every submission of
+// the issuer's certificates to them will be rejected. It returns the URIs of
+// those logs, and is meant to be run once at startup, see
+// cmd.CTConfig.CheckAcceptedRoots. The logs are checked concurrently, up to
+// the healthcheck concurrency at once, and each log's fetch is bounded by its
+// own healthcheck timeout so that a slow log doesn't leave the others
+// unchecked. Logs whose roots can't be fetched are only warned about.
+func (pub *Impl) CheckIssuerAccepted(ctx context.Context) []string {
+	if len(pub.issuerBundle) == 0 && len(pub.crossSigns) == 0 {
+		return nil
+	}
+	logs := pub.logs()
+	// Results are collected by index so that the URIs are returned in the
+	// order the logs are configured
+	rejected := make([]bool, len(logs))
+	sem := make(chan struct{}, pub.healthcheckConcurrency)
+	var wg sync.WaitGroup
+	for i, ctLog := range logs {
+		wg.Add(1)
+		go func(i int, ctLog *Log) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			rejected[i] = pub.checkIssuerAccepted(ctx, ctLog)
+		}(i, ctLog)
+	}
+	wg.Wait()
+	var rejecting []string
+	for i, ctLog := range logs {
+		if rejected[i] {
+			rejecting = append(rejecting, ctLog.uri)
+		}
+	}
+	return rejecting
+}
+
+// checkIssuerAccepted fetches the roots ctLog accepts within the healthcheck
+// timeout and returns true if the issuer doesn't chain to any of them
+func (pub *Impl) checkIssuerAccepted(ctx context.Context, ctLog *Log) bool {
+	ctx, cancel := context.WithTimeout(ctx, pub.healthcheckTimeout)
+	defer cancel()
+	cache, err := pub.cachedRoots(ctx, ctLog)
+	if err == nil {
+		err = cache.err
+	}
+	if err != nil {
+		pub.log.Warning(fmt.Sprintf("Couldn't check that CT log at %s accepts our issuer: %s", ctLog.uri, err))
+		return false
+	}
+	roots := cache.parsed
+	if !pub.issuerChainsTo(roots) {
+		pub.log.Warning(fmt.Sprintf(
+			"Our issuer doesn't chain to any of the %d roots CT log at %s accepts, submissions to it will be rejected",
+			len(roots), ctLog.uri))
+		return true
+	}
+	return false
+}
+
+// issuerChainsTo returns true if the last certificate of the default issuer
+// bundle, or one of the cross-signed intermediates, is one of roots or is
+// issued by one of them
+func (pub *Impl) issuerChainsTo(roots []*x509.Certificate) bool {
+	if len(pub.issuerBundle) > 0 {
+		last, err := x509.ParseCertificate(pub.issuerBundle[len(pub.issuerBundle)-1].Data)
+		if err == nil && issuedByAny(last, roots) {
+			return true
+		}
+	}
+	for _, crossSign := range pub.crossSigns {
+		if issuedByAny(crossSign, roots) {
+			return true
+		}
+	}
+	return false
+}
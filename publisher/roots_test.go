@@ -0,0 +1,170 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestGetRoots(t *testing.T) {
+	pub, leaf, k := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+	rootKey, otherKey := testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	other := issueTestCert(t, "other root", true, &otherKey.PublicKey, nil, otherKey)
+	srv := &rootsLogSrv{roots: []*x509.Certificate{root, other}}
+	httpSrv := srv.start(leaf.Raw, k)
+	defer httpSrv.Close()
+	port, err := getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	uri := pub.ctLogs[0].uri
+
+	roots, err := pub.GetRoots(ctx, uri)
+	test.AssertNotError(t, err, "GetRoots failed")
+	test.AssertEquals(t, len(roots), 2)
+	test.AssertByteEquals(t, roots[0], root.Raw)
+	test.AssertByteEquals(t, roots[1], other.Raw)
+
+	// The roots are cached until the TTL has passed
+	_, err = pub.GetRoots(ctx, uri)
+	test.AssertNotError(t, err, "GetRoots failed")
+	test.AssertEquals(t, srv.getRoots, 1)
+	fc.Add(defaultRootsCacheTTL)
+	_, err = pub.GetRoots(ctx, uri)
+	test.AssertNotError(t, err, "GetRoots failed")
+	test.AssertEquals(t, srv.getRoots, 2)
+
+	down := errorLogSrv()
+	defer down.Close()
+	port, err = getPort(down)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	_, err = pub.GetRoots(ctx, pub.ctLogs[1].uri)
+	test.AssertError(t, err, "GetRoots succeeded for a failing log")
+
+	_, err = pub.GetRoots(ctx, "https://unknown.example.com")
+	test.AssertError(t, err, "GetRoots succeeded for a log that isn't configured")
+}
+
+func TestCheckIssuerAccepted(t *testing.T) {
+	pub, leaf, k := setup(t)
+	rootKey, otherKey, intKey := testKey(t), testKey(t), testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	other := issueTestCert(t, "other root", true, &otherKey.PublicKey, nil, otherKey)
+	intermediate := issueTestCert(t, "intermediate", true, &intKey.PublicKey, root, rootKey)
+	pub.issuerBundle = []ct.ASN1Cert{{Data: intermediate.Raw}}
+
+	for _, accepted := range []*x509.Certificate{root, other} {
+		srv := &rootsLogSrv{roots: []*x509.Certificate{accepted}}
+		httpSrv := srv.start(leaf.Raw, k)
+		defer httpSrv.Close()
+		port, err := getPort(httpSrv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+	down := errorLogSrv()
+	defer down.Close()
+	port, err := getPort(down)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	log.Clear()
+	rejecting := pub.CheckIssuerAccepted(ctx)
+	test.AssertDeepEquals(t, rejecting, []string{pub.ctLogs[1].uri})
+	test.AssertEquals(t, len(log.GetAllMatching(fmt.Sprintf(
+		"Our issuer doesn't chain to any of the 1 roots CT log at %s accepts", pub.ctLogs[1].uri))), 1)
+	test.AssertEquals(t, len(log.GetAllMatching(fmt.Sprintf(
+		"Couldn't check that CT log at %s accepts our issuer", pub.ctLogs[2].uri))), 1)
+
+	// A cross-sign issued by the other root is accepted by the second log
+	otherCross := issueTestCert(t, "intermediate", true, &intKey.PublicKey, other, otherKey)
+	pub.crossSigns = []*x509.Certificate{otherCross}
+	test.AssertEquals(t, len(pub.CheckIssuerAccepted(ctx)), 0)
+
+	// Each log gets its own timeout, so a log that doesn't answer doesn't
+	// stop the logs after it from being checked
+	pub, leaf, k = setup(t)
+	pub.issuerBundle = []ct.ASN1Cert{{Data: intermediate.Raw}}
+	pub.healthcheckConcurrency = 1
+	pub.healthcheckTimeout = 100 * time.Millisecond
+	hung := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer slow.Close()
+	port, err = getPort(slow)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	// Deferred after the server so that its handler returns before the server
+	// is closed
+	defer close(hung)
+	srv := &rootsLogSrv{roots: []*x509.Certificate{other}}
+	httpSrv := srv.start(leaf.Raw, k)
+	defer httpSrv.Close()
+	port, err = getPort(httpSrv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	test.AssertDeepEquals(t, pub.CheckIssuerAccepted(ctx), []string{pub.ctLogs[1].uri})
+}
+
+func TestCachedRootsRefreshInBackground(t *testing.T) {
+	pub, _, k := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Now())
+	pub.clk = fc
+	rootKey := testKey(t)
+	root := issueTestCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+
+	// The second get-roots request hangs until released
+	requests := make(chan struct{}, 2)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		if len(requests) == 2 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(ct.GetRootsResponse{
+			Certificates: []string{base64.StdEncoding.EncodeToString(root.Raw)},
+		})
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	ctLog := pub.ctLogs[0]
+
+	first, err := pub.cachedRoots(ctx, ctLog)
+	test.AssertNotError(t, err, "Failed to fetch roots")
+	test.AssertNotError(t, first.err, "Failed to fetch roots")
+
+	// While the expired roots are being fetched again, other callers get the
+	// expired ones rather than waiting for the fetch
+	fc.Add(defaultRootsCacheTTL)
+	refreshed := make(chan *rootsCache)
+	go func() {
+		cache, _ := pub.cachedRoots(ctx, ctLog)
+		refreshed <- cache
+	}()
+	for len(requests) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	stale, err := pub.cachedRoots(ctx, ctLog)
+	test.AssertNotError(t, err, "Failed to get roots during a fetch")
+	test.Assert(t, stale == first, "Didn't get the previous roots during a fetch")
+	close(release)
+	test.Assert(t, <-refreshed != first, "Roots weren't fetched again")
+	test.AssertEquals(t, len(requests), 2)
+}
@@ -0,0 +1,170 @@
+package publisher
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// createRSASignedSCT returns the JSON add-chain response of a log with an RSA
+// key, signing the SCT using the given hash algorithm
+func createRSASignedSCT(t *testing.T, leaf []byte, k *rsa.PrivateKey, hash ctTLS.HashAlgorithm) string {
+	rawKey, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	pkHash := sha256.Sum256(rawKey)
+	timestamp := nowTimestamp()
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: pkHash},
+		Timestamp:  timestamp,
+	}
+	serialized, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{
+		Leaf: ct.MerkleTreeLeaf{
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				X509Entry: &ct.ASN1Cert{Data: leaf},
+				EntryType: ct.X509LogEntryType,
+			},
+		},
+	})
+	test.AssertNotError(t, err, "Failed to serialize SCT")
+	var sig []byte
+	if hash == ctTLS.SHA1 {
+		hashed := sha1.Sum(serialized)
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA1, hashed[:])
+	} else {
+		hashed := sha256.Sum256(serialized)
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hashed[:])
+	}
+	test.AssertNotError(t, err, "Failed to sign SCT")
+
+	ds := ct.DigitallySigned{
+		Algorithm: ctTLS.SignatureAndHashAlgorithm{
+			Hash:      hash,
+			Signature: ctTLS.RSA,
+		},
+		Signature: sig,
+	}
+	b64Sig, err := ds.Base64String()
+	test.AssertNotError(t, err, "Failed to encode signature")
+	jsonSCT, err := json.Marshal(map[string]interface{}{
+		"sct_version": ct.V1,
+		"id":          base64.StdEncoding.EncodeToString(pkHash[:]),
+		"timestamp":   timestamp,
+		"extensions":  "",
+		"signature":   b64Sig,
+	})
+	test.AssertNotError(t, err, "Failed to marshal SCT")
+	return string(jsonSCT)
+}
+
+func TestRSASignedSCT(t *testing.T) {
+	pub, leaf, _ := setup(t)
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+
+	for _, hash := range []ctTLS.HashAlgorithm{ctTLS.SHA256, ctTLS.SHA1} {
+		sct := createRSASignedSCT(t, leaf.Raw, k, hash)
+		m := http.NewServeMux()
+		m.HandleFunc("/ct/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, sct)
+		})
+		srv := httptest.NewServer(m)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		ctLog, err := NewLog(cmd.LogDescription{
+			URI: fmt.Sprintf("http://localhost:%d/ct", port),
+			Key: base64.StdEncoding.EncodeToString(der),
+		}, nil, log)
+		test.AssertNotError(t, err, "Couldn't create log with an RSA key")
+		pub.ctLogs = append(pub.ctLogs, ctLog)
+	}
+
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "Submission to a log with an RSA key failed")
+
+	// The SHA-1 signature is valid, but RFC 6962 requires SHA-256
+	result = pub.submitToLog(ctx, pub.ctLogs[1], ct.X509LogEntryType, leaf)
+	test.AssertError(t, result.Err, "SCT signed using SHA-1 was accepted")
+	badSig, ok := result.Err.(ErrBadSCTSignature)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTSignature, got %T", result.Err))
+	test.AssertEquals(t, badSig.Err.Error(), "SCT signature uses unsupported hash algorithm SHA1")
+}
+
+// rsaLogKey is the RSA public key of the test log from the Certificate
+// Transparency reference implementation's test data, and rsaLogSCT its
+// add-chain response for rsaLogCert. The response's id is the SHA-256 hash of
+// the key, as the test data doesn't include the log ID.
+const (
+	rsaLogKey = "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAxy7llbig9kL0wo5AyV1FhmJLvWTWxzAMwGdhG1h1CqQpaWutXGI9WKRDJSZ/9dr9vgvqdRX2QsnUdJbJ3cz5Z1ie/RdT/mSVO7ZEqvJS93PIHnquFZXxNnIerGnQ7guC+Zm9BlQ2DIhYpnvVRRVyD/D8KT92R7qOu3JACduoMrF1synknL8rb8lZvCej8tbhJ38yibMWTmkxsFS+a29Xqk8pkhgwIwvUZqcMaqZo+4/iCuKLbVc85V98SvbcnmsX3gqeQnyRtxlctlclcbvHmJt5U+3yF1UtcuiyZf1gjcAqnOgvZZYzsodXi0KGV7NRQhTPvwH0C8In2qL+v4qWAQIDAQAB"
+	rsaLogSCT = `{"sct_version":0,"id":"y3BBSGoPqmoYDmqgOD53idfzkeoAFqCOZs2gGKAe1nc=","timestamp":1348589665525,"extensions":"",` +
+		`"signature":"BAEBAGvB/s/pBSA24xJ4zX7e2Q0ACxJ/K2V4Mbr17LMe48F0l6vZVi32MZkoo23wqxoakXs/RTDhygAArmxKDA762n34O+uV2o7qmPGifHCvocyqegJF4dt4WxwNn+4wfpJuFL7R6sDQHDSTnmWTYEMqlVLAK4nD7zxEqiL8MfJERSKXXug5id168asFuRu/CYXKTQQkW2imgwHTAPDJds4T1YYY2tG0nA7FzcQ1IBaCP8iMR57yFHbF8ZkjryB9uxss/3LU4eXud91CC4XQ+dzDCg9hfC08kW63fxZzI1ANG1PcQlMyGhBuRBrzQ88vaGMIc6vUPKUmKcWGEH6364Xyw+4="}`
+	rsaLogCert = `-----BEGIN CERTIFICATE-----
+MIICyjCCAjOgAwIBAgIBAjANBgkqhkiG9w0BAQUFADBVMQswCQYDVQQGEwJHQjEk
+MCIGA1UEChMbQ2VydGlmaWNhdGUgVHJhbnNwYXJlbmN5IENBMQ4wDAYDVQQIEwVX
+YWxlczEQMA4GA1UEBxMHRXJ3IFdlbjAeFw0xMjA2MDEwMDAwMDBaFw0yMjA2MDEw
+MDAwMDBaMFIxCzAJBgNVBAYTAkdCMSEwHwYDVQQKExhDZXJ0aWZpY2F0ZSBUcmFu
+c3BhcmVuY3kxDjAMBgNVBAgTBVdhbGVzMRAwDgYDVQQHEwdFcncgV2VuMIGfMA0G
+CSqGSIb3DQEBAQUAA4GNADCBiQKBgQC4dCJniYuZumv9bm962o5UM39Y/rcifEYk
+hDe6X4mwB8vh7LRUWzjtI/3b9rl0LK+2OBV/aBhHdqGzirOTGN3XNEibTXUBF82D
+oiCntS8pXR4YVxRppYHCPGjFfZc3Ydl4egkftYZJNrFmU14htCfjxtaQsukah/Nr
+fsJvWc5TtQIDAQABo4GsMIGpMB0GA1UdDgQWBBQRhOEYfIeVbf/DHdBSH/Vk776u
+jTB9BgNVHSMEdjB0gBSjuNibomkN+0i7v4fBA53c5WJWxqFZpFcwVTELMAkGA1UE
+BhMCR0IxJDAiBgNVBAoTG0NlcnRpZmljYXRlIFRyYW5zcGFyZW5jeSBDQTEOMAwG
+A1UECBMFV2FsZXMxEDAOBgNVBAcTB0VydyBXZW6CAQAwCQYDVR0TBAIwADANBgkq
+hkiG9w0BAQUFAAOBgQApLs9uRsegvNaQUXOSd3EDhTYzQcCpBJY3J5cHriPMUSik
+veoNSA7QIGs546d6KwxJsCcfQUCrdcHeV6ukmOCUWbR5z5Kk1dXdXL4/ChHiXwQH
+jfiPw4i2G4Z6jeRiFsDhfDH8fYAD7MN74iKS+EJCq4f7CL1N+jwbnOTT7mZn2g==
+-----END CERTIFICATE-----`
+)
+
+func TestRSALogResponse(t *testing.T) {
+	pub, _, _ := setup(t)
+	fc := clock.NewFake()
+	fc.Set(time.Date(2012, 9, 25, 16, 14, 25, 0, time.UTC))
+	pub.clk = fc
+	block, _ := pem.Decode([]byte(rsaLogCert))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	test.AssertNotError(t, err, "Failed to parse certificate")
+
+	sct := rsaLogSCT
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sct)
+	}))
+	defer srv.Close()
+	ctLog, err := NewLog(cmd.LogDescription{URI: srv.URL + "/ct", Key: rsaLogKey}, nil, log)
+	test.AssertNotError(t, err, "Couldn't create log with an RSA key")
+
+	result := pub.submitToLog(ctx, ctLog, ct.X509LogEntryType, cert)
+	test.AssertNotError(t, result.Err, "Failed to verify the RSA log's SCT")
+
+	// The signature covers the timestamp
+	sct = strings.Replace(rsaLogSCT, "1348589665525", "1348589665526", 1)
+	result = pub.submitToLog(ctx, ctLog, ct.X509LogEntryType, cert)
+	_, ok := result.Err.(ErrBadSCTSignature)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrBadSCTSignature, got %v", result.Err))
+}
@@ -0,0 +1,103 @@
+package publisher
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// logSCTJSON is the JSON encoding of a LogSCT. API clients depend on its
+// field names, so they should be added to rather than changed. The log ID is
+// hex encoded, as in sctEvent, and the signature and extensions, in their TLS
+// encodings, are base64 encoded. The timestamp is the SCT's, in milliseconds
+// since the epoch, and the latency is a Go duration string.
+type logSCTJSON struct {
+	LogURI            string    `json:"logURI"`
+	LogID             string    `json:"logID"`
+	Version           uint8     `json:"version"`
+	Timestamp         uint64    `json:"timestamp"`
+	Signature         string    `json:"signature"`
+	Extensions        string    `json:"extensions"`
+	CertificateSerial string    `json:"certificateSerial"`
+	Operator          string    `json:"operator,omitempty"`
+	BestEffort        bool      `json:"bestEffort,omitempty"`
+	Submitted         time.Time `json:"submitted"`
+	Latency           string    `json:"latency"`
+	Expires           time.Time `json:"expires"`
+	MergeDeadline     time.Time `json:"mergeDeadline"`
+}
+
+// MarshalJSON encodes the SCT and what the publisher knows about it as JSON
+// for APIs, see logSCTJSON. Unlike MarshalBinary of the embedded SCT, which
+// gives the RFC 6962 encoding, it includes the log URI and the other
+// metadata, but not the storage ID.
+func (sct LogSCT) MarshalJSON() ([]byte, error) {
+	logID, err := base64.StdEncoding.DecodeString(sct.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding SCT log ID: %s", err)
+	}
+	signature, err := sct.DigitallySigned()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(logSCTJSON{
+		LogURI:            sct.LogURI,
+		LogID:             hex.EncodeToString(logID),
+		Version:           sct.SCTVersion,
+		Timestamp:         sct.Timestamp,
+		Signature:         base64.StdEncoding.EncodeToString(signature),
+		Extensions:        base64.StdEncoding.EncodeToString(sct.Extensions),
+		CertificateSerial: sct.CertificateSerial,
+		Operator:          sct.Operator,
+		BestEffort:        sct.BestEffort,
+		Submitted:         sct.Submitted,
+		Latency:           sct.Latency.String(),
+		Expires:           sct.Expires,
+		MergeDeadline:     sct.MergeDeadline,
+	})
+}
+
+// UnmarshalJSON decodes an SCT encoded by MarshalJSON, also accepting the
+// variants of base64 that decodeBase64 does
+func (sct *LogSCT) UnmarshalJSON(data []byte) error {
+	var raw logSCTJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	logID, err := hex.DecodeString(raw.LogID)
+	if err != nil {
+		return fmt.Errorf("decoding SCT log ID: %s", err)
+	}
+	signature, err := decodeBase64(raw.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding SCT signature: %s", err)
+	}
+	extensions, err := decodeBase64(raw.Extensions)
+	if err != nil {
+		return fmt.Errorf("decoding SCT extensions: %s", err)
+	}
+	latency, err := time.ParseDuration(raw.Latency)
+	if err != nil {
+		return fmt.Errorf("decoding SCT latency: %s", err)
+	}
+	*sct = LogSCT{
+		LogURI:        raw.LogURI,
+		Operator:      raw.Operator,
+		BestEffort:    raw.BestEffort,
+		Submitted:     raw.Submitted,
+		Latency:       latency,
+		Expires:       raw.Expires,
+		MergeDeadline: raw.MergeDeadline,
+	}
+	sct.SCTVersion = raw.Version
+	sct.LogID = base64.StdEncoding.EncodeToString(logID)
+	sct.Timestamp = raw.Timestamp
+	if err := sct.SetDigitallySigned(signature); err != nil {
+		return fmt.Errorf("decoding SCT signature: %s", err)
+	}
+	sct.Extensions = extensions
+	sct.CertificateSerial = raw.CertificateSerial
+	return nil
+}
@@ -0,0 +1,65 @@
+package publisher
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLogSCTJSON(t *testing.T) {
+	submitted := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	sct := LogSCT{
+		LogURI:        "https://ct.example.com/log",
+		Operator:      "Example",
+		Submitted:     submitted,
+		Latency:       1500 * time.Millisecond,
+		Expires:       submitted.Add(90 * 24 * time.Hour),
+		MergeDeadline: submitted.Add(24 * time.Hour),
+		SignedCertificateTimestamp: core.SignedCertificateTimestamp{
+			ID:                 7,
+			SCTVersion:         0,
+			LogID:              base64.StdEncoding.EncodeToString(make([]byte, 32)),
+			Timestamp:          1488369600000,
+			Extensions:         []byte{},
+			Signature:          []byte{0xaa, 0xbb},
+			HashAlgorithm:      4,
+			SignatureAlgorithm: 3,
+			CertificateSerial:  "00000000000000000000000000000000ff",
+		},
+	}
+	data, err := json.Marshal(sct)
+	test.AssertNotError(t, err, "Failed to marshal SCT")
+	test.AssertEquals(t, string(data), `{"logURI":"https://ct.example.com/log",`+
+		`"logID":"`+strings.Repeat("00", 32)+`","version":0,"timestamp":1488369600000,`+
+		`"signature":"BAMAAqq7","extensions":"","certificateSerial":"00000000000000000000000000000000ff",`+
+		`"operator":"Example","submitted":"2017-03-01T12:00:00Z","latency":"1.5s",`+
+		`"expires":"2017-05-30T12:00:00Z","mergeDeadline":"2017-03-02T12:00:00Z"}`)
+
+	var decoded LogSCT
+	err = json.Unmarshal(data, &decoded)
+	test.AssertNotError(t, err, "Failed to unmarshal SCT")
+	// The storage ID isn't part of the encoding
+	sct.ID = 0
+	test.AssertDeepEquals(t, decoded, sct)
+
+	// SCTs are encoded the same way inside other values
+	data, err = json.Marshal(struct{ SCTs []LogSCT }{[]LogSCT{sct}})
+	test.AssertNotError(t, err, "Failed to marshal SCT list")
+	test.Assert(t, strings.HasPrefix(string(data), `{"SCTs":[{"logURI":"https://ct.example.com/log",`), "Unexpected encoding: "+string(data))
+
+	for _, bad := range []string{
+		`{"logID":"zz"}`,
+		`{"logID":"00","signature":"!"}`,
+		// The signature's length field says 2 bytes but only 1 follows
+		`{"logID":"00","signature":"BAMAAqo="}`,
+		`{"logID":"00","latency":"soon"}`,
+		`[]`,
+	} {
+		test.AssertError(t, json.Unmarshal([]byte(bad), &decoded), "Unmarshaled invalid SCT "+bad)
+	}
+}
@@ -0,0 +1,172 @@
+package publisher
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+)
+
+// SignedTreeHead is a CT log's signed tree head, as returned by its get-sth
+// endpoint, with the base64 encoded fields decoded
+type SignedTreeHead struct {
+	LogURI         string
+	TreeSize       uint64
+	Timestamp      uint64
+	SHA256RootHash []byte
+	// TreeHeadSignature is the TLS encoded DigitallySigned struct over the
+	// tree head
+	TreeHeadSignature []byte
+}
+
+// logByURI returns the configured log with the given URI
+func (pub *Impl) logByURI(logURI string) (*Log, error) {
+	for _, ctLog := range pub.logs() {
+		if ctLog.uri == logURI {
+			return ctLog, nil
+		}
+	}
+	return nil, fmt.Errorf("no CT log is configured with URI %s", logURI)
+}
+
+// GetSTH fetches the current signed tree head of the configured log with the
+// given URI. The signature is checked against the log's key, unless
+// verification is disabled for the log, so that this can be used as a health
+// probe for the log independent of issuance.
+func (pub *Impl) GetSTH(ctx context.Context, logURI string) (*SignedTreeHead, error) {
+	ctLog, err := pub.logByURI(logURI)
+	if err != nil {
+		return nil, err
+	}
+	localCtx, cancel := context.WithTimeout(ctx, pub.submissionTimeout)
+	defer cancel()
+	return pub.getSTH(localCtx, ctLog)
+}
+
+func (pub *Impl) getSTH(ctx context.Context, ctLog *Log) (*SignedTreeHead, error) {
+	var resp ct.GetSTHResponse
+	httpResp, err := pub.doRequest(ctx, ctLog, http.MethodGet, ct.GetSTHPath, nil, nil, &resp)
+	if err == nil && httpResp.StatusCode != http.StatusOK {
+		err = statusError(ctx, httpResp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching STH from CT log at %s: %s", ctLog.uri, err)
+	}
+	sth, err := parseSTH(resp)
+	if err != nil {
+		return nil, fmt.Errorf("malformed STH from CT log at %s: %s", ctLog.uri, err)
+	}
+	if err := pub.verifySTH(ctLog, sth); err != nil {
+		return nil, err
+	}
+	pub.checkClockSkew(ctLog, resp.Timestamp)
+	return &SignedTreeHead{
+		LogURI:            ctLog.uri,
+		TreeSize:          resp.TreeSize,
+		Timestamp:         resp.Timestamp,
+		SHA256RootHash:    resp.SHA256RootHash,
+		TreeHeadSignature: resp.TreeHeadSignature,
+	}, nil
+}
+
+// defaultClockSkewThreshold is the default for how far an STH timestamp may
+// be ahead of the local clock before checkClockSkew warns
+const defaultClockSkewThreshold = time.Minute
+
+// checkClockSkew warns, and counts in the clock skew metric, if the timestamp
+// of an STH from ctLog is further ahead of the local clock than the clock
+// skew threshold, as the log's SCTs may then be rejected by
+// checkSCTTimestamp or by clients. Only a log that is ahead can be detected,
+// since an STH may legitimately be up to the log's maximum merge delay old.
+func (pub *Impl) checkClockSkew(ctLog *Log, timestamp uint64) {
+	t := time.Unix(0, int64(timestamp)*int64(time.Millisecond))
+	skew := t.Sub(pub.clk.Now())
+	if skew <= pub.clockSkewThreshold {
+		return
+	}
+	pub.metrics.clockSkew.With(prometheus.Labels{"log": ctLog.uri}).Inc()
+	pub.log.Warning(fmt.Sprintf(
+		"STH from CT log at %s is timestamped %s ahead of the local clock, more than the clock skew threshold of %s",
+		ctLog.uri, skew, pub.clockSkewThreshold))
+}
+
+// parseSTH checks the shape of a get-sth response, in the same way as the
+// signature on an SCT is checked before it is verified
+func parseSTH(resp ct.GetSTHResponse) (ct.SignedTreeHead, error) {
+	sth := ct.SignedTreeHead{
+		Version:   ct.V1,
+		TreeSize:  resp.TreeSize,
+		Timestamp: resp.Timestamp,
+	}
+	if len(resp.SHA256RootHash) != sha256.Size {
+		return sth, fmt.Errorf("sha256_root_hash is %d bytes, expected %d", len(resp.SHA256RootHash), sha256.Size)
+	}
+	copy(sth.SHA256RootHash[:], resp.SHA256RootHash)
+	rest, err := ctTLS.Unmarshal(resp.TreeHeadSignature, &sth.TreeHeadSignature)
+	if err != nil {
+		return sth, fmt.Errorf("unmarshaling tree_head_signature: %s", err)
+	}
+	if len(rest) > 0 {
+		return sth, fmt.Errorf("%d bytes of trailing data after tree_head_signature", len(rest))
+	}
+	if err := checkSignatureAlgorithm(sth.TreeHeadSignature.Algorithm); err != nil {
+		return sth, fmt.Errorf("tree_head_signature %s", err)
+	}
+	if len(sth.TreeHeadSignature.Signature) == 0 {
+		return sth, fmt.Errorf("tree_head_signature is empty")
+	}
+	return sth, nil
+}
+
+// defaultHealthcheckTimeout bounds Healthcheck when no timeout is configured
+const defaultHealthcheckTimeout = 5 * time.Second
+
+// defaultHealthcheckConcurrency is the number of logs Healthcheck probes at
+// once when no concurrency is configured
+const defaultHealthcheckConcurrency = 10
+
+// Healthcheck fetches the STH of every configured log and returns the error
+// for each log's URI, nil if the log returned a valid STH. The logs are probed
+// concurrently, up to the healthcheck concurrency at once so that a long log
+// list doesn't open a connection to every log together. The probes are
+// bounded by the healthcheck timeout rather than the submission timeout, so
+// that a readiness check returns promptly even when logs are down, and logs
+// that haven't been probed by then have context.DeadlineExceeded as their
+// error.
+func (pub *Impl) Healthcheck(ctx context.Context) map[string]error {
+	ctx, cancel := context.WithTimeout(ctx, pub.healthcheckTimeout)
+	defer cancel()
+	logs := pub.logs()
+	results := make(map[string]error, len(logs))
+	sem := make(chan struct{}, pub.healthcheckConcurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ctLog := range logs {
+		wg.Add(1)
+		go func(ctLog *Log) {
+			defer wg.Done()
+			var err error
+			select {
+			case sem <- struct{}{}:
+				// The deadline may have passed while waiting for the slot
+				if err = ctx.Err(); err == nil {
+					_, err = pub.getSTH(ctx, ctLog)
+				}
+				<-sem
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			mu.Lock()
+			results[ctLog.uri] = err
+			mu.Unlock()
+		}(ctLog)
+	}
+	wg.Wait()
+	return results
+}
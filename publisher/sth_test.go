@@ -0,0 +1,223 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func sthLogSrv(t *testing.T, resp ct.GetSTHResponse) *httptest.Server {
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewEncoder(w).Encode(resp)
+		test.AssertNotError(t, err, "Failed to encode get-sth response")
+	})
+	return httptest.NewServer(m)
+}
+
+func sthResponse(t *testing.T, sth ct.SignedTreeHead) ct.GetSTHResponse {
+	sig, err := ctTLS.Marshal(sth.TreeHeadSignature)
+	test.AssertNotError(t, err, "Failed to marshal tree head signature")
+	return ct.GetSTHResponse{
+		TreeSize:          sth.TreeSize,
+		Timestamp:         sth.Timestamp,
+		SHA256RootHash:    sth.SHA256RootHash[:],
+		TreeHeadSignature: sig,
+	}
+}
+
+func TestGetSTH(t *testing.T) {
+	pub, _, k := setup(t)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+
+	good := sthResponse(t, createSignedSTH(t, k, 10))
+	badSig := sthResponse(t, createSignedSTH(t, otherKey, 10))
+	shortHash := good
+	shortHash.SHA256RootHash = shortHash.SHA256RootHash[:16]
+	for _, resp := range []ct.GetSTHResponse{good, badSig, shortHash} {
+		srv := sthLogSrv(t, resp)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	sth, err := pub.GetSTH(ctx, pub.ctLogs[0].uri)
+	test.AssertNotError(t, err, "GetSTH failed")
+	test.AssertEquals(t, sth.LogURI, pub.ctLogs[0].uri)
+	test.AssertEquals(t, sth.TreeSize, uint64(10))
+	test.AssertEquals(t, sth.Timestamp, uint64(1337))
+	test.AssertByteEquals(t, sth.SHA256RootHash, good.SHA256RootHash)
+	test.AssertByteEquals(t, sth.TreeHeadSignature, good.TreeHeadSignature)
+
+	log.Clear()
+	_, err = pub.GetSTH(ctx, pub.ctLogs[1].uri)
+	test.AssertError(t, err, "GetSTH accepted an STH signed by the wrong key")
+	test.AssertEquals(t, len(log.GetAllMatching("Failed to verify STH signature")), 1)
+
+	_, err = pub.GetSTH(ctx, pub.ctLogs[2].uri)
+	test.AssertError(t, err, "GetSTH accepted an STH with a truncated root hash")
+	test.AssertEquals(t, err.Error(), "malformed STH from CT log at "+pub.ctLogs[2].uri+": sha256_root_hash is 16 bytes, expected 32")
+
+	_, err = pub.GetSTH(ctx, "https://unknown.example.com")
+	test.AssertError(t, err, "GetSTH succeeded for a log that isn't configured")
+}
+
+func TestGetSTHClockSkew(t *testing.T) {
+	pub, _, k := setup(t)
+	srv := sthLogSrv(t, sthResponse(t, createSignedSTH(t, k, 10)))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	uri := pub.ctLogs[0].uri
+	labels := prometheus.Labels{"log": uri}
+
+	// The test STH is timestamped 1337ms after the epoch
+	fc := clock.NewFake()
+	fc.Set(time.Unix(0, 0))
+	pub.clk = fc
+	pub.clockSkewThreshold = 2 * time.Second
+	log.Clear()
+	_, err = pub.GetSTH(ctx, uri)
+	test.AssertNotError(t, err, "GetSTH failed")
+	test.AssertEquals(t, count(labels, pub.metrics.clockSkew), 0)
+	test.AssertEquals(t, len(log.GetAllMatching("clock skew threshold")), 0)
+
+	pub.clockSkewThreshold = time.Second
+	_, err = pub.GetSTH(ctx, uri)
+	test.AssertNotError(t, err, "GetSTH failed for a skewed log")
+	test.AssertEquals(t, count(labels, pub.metrics.clockSkew), 1)
+	test.AssertEquals(t, len(log.GetAllMatching(fmt.Sprintf(
+		"STH from CT log at %s is timestamped 1.337s ahead of the local clock, more than the clock skew threshold of 1s", uri))), 1)
+
+	// An STH behind the local clock may just be old
+	fc.Set(time.Unix(3600, 0))
+	_, err = pub.GetSTH(ctx, uri)
+	test.AssertNotError(t, err, "GetSTH failed")
+	test.AssertEquals(t, count(labels, pub.metrics.clockSkew), 1)
+}
+
+func TestParseSTHSignatureShape(t *testing.T) {
+	_, _, k := setup(t)
+	sth := createSignedSTH(t, k, 10)
+	sth.TreeHeadSignature.Algorithm.Hash = ctTLS.SHA1
+	_, err := parseSTH(sthResponse(t, sth))
+	test.AssertError(t, err, "parseSTH accepted a SHA-1 signature")
+
+	resp := sthResponse(t, createSignedSTH(t, k, 10))
+	resp.TreeHeadSignature = append(resp.TreeHeadSignature, 0)
+	_, err = parseSTH(resp)
+	test.AssertError(t, err, "parseSTH accepted trailing data after the signature")
+}
+
+func TestHealthcheck(t *testing.T) {
+	pub, _, k := setup(t)
+	pub.healthcheckTimeout = 100 * time.Millisecond
+
+	good := sthLogSrv(t, sthResponse(t, createSignedSTH(t, k, 10)))
+	defer good.Close()
+	down := errorLogSrv()
+	defer down.Close()
+	hung := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer slow.Close()
+	defer close(hung)
+	for _, srv := range []*httptest.Server{good, down, slow} {
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	start := time.Now()
+	results := pub.Healthcheck(ctx)
+	test.Assert(t, time.Since(start) < time.Second, "Healthcheck didn't respect its timeout")
+	test.AssertEquals(t, len(results), 3)
+	test.AssertNotError(t, results[pub.ctLogs[0].uri], "Healthy log reported an error")
+	test.AssertError(t, results[pub.ctLogs[1].uri], "Failing log reported healthy")
+	test.AssertError(t, results[pub.ctLogs[2].uri], "Unresponsive log reported healthy")
+}
+
+func TestHealthcheckConcurrency(t *testing.T) {
+	pub, _, k := setup(t)
+	pub.healthcheckConcurrency = 3
+	resp := sthResponse(t, createSignedSTH(t, k, 10))
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		err := json.NewEncoder(w).Encode(resp)
+		test.AssertNotError(t, err, "Failed to encode get-sth response")
+	})
+	for i := 0; i < 12; i++ {
+		srv := httptest.NewServer(m)
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+
+	results := pub.Healthcheck(ctx)
+	test.AssertEquals(t, len(results), 12)
+	for uri, err := range results {
+		test.AssertNotError(t, err, fmt.Sprintf("Healthy log at %s reported an error", uri))
+	}
+	test.Assert(t, maxInFlight <= 3, fmt.Sprintf("%d probes were made at once, the limit is 3", maxInFlight))
+
+	// Probes still waiting for a slot when the timeout passes aren't made
+	pub, _, k = setup(t)
+	pub.healthcheckConcurrency = 1
+	pub.healthcheckTimeout = 100 * time.Millisecond
+	hung := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-hung
+		}))
+		defer srv.Close()
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+	}
+	// Deferred after the servers so that their handlers return before the
+	// servers are closed
+	defer close(hung)
+	start := time.Now()
+	results = pub.Healthcheck(ctx)
+	test.Assert(t, time.Since(start) < time.Second, "Healthcheck didn't respect its timeout")
+	laggards := 0
+	for _, err := range results {
+		test.AssertError(t, err, "Unresponsive log reported healthy")
+		if err == context.DeadlineExceeded {
+			laggards++
+		}
+	}
+	test.AssertEquals(t, laggards, 2)
+}
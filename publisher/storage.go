@@ -0,0 +1,494 @@
+package publisher
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// SCTStorage stores the SCTs obtained by the publisher so that they can be
+// served in OCSP responses, and records failed submissions so that logs which
+// failed can be retried later. The SA backed storage keeps both durably, while
+// the in-memory storage used without an SA loses them on restart.
+type SCTStorage interface {
+	// Store keeps a single SCT per log for the certificate's serial. Logs may
+	// return a previously issued SCT when a certificate is resubmitted, so an
+	// SCT from a log that already has one stored replaces it only if it has an
+	// earlier timestamp. The SA backed storage relies on the SA's
+	// AddSCTReceipt doing the same.
+	Store(ctx context.Context, sct LogSCT) error
+	Load(ctx context.Context, serial string) ([]LogSCT, error)
+	// DeleteExpired removes the SCTs for certificates that expired before
+	// cutoff and returns the number of SCTs removed
+	DeleteExpired(ctx context.Context, cutoff time.Time) (int, error)
+	// Purge removes every SCT stored for the serial and returns the number of
+	// SCTs removed, which is zero for a serial without any. Failures recorded
+	// for the serial are removed as well.
+	Purge(ctx context.Context, serial string) (int, error)
+	// RecordAttempt records the outcome of submitting the certificate with the
+	// serial to the log at logURI. A failed attempt, with a non-nil err,
+	// replaces any failure recorded for the serial and log, and a successful
+	// one clears it.
+	RecordAttempt(ctx context.Context, serial, logURI string, err error, at time.Time) error
+	// Failures returns the failures currently recorded, sorted by serial and
+	// then log URI
+	Failures(ctx context.Context) ([]SubmissionFailure, error)
+}
+
+// SubmissionFailure is the last failed attempt to submit the certificate with
+// Serial to the log at LogURI, recorded until an attempt succeeds so that the
+// certificate can be resubmitted, e.g. with ResubmitMissing. Reason is the
+// error of the attempt.
+type SubmissionFailure struct {
+	Serial string
+	LogURI string
+	Reason string
+	At     time.Time
+}
+
+// deleteBatchSize is the number of certificates whose SCTs are checked for
+// expiry while holding the storage lock, so that deleting doesn't block
+// submissions for long, and the number of receipts the SA is asked to delete
+// at a time
+const deleteBatchSize = 1000
+
+// DeleteExpiredSCTs removes the stored SCTs for certificates that expired
+// before cutoff, returning the number of SCTs removed. It is safe to call
+// while certificates are being submitted.
+func (pub *Impl) DeleteExpiredSCTs(ctx context.Context, cutoff time.Time) (int, error) {
+	deleted, err := pub.storage.DeleteExpired(ctx, cutoff)
+	if err != nil {
+		return deleted, err
+	}
+	pub.log.Info(fmt.Sprintf("Deleted %d SCTs for certificates expired before %s", deleted, cutoff))
+	return deleted, nil
+}
+
+// PurgeSCTs removes every stored SCT for the certificate with the given
+// serial, e.g. when cleaning up after a misissuance, and returns the number of
+// SCTs removed. Purging a serial without stored SCTs isn't an error. Purges
+// are audit logged since they remove records otherwise kept until expiry.
+// Cached SCTs are discarded too, so that the purged SCTs aren't served from
+// the cache.
+func (pub *Impl) PurgeSCTs(ctx context.Context, serial string) (int, error) {
+	purged, err := pub.storage.Purge(ctx, serial)
+	pub.cache.clear()
+	if err != nil {
+		pub.log.AuditErr(fmt.Sprintf("Failed to purge stored SCTs for %s: %s", serial, err))
+		return purged, err
+	}
+	pub.log.AuditInfo(fmt.Sprintf("Purged %d stored SCTs for %s", purged, serial))
+	return purged, nil
+}
+
+// SubmissionFailures returns the failed submissions recorded for certificates
+// that no later submission to the same log has succeeded for, so that a retry
+// job can tell which logs to resubmit each certificate to and why they failed
+func (pub *Impl) SubmissionFailures(ctx context.Context) ([]SubmissionFailure, error) {
+	return pub.storage.Failures(ctx)
+}
+
+// recordAttempt records the outcome of a submission of cert to ctLog in the
+// storage, logging rather than returning any error since the submission
+// itself is unaffected
+func (pub *Impl) recordAttempt(ctx context.Context, cert *x509.Certificate, ctLog *Log, err error) {
+	serial := core.SerialToString(cert.SerialNumber)
+	if recordErr := pub.storage.RecordAttempt(ctx, serial, ctLog.uri, err, pub.clk.Now()); recordErr != nil {
+		pub.log.Warning(fmt.Sprintf("Failed to record submission of %s to CT log at %s: %s", serial, ctLog.uri, recordErr))
+	}
+}
+
+// SCTListForSerial assembles the SCT list extension for the certificate with
+// the given serial from its stored SCTs, e.g. when re-issuing OCSP responses,
+// without submitting the certificate again. The result is the value of an
+// extension with core.SCTListOID. ErrInsufficientSCTs is returned when fewer
+// SCTs from required logs are stored than the minimum SCT count or, without
+// one, than there are enabled required logs.
+func (pub *Impl) SCTListForSerial(ctx context.Context, serial string) ([]byte, error) {
+	stored, err := pub.storage.Load(ctx, serial)
+	if err != nil {
+		return nil, fmt.Errorf("loading stored SCTs for %s: %s", serial, err)
+	}
+	required := pub.minimumSCTCount
+	if required <= 0 {
+		for _, ctLog := range pub.enabledLogs() {
+			if !ctLog.bestEffort() {
+				required++
+			}
+		}
+	}
+	scts := make([]core.SignedCertificateTimestamp, len(stored))
+	have := 0
+	for i, sct := range stored {
+		scts[i] = sct.SignedCertificateTimestamp
+		if !sct.BestEffort {
+			have++
+		}
+	}
+	if len(stored) == 0 || have < required {
+		return nil, ErrInsufficientSCTs{Serial: serial, SCTs: have, Required: required}
+	}
+	return core.MarshalSCTList(scts)
+}
+
+// maxFailureRecords is the number of submission failures kept in memory, above
+// which the least recently recorded are dropped
+const maxFailureRecords = 10000
+
+// failureRecordTTL is how long a submission failure is kept after it was
+// recorded, so that the failures of certificates which are never resubmitted
+// don't accumulate
+const failureRecordTTL = 7 * 24 * time.Hour
+
+// failureRecords keeps the submission failures recorded by RecordAttempt in
+// memory, keyed by serial and then log URI. They are lost when the publisher
+// restarts, and only the maxFailureRecords most recently recorded within
+// failureRecordTTL are kept.
+type failureRecords struct {
+	clk clock.Clock
+
+	mu       sync.Mutex
+	failures map[string]map[string]*list.Element
+	// order has the most recently recorded failure at the front
+	order *list.List
+}
+
+func newFailureRecords(clk clock.Clock) *failureRecords {
+	return &failureRecords{
+		clk:      clk,
+		failures: make(map[string]map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (fr *failureRecords) RecordAttempt(_ context.Context, serial, logURI string, err error, at time.Time) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if elem, ok := fr.failures[serial][logURI]; ok {
+		fr.remove(elem)
+	}
+	if err == nil {
+		return nil
+	}
+	if fr.failures[serial] == nil {
+		fr.failures[serial] = make(map[string]*list.Element)
+	}
+	fr.failures[serial][logURI] = fr.order.PushFront(SubmissionFailure{Serial: serial, LogURI: logURI, Reason: err.Error(), At: at})
+	for fr.order.Len() > maxFailureRecords {
+		fr.remove(fr.order.Back())
+	}
+	fr.expire()
+	return nil
+}
+
+func (fr *failureRecords) Failures(_ context.Context) ([]SubmissionFailure, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.expire()
+	var failures []SubmissionFailure
+	for elem := fr.order.Front(); elem != nil; elem = elem.Next() {
+		failures = append(failures, elem.Value.(SubmissionFailure))
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Serial != failures[j].Serial {
+			return failures[i].Serial < failures[j].Serial
+		}
+		return failures[i].LogURI < failures[j].LogURI
+	})
+	return failures, nil
+}
+
+// expire drops the failures recorded more than failureRecordTTL ago. Failures
+// are recorded in order, so it stops at the oldest one that hasn't expired.
+// The caller must hold fr.mu.
+func (fr *failureRecords) expire() {
+	cutoff := fr.clk.Now().Add(-failureRecordTTL)
+	for elem := fr.order.Back(); elem != nil && elem.Value.(SubmissionFailure).At.Before(cutoff); elem = fr.order.Back() {
+		fr.remove(elem)
+	}
+}
+
+// remove drops the failure held by elem. The caller must hold fr.mu.
+func (fr *failureRecords) remove(elem *list.Element) {
+	failure := fr.order.Remove(elem).(SubmissionFailure)
+	delete(fr.failures[failure.Serial], failure.LogURI)
+	if len(fr.failures[failure.Serial]) == 0 {
+		delete(fr.failures, failure.Serial)
+	}
+}
+
+// purge removes the failures recorded for serial
+func (fr *failureRecords) purge(serial string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	for _, elem := range fr.failures[serial] {
+		fr.remove(elem)
+	}
+}
+
+// maxMemorySCTSerials is the number of certificates whose SCTs are kept by the
+// in-memory storage, above which the SCTs of the certificate least recently
+// stored for are dropped, so that a long running publisher without an SA
+// doesn't grow without bound
+const maxMemorySCTSerials = 10000
+
+// memorySCTStorage is an SCTStorage that keeps SCTs and submission failures in
+// memory. It is used when the publisher isn't given a storage authority, and
+// keeps the SCTs of at most maxMemorySCTSerials certificates.
+type memorySCTStorage struct {
+	*failureRecords
+
+	sync.RWMutex
+	scts map[string][]LogSCT
+	// order has the serial most recently stored for at the front, and serials
+	// holds its element for each serial in scts
+	order   *list.List
+	serials map[string]*list.Element
+}
+
+func newMemorySCTStorage(clk clock.Clock) *memorySCTStorage {
+	return &memorySCTStorage{
+		failureRecords: newFailureRecords(clk),
+		scts:           make(map[string][]LogSCT),
+		order:          list.New(),
+		serials:        make(map[string]*list.Element),
+	}
+}
+
+// Store keeps a single SCT per log for each serial, preferring the earliest
+// as described by SCTStorage.
+func (ms *memorySCTStorage) Store(_ context.Context, sct LogSCT) error {
+	ms.Lock()
+	defer ms.Unlock()
+	serial := sct.CertificateSerial
+	if elem, ok := ms.serials[serial]; ok {
+		ms.order.MoveToFront(elem)
+	} else {
+		ms.serials[serial] = ms.order.PushFront(serial)
+	}
+	stored := ms.scts[serial]
+	for i, existing := range stored {
+		if existing.LogID != sct.LogID {
+			continue
+		}
+		if sct.Timestamp < existing.Timestamp {
+			stored[i] = sct
+		}
+		return nil
+	}
+	ms.scts[serial] = append(stored, sct)
+	for ms.order.Len() > maxMemorySCTSerials {
+		ms.remove(ms.order.Back().Value.(string))
+	}
+	return nil
+}
+
+// remove drops the SCTs stored for serial. The caller must hold the lock.
+func (ms *memorySCTStorage) remove(serial string) {
+	if elem, ok := ms.serials[serial]; ok {
+		ms.order.Remove(elem)
+		delete(ms.serials, serial)
+	}
+	delete(ms.scts, serial)
+}
+
+func (ms *memorySCTStorage) Load(_ context.Context, serial string) ([]LogSCT, error) {
+	ms.RLock()
+	defer ms.RUnlock()
+	return append([]LogSCT(nil), ms.scts[serial]...), nil
+}
+
+// DeleteExpired removes expired SCTs in batches of deleteBatchSize serials,
+// releasing the lock between batches. SCTs stored for a serial while a delete
+// is in progress may not be considered until the next call.
+func (ms *memorySCTStorage) DeleteExpired(ctx context.Context, cutoff time.Time) (int, error) {
+	ms.RLock()
+	serials := make([]string, 0, len(ms.scts))
+	for serial := range ms.scts {
+		serials = append(serials, serial)
+	}
+	ms.RUnlock()
+
+	deleted := 0
+	for start := 0; start < len(serials); start += deleteBatchSize {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		end := start + deleteBatchSize
+		if end > len(serials) {
+			end = len(serials)
+		}
+		ms.Lock()
+		for _, serial := range serials[start:end] {
+			var kept []LogSCT
+			for _, sct := range ms.scts[serial] {
+				if sct.Expires.Before(cutoff) {
+					deleted++
+				} else {
+					kept = append(kept, sct)
+				}
+			}
+			if len(kept) == 0 {
+				ms.remove(serial)
+			} else {
+				ms.scts[serial] = kept
+			}
+		}
+		ms.Unlock()
+	}
+	return deleted, nil
+}
+
+// Purge removes the serial's SCTs and failures
+func (ms *memorySCTStorage) Purge(_ context.Context, serial string) (int, error) {
+	ms.failureRecords.purge(serial)
+	ms.Lock()
+	defer ms.Unlock()
+	purged := len(ms.scts[serial])
+	ms.remove(serial)
+	return purged, nil
+}
+
+// saSCTStorage is an SCTStorage backed by the SA's SCT receipts. The SA only
+// records the SCT itself, so the log URI of a loaded SCT is found by matching
+// its log ID against the configured logs and the submission time is not
+// available. Submission failures are recorded by the SA too, so that they
+// outlive the publisher and can be queried by a retry job.
+type saSCTStorage struct {
+	sa   core.StorageAuthority
+	log  blog.Logger
+	logs func() []*Log
+}
+
+// Store skips adding a receipt when the SA already has one from the same log
+// for the serial that is no later. The SA keeps the earliest receipt itself,
+// so checking first only saves the write, but an SA error other than the
+// receipt not being found is returned rather than taken to mean it's missing.
+func (ss saSCTStorage) Store(ctx context.Context, sct LogSCT) error {
+	existing, err := ss.sa.GetSCTReceipt(ctx, sct.CertificateSerial, sct.LogID)
+	if err == nil && existing.Timestamp <= sct.Timestamp {
+		ss.log.Debug(fmt.Sprintf("Skipping duplicate SCT receipt for %s from CT log at %s", sct.CertificateSerial, sct.LogURI))
+		return nil
+	}
+	if err != nil && !berrors.Is(err, berrors.NotFound) {
+		return fmt.Errorf("getting SCT receipt for %s from CT log at %s: %s", sct.CertificateSerial, sct.LogURI, err)
+	}
+	return ss.sa.AddSCTReceipt(ctx, sct.SignedCertificateTimestamp)
+}
+
+// Load looks up the receipt for the serial from each configured log. A log
+// the SA has no receipt from is skipped, while any other error is returned so
+// that an SA outage isn't mistaken for missing SCTs.
+func (ss saSCTStorage) Load(ctx context.Context, serial string) ([]LogSCT, error) {
+	var scts []LogSCT
+	for _, ctLog := range ss.logs() {
+		logID, err := receiptLogID(ctLog)
+		if err != nil {
+			return nil, err
+		}
+		sct, err := ss.sa.GetSCTReceipt(ctx, serial, logID)
+		if berrors.Is(err, berrors.NotFound) {
+			ss.log.Debug(fmt.Sprintf("No SCT receipt for %s from CT log at %s", serial, ctLog.uri))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting SCT receipt for %s from CT log at %s: %s", serial, ctLog.uri, err)
+		}
+		scts = append(scts, LogSCT{LogURI: ctLog.uri, Operator: ctLog.operator, BestEffort: ctLog.bestEffort(), SignedCertificateTimestamp: sct})
+	}
+	return scts, nil
+}
+
+// DeleteExpired asks the SA to delete the expired receipts deleteBatchSize at
+// a time, until a batch comes back short. Receipts for certificates the SA
+// doesn't have are never deleted.
+func (ss saSCTStorage) DeleteExpired(ctx context.Context, cutoff time.Time) (int, error) {
+	deleted := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		batch, err := ss.sa.DeleteExpiredSCTReceipts(ctx, cutoff, deleteBatchSize)
+		deleted += int(batch)
+		if err != nil {
+			return deleted, fmt.Errorf("deleting expired SCT receipts: %s", err)
+		}
+		if batch < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// Purge asks the SA to delete the receipts and submission failures recorded
+// for the serial
+func (ss saSCTStorage) Purge(ctx context.Context, serial string) (int, error) {
+	purged, err := ss.sa.PurgeSCTReceipts(ctx, serial)
+	if err != nil {
+		return int(purged), fmt.Errorf("purging SCT receipts: %s", err)
+	}
+	return int(purged), nil
+}
+
+// RecordAttempt asks the SA to record a failed attempt, replacing any failure
+// it has for the serial and log, or to delete the failure after a successful
+// one
+func (ss saSCTStorage) RecordAttempt(ctx context.Context, serial, logURI string, err error, at time.Time) error {
+	if err == nil {
+		if deleteErr := ss.sa.DeleteSCTSubmissionFailure(ctx, serial, logURI); deleteErr != nil {
+			return fmt.Errorf("deleting SCT submission failure: %s", deleteErr)
+		}
+		return nil
+	}
+	failure := core.SCTSubmissionFailure{
+		CertificateSerial: serial,
+		LogURI:            logURI,
+		Reason:            err.Error(),
+		AttemptedAt:       at,
+	}
+	if addErr := ss.sa.AddSCTSubmissionFailure(ctx, failure); addErr != nil {
+		return fmt.Errorf("recording SCT submission failure: %s", addErr)
+	}
+	return nil
+}
+
+// Failures returns the submission failures recorded by the SA, which sorts
+// them by serial and then log URI
+func (ss saSCTStorage) Failures(ctx context.Context) ([]SubmissionFailure, error) {
+	recorded, err := ss.sa.GetSCTSubmissionFailures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting SCT submission failures: %s", err)
+	}
+	failures := make([]SubmissionFailure, len(recorded))
+	for i, failure := range recorded {
+		failures[i] = SubmissionFailure{
+			Serial: failure.CertificateSerial,
+			LogURI: failure.LogURI,
+			Reason: failure.Reason,
+			At:     failure.AttemptedAt,
+		}
+	}
+	return failures, nil
+}
+
+// receiptLogID returns the log ID used by the SA to identify the log's SCT
+// receipts, the base64 encoded SHA-256 hash of the log's public key
+func receiptLogID(ctLog *Log) (string, error) {
+	keyDER, err := base64.StdEncoding.DecodeString(ctLog.logID)
+	if err != nil {
+		return "", fmt.Errorf("decoding public key of CT log at %s: %s", ctLog.uri, err)
+	}
+	keyHash := sha256.Sum256(keyDER)
+	return base64.StdEncoding.EncodeToString(keyHash[:]), nil
+}
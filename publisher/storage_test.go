@@ -0,0 +1,640 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/publisher/testlog"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// receiptSA is a mock SA that keeps SCT receipts and submission failures in
+// memory, along with the expiry of the certificates it has by serial
+type receiptSA struct {
+	*mocks.StorageAuthority
+	sync.Mutex
+	receipts    map[string]core.SignedCertificateTimestamp
+	failures    map[string]core.SCTSubmissionFailure
+	certExpires map[string]time.Time
+	deleteCalls int
+	// getErr, if set, is returned by GetSCTReceipt as if the SA was down
+	getErr error
+}
+
+func newReceiptSA() *receiptSA {
+	return &receiptSA{
+		StorageAuthority: mocks.NewStorageAuthority(clock.NewFake()),
+		receipts:         make(map[string]core.SignedCertificateTimestamp),
+		failures:         make(map[string]core.SCTSubmissionFailure),
+		certExpires:      make(map[string]time.Time),
+	}
+}
+
+func (sa *receiptSA) AddSCTReceipt(_ context.Context, sct core.SignedCertificateTimestamp) error {
+	sa.Lock()
+	defer sa.Unlock()
+	// Like the SA, keep the receipt with the earliest timestamp
+	key := sct.CertificateSerial + sct.LogID
+	if existing, present := sa.receipts[key]; present && existing.Timestamp <= sct.Timestamp {
+		return nil
+	}
+	sa.receipts[key] = sct
+	return nil
+}
+
+func (sa *receiptSA) GetSCTReceipt(_ context.Context, serial, logID string) (core.SignedCertificateTimestamp, error) {
+	sa.Lock()
+	defer sa.Unlock()
+	if sa.getErr != nil {
+		return core.SignedCertificateTimestamp{}, sa.getErr
+	}
+	sct, present := sa.receipts[serial+logID]
+	if !present {
+		return sct, berrors.NotFoundError("no receipt")
+	}
+	return sct, nil
+}
+
+func (sa *receiptSA) DeleteExpiredSCTReceipts(_ context.Context, cutoff time.Time, limit int) (int64, error) {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.deleteCalls++
+	var deleted int64
+	for key, sct := range sa.receipts {
+		if deleted == int64(limit) {
+			break
+		}
+		expires, present := sa.certExpires[sct.CertificateSerial]
+		if present && expires.Before(cutoff) {
+			delete(sa.receipts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (sa *receiptSA) PurgeSCTReceipts(_ context.Context, serial string) (int64, error) {
+	sa.Lock()
+	defer sa.Unlock()
+	var purged int64
+	for key, sct := range sa.receipts {
+		if sct.CertificateSerial == serial {
+			delete(sa.receipts, key)
+			purged++
+		}
+	}
+	for key, failure := range sa.failures {
+		if failure.CertificateSerial == serial {
+			delete(sa.failures, key)
+		}
+	}
+	return purged, nil
+}
+
+func (sa *receiptSA) AddSCTSubmissionFailure(_ context.Context, failure core.SCTSubmissionFailure) error {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.failures[failure.CertificateSerial+" "+failure.LogURI] = failure
+	return nil
+}
+
+func (sa *receiptSA) DeleteSCTSubmissionFailure(_ context.Context, serial, logURI string) error {
+	sa.Lock()
+	defer sa.Unlock()
+	delete(sa.failures, serial+" "+logURI)
+	return nil
+}
+
+func (sa *receiptSA) GetSCTSubmissionFailures(_ context.Context) ([]core.SCTSubmissionFailure, error) {
+	sa.Lock()
+	defer sa.Unlock()
+	var failures []core.SCTSubmissionFailure
+	for _, failure := range sa.failures {
+		failures = append(failures, failure)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].CertificateSerial != failures[j].CertificateSerial {
+			return failures[i].CertificateSerial < failures[j].CertificateSerial
+		}
+		return failures[i].LogURI < failures[j].LogURI
+	})
+	return failures, nil
+}
+
+func TestMemorySCTStorage(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	serial := core.SerialToString(leaf.SerialNumber)
+	stored, err := pub.storage.Load(ctx, serial)
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 0)
+
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	stored, err = pub.storage.Load(ctx, serial)
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 1)
+	test.AssertEquals(t, stored[0].LogURI, pub.ctLogs[0].uri)
+	test.AssertEquals(t, stored[0].CertificateSerial, serial)
+	test.Assert(t, !stored[0].Submitted.IsZero(), "Stored SCT has no submission time")
+	test.Assert(t, stored[0].Latency > 0, "Stored SCT has no submission latency")
+	timestamp := time.Unix(0, int64(stored[0].Timestamp)*int64(time.Millisecond))
+	test.Assert(t, stored[0].MergeDeadline.Equal(timestamp.Add(defaultMMD)), "Stored SCT doesn't have the log's merge deadline")
+	test.Assert(t, stored[0].Expires.Equal(leaf.NotAfter), "Stored SCT doesn't have the certificate's expiry")
+}
+
+func TestNilSAUsesMemoryStorage(t *testing.T) {
+	pub := New(cmd.CTConfig{}, nil, nil, nil, nil, nil, 0, log, metrics.NewNoopScope(), nil)
+	metered, ok := pub.storage.(meteredSCTStorage)
+	test.Assert(t, ok, "Publisher's storage isn't metered")
+	_, ok = metered.inner.(*memorySCTStorage)
+	test.Assert(t, ok, "Publisher without an SA didn't default to memory storage")
+}
+
+func TestSASCTStorage(t *testing.T) {
+	pub, leaf, k := setup(t)
+	sa := newReceiptSA()
+	pub.storage = saSCTStorage{sa: sa, log: log, logs: func() []*Log { return pub.ctLogs }}
+	srvA := logSrv(leaf.Raw, k)
+	defer srvA.Close()
+	srvB := errorLogSrv()
+	defer srvB.Close()
+	portA, err := getPort(srvA)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, portA, &k.PublicKey)
+	// Receipts are looked up by log key, so the failing log needs its own
+	portB, err := getPort(srvB)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, portB, &testKey(t).PublicKey)
+
+	err = pub.SubmitToCT(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission with a failing log didn't error")
+	stored, err := pub.storage.Load(ctx, core.SerialToString(leaf.SerialNumber))
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	// Only the log that succeeded should have a receipt
+	test.AssertEquals(t, len(stored), 1)
+	test.AssertEquals(t, stored[0].LogURI, pub.ctLogs[0].uri)
+
+	// An SA error isn't mistaken for the SCTs being missing
+	sa.getErr = errors.New("SA unavailable")
+	_, err = pub.storage.Load(ctx, core.SerialToString(leaf.SerialNumber))
+	test.AssertError(t, err, "Loading SCTs while the SA is down didn't error")
+	// Nor for a receipt not being stored yet
+	err = pub.storage.Store(ctx, stored[0])
+	test.AssertError(t, err, "Storing an SCT while the SA is down didn't error")
+}
+
+func TestMemorySCTStorageBounded(t *testing.T) {
+	storage := newMemorySCTStorage(clock.New())
+	for i := 0; i <= maxMemorySCTSerials; i++ {
+		err := storage.Store(ctx, LogSCT{SignedCertificateTimestamp: core.SignedCertificateTimestamp{
+			LogID:             "log",
+			CertificateSerial: fmt.Sprintf("%d", i),
+		}})
+		test.AssertNotError(t, err, "Failed to store SCT")
+		if i == 0 {
+			continue
+		}
+		// Storing for the first serial again keeps it the most recent
+		err = storage.Store(ctx, LogSCT{SignedCertificateTimestamp: core.SignedCertificateTimestamp{
+			LogID:             "log",
+			CertificateSerial: "0",
+		}})
+		test.AssertNotError(t, err, "Failed to store SCT")
+	}
+	test.AssertEquals(t, len(storage.scts), maxMemorySCTSerials)
+	test.AssertEquals(t, storage.order.Len(), maxMemorySCTSerials)
+	stored, err := storage.Load(ctx, "1")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 0)
+	stored, err = storage.Load(ctx, "0")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 1)
+
+	_, err = storage.Purge(ctx, "0")
+	test.AssertNotError(t, err, "Failed to purge SCTs")
+	test.AssertEquals(t, len(storage.serials), maxMemorySCTSerials-1)
+}
+
+func TestDeleteExpiredSCTs(t *testing.T) {
+	pub, _, _ := setup(t)
+	storage := newMemorySCTStorage(pub.clk)
+	pub.storage = storage
+	now := time.Now()
+	// More serials than fit in one batch, half of them expired
+	for i := 0; i < deleteBatchSize*2+1; i++ {
+		expires := now.Add(time.Hour)
+		if i%2 == 0 {
+			expires = now.Add(-time.Hour)
+		}
+		serial := fmt.Sprintf("%d", i)
+		for _, uri := range []string{"https://a.example.com", "https://b.example.com"} {
+			err := storage.Store(ctx, LogSCT{
+				LogURI:                     uri,
+				Expires:                    expires,
+				SignedCertificateTimestamp: core.SignedCertificateTimestamp{LogID: uri, CertificateSerial: serial},
+			})
+			test.AssertNotError(t, err, "Failed to store SCT")
+		}
+	}
+
+	deleted, err := pub.DeleteExpiredSCTs(ctx, now)
+	test.AssertNotError(t, err, "Failed to delete expired SCTs")
+	test.AssertEquals(t, deleted, (deleteBatchSize+1)*2)
+	test.AssertEquals(t, len(storage.scts), deleteBatchSize)
+	stored, err := storage.Load(ctx, "1")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 2)
+	stored, err = storage.Load(ctx, "0")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 0)
+
+	deleted, err = pub.DeleteExpiredSCTs(ctx, now)
+	test.AssertNotError(t, err, "Failed to delete expired SCTs")
+	test.AssertEquals(t, deleted, 0)
+
+}
+
+func TestSADeleteExpiredSCTs(t *testing.T) {
+	pub, _, _ := setup(t)
+	sa := newReceiptSA()
+	pub.storage = saSCTStorage{sa: sa, log: log, logs: func() []*Log { return pub.ctLogs }}
+	now := time.Now()
+	// More expired receipts than the SA is asked to delete at once
+	for i := 0; i < deleteBatchSize+1; i++ {
+		serial := fmt.Sprintf("expired %d", i)
+		sa.certExpires[serial] = now.Add(-time.Hour)
+		err := sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{LogID: "log", CertificateSerial: serial})
+		test.AssertNotError(t, err, "Failed to add SCT receipt")
+	}
+	sa.certExpires["valid"] = now.Add(time.Hour)
+	for _, serial := range []string{"valid", "no certificate"} {
+		err := sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{LogID: "log", CertificateSerial: serial})
+		test.AssertNotError(t, err, "Failed to add SCT receipt")
+	}
+
+	deleted, err := pub.DeleteExpiredSCTs(ctx, now)
+	test.AssertNotError(t, err, "Failed to delete expired SCTs")
+	test.AssertEquals(t, deleted, deleteBatchSize+1)
+	test.AssertEquals(t, sa.deleteCalls, 2)
+	test.AssertEquals(t, len(sa.receipts), 2)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = pub.DeleteExpiredSCTs(cancelled, now)
+	test.AssertError(t, err, "Deleting with a cancelled context didn't error")
+}
+
+func TestPurgeSCTs(t *testing.T) {
+	pub, _, _ := setup(t)
+	storage := newMemorySCTStorage(pub.clk)
+	pub.storage = storage
+	for _, serial := range []string{"purged", "kept"} {
+		for _, uri := range []string{"https://a.example.com", "https://b.example.com"} {
+			err := storage.Store(ctx, LogSCT{
+				LogURI:                     uri,
+				SignedCertificateTimestamp: core.SignedCertificateTimestamp{LogID: uri, CertificateSerial: serial},
+			})
+			test.AssertNotError(t, err, "Failed to store SCT")
+		}
+	}
+
+	purged, err := pub.PurgeSCTs(ctx, "purged")
+	test.AssertNotError(t, err, "Failed to purge SCTs")
+	test.AssertEquals(t, purged, 2)
+	stored, err := storage.Load(ctx, "purged")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 0)
+	stored, err = storage.Load(ctx, "kept")
+	test.AssertNotError(t, err, "Failed to load SCTs")
+	test.AssertEquals(t, len(stored), 2)
+	test.AssertEquals(t, len(log.GetAllMatching(`\[AUDIT\] Purged 2 stored SCTs for purged`)), 1)
+
+	// Unknown serials have nothing to purge
+	purged, err = pub.PurgeSCTs(ctx, "unknown")
+	test.AssertNotError(t, err, "Purging an unknown serial failed")
+	test.AssertEquals(t, purged, 0)
+
+}
+
+func TestSAPurgeSCTs(t *testing.T) {
+	pub, _, _ := setup(t)
+	sa := newReceiptSA()
+	storage := saSCTStorage{sa: sa, log: log, logs: func() []*Log { return pub.ctLogs }}
+	pub.storage = storage
+	for _, serial := range []string{"purged", "kept"} {
+		for _, logID := range []string{"a", "b"} {
+			err := sa.AddSCTReceipt(ctx, core.SignedCertificateTimestamp{LogID: logID, CertificateSerial: serial})
+			test.AssertNotError(t, err, "Failed to add SCT receipt")
+		}
+		err := storage.RecordAttempt(ctx, serial, "https://c.example.com", errors.New("log down"), time.Now())
+		test.AssertNotError(t, err, "Failed to record failure")
+	}
+
+	purged, err := pub.PurgeSCTs(ctx, "purged")
+	test.AssertNotError(t, err, "Failed to purge SCTs")
+	test.AssertEquals(t, purged, 2)
+	test.AssertEquals(t, len(sa.receipts), 2)
+	failures, err := storage.Failures(ctx)
+	test.AssertNotError(t, err, "Failed to get failures")
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].Serial, "kept")
+}
+
+func TestSCTListForSerial(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+	serial := core.SerialToString(leaf.SerialNumber)
+
+	_, err = pub.SCTListForSerial(ctx, serial)
+	test.AssertError(t, err, "SCT list was assembled without any stored SCTs")
+	_, ok := err.(ErrInsufficientSCTs)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrInsufficientSCTs, got %T", err))
+
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	list, err := pub.SCTListForSerial(ctx, serial)
+	test.AssertNotError(t, err, "Failed to assemble SCT list")
+	expected, err := core.MarshalSCTList([]core.SignedCertificateTimestamp{scts[0].SignedCertificateTimestamp})
+	test.AssertNotError(t, err, "Failed to marshal SCT list")
+	test.AssertByteEquals(t, list, expected)
+
+	// Without a minimum SCT count every log has to have provided an SCT
+	addLog(t, pub, port+1, &k.PublicKey)
+	_, err = pub.SCTListForSerial(ctx, serial)
+	insufficient, ok := err.(ErrInsufficientSCTs)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrInsufficientSCTs, got %T", err))
+	test.AssertEquals(t, insufficient.SCTs, 1)
+	test.AssertEquals(t, insufficient.Required, 2)
+
+	pub.minimumSCTCount = 1
+	_, err = pub.SCTListForSerial(ctx, serial)
+	test.AssertNotError(t, err, "SCT list with the minimum SCT count wasn't assembled")
+}
+
+func TestDeleteExpiredSCTsConcurrently(t *testing.T) {
+	storage := newMemorySCTStorage(clock.New())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				_ = storage.Store(ctx, LogSCT{
+					Expires:                    time.Now().Add(-time.Hour),
+					SignedCertificateTimestamp: core.SignedCertificateTimestamp{CertificateSerial: fmt.Sprintf("%d-%d", i, j)},
+				})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := storage.DeleteExpired(ctx, time.Now())
+			test.AssertNotError(t, err, "Failed to delete expired SCTs")
+		}()
+	}
+	wg.Wait()
+	_, err := storage.DeleteExpired(ctx, time.Now())
+	test.AssertNotError(t, err, "Failed to delete expired SCTs")
+	test.AssertEquals(t, len(storage.scts), 0)
+}
+
+func TestResubmitMissing(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	// Stored SCTs are told apart by log ID, so each log needs its own key
+	kB := testKey(t)
+	sctA, sctB := createSignedSCT(leaf.Raw, k), createSignedSCT(leaf.Raw, kB)
+	var hitsA, hitsB int32
+	var bUp int32
+	srvA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hitsA, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sctA)
+	}))
+	defer srvA.Close()
+	srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hitsB, 1)
+		if atomic.LoadInt32(&bUp) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sctB)
+	}))
+	defer srvB.Close()
+	portA, err := getPort(srvA)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, portA, &k.PublicKey)
+	portB, err := getPort(srvB)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, portB, &kB.PublicKey)
+
+	scts, err := pub.CollectSCTs(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission with a failing log didn't error")
+	test.AssertEquals(t, len(scts), 1)
+
+	// Only the log without a stored SCT should be submitted to again, and
+	// while it's still failing the stored SCT is returned with the error
+	scts, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertError(t, err, "Resubmission to a failing log didn't error")
+	failed, ok := err.(ErrSubmissionFailed)
+	test.Assert(t, ok, fmt.Sprintf("Expected ErrSubmissionFailed, got %T", err))
+	test.AssertEquals(t, failed.Logs, 2)
+	test.AssertEquals(t, len(failed.Failures), 1)
+	test.AssertEquals(t, len(scts), 1)
+	test.AssertEquals(t, atomic.LoadInt32(&hitsA), int32(1))
+	test.AssertEquals(t, atomic.LoadInt32(&hitsB), int32(2))
+
+	atomic.StoreInt32(&bUp, 1)
+	scts, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Resubmission failed")
+	test.AssertEquals(t, len(scts), 2)
+	test.AssertEquals(t, atomic.LoadInt32(&hitsB), int32(3))
+
+	// Once every log has provided an SCT, resubmitting doesn't contact any
+	scts, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Resubmission with every SCT stored failed")
+	test.AssertEquals(t, len(scts), 2)
+	test.AssertEquals(t, atomic.LoadInt32(&hitsA), int32(1))
+	test.AssertEquals(t, atomic.LoadInt32(&hitsB), int32(3))
+}
+
+func TestStoreDeduplicatesSCTs(t *testing.T) {
+	for _, name := range []string{"memory", "SA"} {
+		pub, leaf, k := setup(t)
+		if name == "memory" {
+			pub.storage = newMemorySCTStorage(pub.clk)
+		} else {
+			pub.storage = saSCTStorage{sa: newReceiptSA(), log: log, logs: func() []*Log { return pub.ctLogs }}
+		}
+		srv := logSrv(leaf.Raw, k)
+		port, err := getPort(srv)
+		test.AssertNotError(t, err, "Failed to get test server port")
+		addLog(t, pub, port, &k.PublicKey)
+
+		// The log returns the same SCT for both submissions
+		for i := 0; i < 2; i++ {
+			err = pub.SubmitToCT(ctx, leaf.Raw)
+			test.AssertNotError(t, err, "Certificate submission failed")
+		}
+		srv.Close()
+		serial := core.SerialToString(leaf.SerialNumber)
+		stored, err := pub.storage.Load(ctx, serial)
+		test.AssertNotError(t, err, "Failed to load SCTs")
+		test.AssertEquals(t, len(stored), 1)
+
+		// A later SCT from the same log is skipped, an earlier one replaces it
+		later := stored[0]
+		later.Timestamp++
+		err = pub.storage.Store(ctx, later)
+		test.AssertNotError(t, err, "Failed to store SCT")
+		stored, err = pub.storage.Load(ctx, serial)
+		test.AssertNotError(t, err, "Failed to load SCTs")
+		test.AssertEquals(t, len(stored), 1)
+		test.AssertEquals(t, stored[0].Timestamp, later.Timestamp-1)
+		earlier := stored[0]
+		earlier.Timestamp--
+		err = pub.storage.Store(ctx, earlier)
+		test.AssertNotError(t, err, "Failed to store SCT")
+		stored, err = pub.storage.Load(ctx, serial)
+		test.AssertNotError(t, err, "Failed to load SCTs")
+		test.AssertEquals(t, len(stored), 1)
+		test.AssertEquals(t, stored[0].Timestamp, earlier.Timestamp)
+	}
+}
+
+func TestSubmissionFailures(t *testing.T) {
+	pub, leaf, _ := setup(t)
+	pub.storage = newMemorySCTStorage(pub.clk)
+	pub.submissionTimeout = 100 * time.Millisecond
+	var testLogs []*testlog.Log
+	for i := 0; i < 2; i++ {
+		l, err := testlog.New()
+		test.AssertNotError(t, err, "Failed to start test log")
+		defer l.Close()
+		ctLog, err := NewLog(l.Description(), pub.client, log)
+		test.AssertNotError(t, err, "Couldn't create log")
+		pub.ctLogs = append(pub.ctLogs, ctLog)
+		testLogs = append(testLogs, l)
+	}
+	serial := core.SerialToString(leaf.SerialNumber)
+
+	testLogs[1].SetFailureMode(testlog.Unavailable)
+	_, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission to unavailable log succeeded")
+	failures, err := pub.SubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].Serial, serial)
+	test.AssertEquals(t, failures[0].LogURI, pub.ctLogs[1].uri)
+	test.AssertContains(t, failures[0].Reason, "gave up submitting to CT log")
+
+	// Once the log recovers a successful submission clears the failure
+	testLogs[1].SetFailureMode(testlog.Healthy)
+	_, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Resubmission to recovered log failed")
+	failures, err = pub.SubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 0)
+
+	// Failures are sorted, and purged along with the serial's SCTs
+	storage := newMemorySCTStorage(pub.clk)
+	for _, serial := range []string{"b", "a"} {
+		for _, uri := range []string{"https://b.example.com", "https://a.example.com"} {
+			err := storage.RecordAttempt(ctx, serial, uri, errors.New("failed"), time.Now())
+			test.AssertNotError(t, err, "Failed to record attempt")
+		}
+	}
+	_, err = storage.Purge(ctx, "b")
+	test.AssertNotError(t, err, "Failed to purge serial")
+	failures, err = storage.Failures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 2)
+	test.AssertEquals(t, failures[0].LogURI, "https://a.example.com")
+	test.AssertEquals(t, failures[1].LogURI, "https://b.example.com")
+	test.AssertEquals(t, failures[1].Serial, "a")
+}
+
+func TestFailureRecordsBounded(t *testing.T) {
+	fc := clock.NewFake()
+	records := newFailureRecords(fc)
+	for i := 0; i < maxFailureRecords+1; i++ {
+		err := records.RecordAttempt(ctx, fmt.Sprintf("%d", i), "https://a.example.com", errors.New("failed"), fc.Now())
+		test.AssertNotError(t, err, "Failed to record attempt")
+	}
+	failures, err := records.Failures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), maxFailureRecords)
+	// The least recently recorded failure is the one dropped
+	_, ok := records.failures["0"]
+	test.Assert(t, !ok, "Oldest failure was kept above the limit")
+
+	// Failures expire once failureRecordTTL has passed since they were recorded
+	fc.Add(failureRecordTTL / 2)
+	err = records.RecordAttempt(ctx, "recent", "https://a.example.com", errors.New("failed"), fc.Now())
+	test.AssertNotError(t, err, "Failed to record attempt")
+	fc.Add(failureRecordTTL/2 + time.Second)
+	failures, err = records.Failures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].Serial, "recent")
+	test.AssertEquals(t, len(records.failures), 1)
+	test.AssertEquals(t, records.order.Len(), 1)
+}
+
+func TestSASubmissionFailures(t *testing.T) {
+	pub, leaf, _ := setup(t)
+	sa := newReceiptSA()
+	pub.storage = saSCTStorage{sa: sa, log: log, logs: func() []*Log { return pub.ctLogs }}
+	pub.submissionTimeout = 100 * time.Millisecond
+	l, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer l.Close()
+	ctLog, err := NewLog(l.Description(), pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+	serial := core.SerialToString(leaf.SerialNumber)
+
+	// The failure is recorded by the SA, so it outlives the publisher
+	l.SetFailureMode(testlog.Unavailable)
+	_, err = pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertError(t, err, "Submission to unavailable log succeeded")
+	test.AssertEquals(t, len(sa.failures), 1)
+	failures, err := pub.SubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get submission failures")
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].Serial, serial)
+	test.AssertEquals(t, failures[0].LogURI, ctLog.uri)
+	test.AssertContains(t, failures[0].Reason, "gave up submitting to CT log")
+
+	l.SetFailureMode(testlog.Healthy)
+	_, err = pub.ResubmitMissing(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Resubmission to recovered log failed")
+	test.AssertEquals(t, len(sa.failures), 0)
+}
@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+)
+
+// meteredSCTStorage wraps the publisher's SCTStorage to count each operation
+// by whether it succeeded or failed and to time it, so that slow or failing
+// storage can be told apart from problems with the logs
+type meteredSCTStorage struct {
+	inner   SCTStorage
+	clk     clock.Clock
+	ops     *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+func newMeteredSCTStorage(inner SCTStorage, clk clock.Clock, m *pubMetrics) meteredSCTStorage {
+	return meteredSCTStorage{
+		inner:   inner,
+		clk:     clk,
+		ops:     m.storageOps,
+		latency: m.storageLatency,
+	}
+}
+
+// observe records an operation that started at start and returned err
+func (s meteredSCTStorage) observe(operation string, start time.Time, err error) {
+	result := "succeeded"
+	if err != nil {
+		result = "failed"
+	}
+	s.ops.With(prometheus.Labels{"operation": operation, "result": result}).Inc()
+	s.latency.With(prometheus.Labels{"operation": operation}).Observe(s.clk.Since(start).Seconds())
+}
+
+func (s meteredSCTStorage) Store(ctx context.Context, sct LogSCT) error {
+	start := s.clk.Now()
+	err := s.inner.Store(ctx, sct)
+	s.observe("store", start, err)
+	return err
+}
+
+func (s meteredSCTStorage) Load(ctx context.Context, serial string) ([]LogSCT, error) {
+	start := s.clk.Now()
+	scts, err := s.inner.Load(ctx, serial)
+	s.observe("load", start, err)
+	return scts, err
+}
+
+func (s meteredSCTStorage) DeleteExpired(ctx context.Context, cutoff time.Time) (int, error) {
+	start := s.clk.Now()
+	deleted, err := s.inner.DeleteExpired(ctx, cutoff)
+	s.observe("delete_expired", start, err)
+	return deleted, err
+}
+
+func (s meteredSCTStorage) Purge(ctx context.Context, serial string) (int, error) {
+	start := s.clk.Now()
+	purged, err := s.inner.Purge(ctx, serial)
+	s.observe("purge", start, err)
+	return purged, err
+}
+
+func (s meteredSCTStorage) RecordAttempt(ctx context.Context, serial, logURI string, err error, at time.Time) error {
+	start := s.clk.Now()
+	recordErr := s.inner.RecordAttempt(ctx, serial, logURI, err, at)
+	s.observe("record_attempt", start, recordErr)
+	return recordErr
+}
+
+func (s meteredSCTStorage) Failures(ctx context.Context) ([]SubmissionFailure, error) {
+	start := s.clk.Now()
+	failures, err := s.inner.Failures(ctx)
+	s.observe("failures", start, err)
+	return failures, err
+}
@@ -0,0 +1,56 @@
+package publisher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+// brokenPurgeStorage is memory storage whose purges always fail
+type brokenPurgeStorage struct {
+	*memorySCTStorage
+}
+
+func (brokenPurgeStorage) Purge(ctx context.Context, serial string) (int, error) {
+	return 0, errors.New("storage unavailable")
+}
+
+func storageLatencyCount(t *testing.T, pub *Impl, operation string) int {
+	ch := make(chan prometheus.Metric, 1)
+	pub.metrics.storageLatency.With(prometheus.Labels{"operation": operation}).Collect(ch)
+	var m io_prometheus_client.Metric
+	test.AssertNotError(t, (<-ch).Write(&m), "Failed to read histogram")
+	return int(m.Histogram.GetSampleCount())
+}
+
+func TestMeteredSCTStorage(t *testing.T) {
+	pub, leaf, k := setup(t)
+	pub.storage = newMeteredSCTStorage(brokenPurgeStorage{newMemorySCTStorage(pub.clk)}, pub.clk, pub.metrics)
+	srv := logSrv(leaf.Raw, k)
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	_, err = pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Certificate submission failed")
+	serial := leaf.SerialNumber.String()
+	_, err = pub.storage.Load(ctx, serial)
+	test.AssertNotError(t, err, "Failed to load stored SCTs")
+	_, err = pub.PurgeSCTs(ctx, serial)
+	test.AssertError(t, err, "Purge with broken storage succeeded")
+
+	ops := pub.metrics.storageOps
+	test.AssertEquals(t, count(prometheus.Labels{"operation": "store", "result": "succeeded"}, ops), 1)
+	test.AssertEquals(t, count(prometheus.Labels{"operation": "record_attempt", "result": "succeeded"}, ops), 1)
+	test.AssertEquals(t, count(prometheus.Labels{"operation": "load", "result": "succeeded"}, ops), 1)
+	test.AssertEquals(t, count(prometheus.Labels{"operation": "purge", "result": "succeeded"}, ops), 0)
+	test.AssertEquals(t, count(prometheus.Labels{"operation": "purge", "result": "failed"}, ops), 1)
+	test.AssertEquals(t, storageLatencyCount(t, pub, "store"), 1)
+	test.AssertEquals(t, storageLatencyCount(t, pub, "purge"), 1)
+}
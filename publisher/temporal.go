@@ -0,0 +1,39 @@
+package publisher
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// covers returns whether ctLog accepts certificates expiring at notAfter, which
+// is always the case for a log that isn't temporally sharded
+func (ctLog *Log) covers(notAfter time.Time) bool {
+	if ctLog.temporal == nil {
+		return true
+	}
+	return !notAfter.Before(ctLog.temporal.StartInclusive) && notAfter.Before(ctLog.temporal.EndExclusive)
+}
+
+// logsFor returns the logs, in the same order, whose temporal shards accept
+// cert, so that a certificate is only submitted to the shard of each sharded
+// log that covers its NotAfter. The skipped shards are counted in the
+// submissions metric as skipped. ErrNoTemporalShard is returned if none of the
+// logs accept cert.
+func (pub *Impl) logsFor(cert *x509.Certificate, logs []*Log) ([]*Log, error) {
+	var covering []*Log
+	for _, ctLog := range logs {
+		if !ctLog.covers(cert.NotAfter) {
+			pub.metrics.submissions.With(prometheus.Labels{"log": ctLog.uri, "result": "skipped"}).Inc()
+			continue
+		}
+		covering = append(covering, ctLog)
+	}
+	if len(covering) == 0 && len(logs) > 0 {
+		return nil, ErrNoTemporalShard{Serial: core.SerialToString(cert.SerialNumber), NotAfter: cert.NotAfter}
+	}
+	return covering, nil
+}
@@ -0,0 +1,79 @@
+package publisher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/publisher/testlog"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestTemporalShards(t *testing.T) {
+	pub, leaf, _ := setup(t)
+
+	// Three yearly shards of one log, only the second of which covers the
+	// certificate, and a log that isn't sharded
+	year := 365 * 24 * time.Hour
+	start := leaf.NotAfter.Add(-year - time.Hour)
+	var shards []*testlog.Log
+	for i := 0; i < 3; i++ {
+		l, err := testlog.New()
+		test.AssertNotError(t, err, "Failed to start test log")
+		defer l.Close()
+		ld := l.Description()
+		ld.TemporalInterval = &cmd.TemporalInterval{
+			StartInclusive: start.Add(time.Duration(i) * year),
+			EndExclusive:   start.Add(time.Duration(i+1) * year),
+		}
+		ctLog, err := NewLog(ld, pub.client, log)
+		test.AssertNotError(t, err, "Couldn't create temporal shard")
+		pub.ctLogs = append(pub.ctLogs, ctLog)
+		shards = append(shards, l)
+	}
+	unsharded, err := testlog.New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer unsharded.Close()
+	ctLog, err := NewLog(unsharded.Description(), pub.client, log)
+	test.AssertNotError(t, err, "Couldn't create log")
+	pub.ctLogs = append(pub.ctLogs, ctLog)
+
+	report, err := pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertNotError(t, err, "Submission to covering shard failed")
+	test.AssertEquals(t, len(report.SCTs), 2)
+	test.AssertEquals(t, shards[0].Submissions(), 0)
+	test.AssertEquals(t, shards[1].Submissions(), 1)
+	test.AssertEquals(t, shards[2].Submissions(), 0)
+	test.AssertEquals(t, unsharded.Submissions(), 1)
+
+	// Without the unsharded log or the covering shard the certificate has
+	// nowhere to go
+	pub.ctLogs = []*Log{pub.ctLogs[0], pub.ctLogs[2]}
+	_, err = pub.SubmitToCTDetailed(ctx, leaf.Raw)
+	test.AssertEquals(t, err, error(ErrNoTemporalShard{Serial: core.SerialToString(leaf.SerialNumber), NotAfter: leaf.NotAfter}))
+	test.AssertEquals(t, len(log.GetAllMatching(fmt.Sprintf("no CT log has a temporal shard covering certificate %s",
+		core.SerialToString(leaf.SerialNumber)))), 1)
+	test.AssertEquals(t, shards[0].Submissions()+shards[2].Submissions(), 0)
+}
+
+func TestTemporalIntervalValidation(t *testing.T) {
+	now := time.Now()
+	_, err := NewLog(cmd.LogDescription{
+		URI:                       "https://ct.example.com",
+		SkipSignatureVerification: true,
+		TemporalInterval:          &cmd.TemporalInterval{StartInclusive: now, EndExclusive: now},
+	}, nil, log)
+	test.AssertEquals(t, err.Error(), "CT log at https://ct.example.com has a temporal interval that doesn't end after it starts")
+
+	ctLog, err := NewLog(cmd.LogDescription{
+		URI:                       "https://ct.example.com",
+		SkipSignatureVerification: true,
+		TemporalInterval:          &cmd.TemporalInterval{StartInclusive: now, EndExclusive: now.Add(time.Hour)},
+	}, nil, log)
+	test.AssertNotError(t, err, "Couldn't create temporal shard")
+	test.Assert(t, ctLog.covers(now), "Shard doesn't cover its start")
+	test.Assert(t, !ctLog.covers(now.Add(time.Hour)), "Shard covers its end")
+	test.Assert(t, !ctLog.covers(now.Add(-time.Second)), "Shard covers a time before its start")
+}
@@ -0,0 +1,213 @@
+// Package testlog provides a CT log for tests: an httptest server that
+// implements the add-chain endpoint and signs real SCTs with a generated
+// ECDSA key, so that the publisher can verify them end-to-end. The log can
+// be made to fail in the ways real logs do, see FailureMode.
+package testlog
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// FailureMode is how a Log responds to submissions
+type FailureMode int
+
+const (
+	// Healthy logs return a valid SCT for every submission
+	Healthy FailureMode = iota
+	// Timeout logs never respond, the request is held until the client gives
+	// up or the log is closed
+	Timeout
+	// Unavailable logs respond with HTTP status 503
+	Unavailable
+	// BadSignature logs return SCTs whose signatures don't verify against the
+	// log's key
+	BadSignature
+)
+
+// Log is a CT log served by an httptest server. Its methods are safe for
+// concurrent use.
+type Log struct {
+	// Key is the log's signing key
+	Key *ecdsa.PrivateKey
+
+	server  *httptest.Server
+	keyDER  []byte
+	logID   [sha256.Size]byte
+	closing chan struct{}
+
+	mu          sync.Mutex
+	mode        FailureMode
+	submissions int
+}
+
+// New generates a signing key and starts a healthy Log. The caller must Close
+// it.
+func New() (*Log, error) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{
+		Key:     k,
+		keyDER:  keyDER,
+		logID:   sha256.Sum256(keyDER),
+		closing: make(chan struct{}),
+	}
+	m := http.NewServeMux()
+	m.HandleFunc("/ct"+ct.AddChainPath, l.addChain)
+	l.server = httptest.NewServer(m)
+	return l, nil
+}
+
+// Close releases any requests held by the Timeout failure mode and shuts the
+// log's server down
+func (l *Log) Close() {
+	close(l.closing)
+	l.server.Close()
+}
+
+// URI is the log's URI, as configured in cmd.LogDescription
+func (l *Log) URI() string {
+	return l.server.URL + "/ct"
+}
+
+// PublicKey is the base64 DER encoding of the log's public key, as configured
+// in cmd.LogDescription
+func (l *Log) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(l.keyDER)
+}
+
+// LogID is the ID of the log, the SHA-256 hash of its public key
+func (l *Log) LogID() [sha256.Size]byte {
+	return l.logID
+}
+
+// Description returns the configuration of the log for the publisher
+func (l *Log) Description() cmd.LogDescription {
+	return cmd.LogDescription{URI: l.URI(), Key: l.PublicKey()}
+}
+
+// SetFailureMode changes how the log responds to subsequent submissions
+func (l *Log) SetFailureMode(mode FailureMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.mode = mode
+}
+
+// Submissions returns the number of submissions the log has received,
+// including those it failed
+func (l *Log) Submissions() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.submissions
+}
+
+func (l *Log) addChain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.NotFound(w, r)
+		return
+	}
+	l.mu.Lock()
+	l.submissions++
+	mode := l.mode
+	l.mu.Unlock()
+
+	switch mode {
+	case Timeout:
+		select {
+		case <-r.Context().Done():
+		case <-l.closing:
+		}
+		return
+	case Unavailable:
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ct.AddChainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Chain) == 0 {
+		http.Error(w, "empty chain", http.StatusBadRequest)
+		return
+	}
+	resp, err := l.sign(req.Chain[0], mode == BadSignature)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// sign returns an add-chain response with an SCT for leaf. When corrupt is
+// set the signature is made over different data, so that it is well formed
+// but doesn't verify.
+func (l *Log) sign(leaf []byte, corrupt bool) (*ct.AddChainResponse, error) {
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: l.logID},
+		// The publisher rejects SCTs with timestamps far from the current time
+		Timestamp: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+	serialized, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{
+		Leaf: *ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: leaf}, sct.Timestamp),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if corrupt {
+		serialized = append(serialized, 0)
+	}
+	hashed := sha256.Sum256(serialized)
+	var ecdsaSig struct {
+		R, S *big.Int
+	}
+	ecdsaSig.R, ecdsaSig.S, err = ecdsa.Sign(rand.Reader, l.Key, hashed[:])
+	if err != nil {
+		return nil, err
+	}
+	sig, err := asn1.Marshal(ecdsaSig)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := ctTLS.Marshal(ct.DigitallySigned{
+		Algorithm: ctTLS.SignatureAndHashAlgorithm{
+			Hash:      ctTLS.SHA256,
+			Signature: ctTLS.ECDSA,
+		},
+		Signature: sig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ct.AddChainResponse{
+		SCTVersion: sct.SCTVersion,
+		ID:         l.logID[:],
+		Timestamp:  sct.Timestamp,
+		Signature:  ds,
+	}, nil
+}
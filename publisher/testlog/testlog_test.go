@@ -0,0 +1,78 @@
+package testlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctTLS "github.com/google/certificate-transparency-go/tls"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func addChain(t *testing.T, l *Log, leaf []byte) *http.Response {
+	body, err := json.Marshal(ct.AddChainRequest{Chain: [][]byte{leaf}})
+	test.AssertNotError(t, err, "Failed to marshal add-chain request")
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	resp, err := client.Post(l.URI()+ct.AddChainPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+func verifySCT(t *testing.T, l *Log, leaf []byte, resp *http.Response) error {
+	defer resp.Body.Close()
+	test.AssertEquals(t, resp.StatusCode, http.StatusOK)
+	var acr ct.AddChainResponse
+	err := json.NewDecoder(resp.Body).Decode(&acr)
+	test.AssertNotError(t, err, "Failed to decode add-chain response")
+	logID := l.LogID()
+	test.AssertByteEquals(t, acr.ID, logID[:])
+	var ds ct.DigitallySigned
+	_, err = ctTLS.Unmarshal(acr.Signature, &ds)
+	test.AssertNotError(t, err, "Failed to unmarshal SCT signature")
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: acr.SCTVersion,
+		LogID:      ct.LogID{KeyID: logID},
+		Timestamp:  acr.Timestamp,
+		Signature:  ds,
+	}
+	verifier, err := ct.NewSignatureVerifier(&l.Key.PublicKey)
+	test.AssertNotError(t, err, "Failed to create signature verifier")
+	return verifier.VerifySCTSignature(sct, ct.LogEntry{
+		Leaf: *ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: leaf}, sct.Timestamp),
+	})
+}
+
+func TestLog(t *testing.T) {
+	l, err := New()
+	test.AssertNotError(t, err, "Failed to start test log")
+	defer l.Close()
+	leaf := []byte("leaf")
+
+	err = verifySCT(t, l, leaf, addChain(t, l, leaf))
+	test.AssertNotError(t, err, "SCT from healthy log didn't verify")
+
+	l.SetFailureMode(BadSignature)
+	err = verifySCT(t, l, leaf, addChain(t, l, leaf))
+	test.AssertError(t, err, "SCT with bad signature verified")
+
+	l.SetFailureMode(Unavailable)
+	resp := addChain(t, l, leaf)
+	test.Assert(t, resp != nil, "Unavailable log didn't respond")
+	resp.Body.Close()
+	test.AssertEquals(t, resp.StatusCode, http.StatusServiceUnavailable)
+
+	l.SetFailureMode(Timeout)
+	test.Assert(t, addChain(t, l, leaf) == nil, "Timeout log responded")
+
+	test.AssertEquals(t, l.Submissions(), 4)
+	test.AssertEquals(t, l.Description().URI, l.URI())
+	test.AssertEquals(t, l.Description().Key, l.PublicKey())
+	test.AssertEquals(t, l.URI(), fmt.Sprintf("%s/ct", l.server.URL))
+}
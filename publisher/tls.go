@@ -0,0 +1,58 @@
+package publisher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// tlsVersions are the values accepted for CTConfig.MinTLSVersion
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
+// logTLSConfig returns the TLS configuration for connections to the logs in
+// config, or nil if config doesn't change the defaults
+func logTLSConfig(config cmd.CTConfig) (*tls.Config, error) {
+	if config.MinTLSVersion == "" && config.LogCACertFile == "" &&
+		config.ClientCertFile == "" && config.ClientKeyFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{}
+	if config.MinTLSVersion != "" {
+		version, ok := tlsVersions[config.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", config.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if config.LogCACertFile != "" {
+		caCertBytes, err := ioutil.ReadFile(config.LogCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CT log CA certs from %q: %s", config.LogCACertFile, err)
+		}
+		rootCAs := x509.NewCertPool()
+		if ok := rootCAs.AppendCertsFromPEM(caCertBytes); !ok {
+			return nil, fmt.Errorf("parsing CT log CA certs from %s failed", config.LogCACertFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		if config.ClientCertFile == "" || config.ClientKeyFile == "" {
+			return nil, fmt.Errorf("both or neither of ClientCertFile and ClientKeyFile must be set")
+		}
+		// LoadX509KeyPair also checks that the key matches the certificate
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading CT log client certificate from %q and key from %q: %s",
+				config.ClientCertFile, config.ClientKeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
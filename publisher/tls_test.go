@@ -0,0 +1,124 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestLogTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile, err := ioutil.TempFile("", "ct-log-ca")
+	test.AssertNotError(t, err, "Failed to create CA file")
+	defer func() { _ = os.Remove(caFile.Name()) }()
+	err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: srv.TLS.Certificates[0].Certificate[0]})
+	test.AssertNotError(t, err, "Failed to write CA file")
+	_ = caFile.Close()
+
+	// The test server's certificate is only trusted with the CA file
+	client, err := NewHTTPClient(cmd.CTConfig{})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	_, err = client.Get(srv.URL)
+	test.AssertError(t, err, "Request to a server with an untrusted certificate succeeded")
+
+	client, err = NewHTTPClient(cmd.CTConfig{
+		LogCACertFile: caFile.Name(),
+		MinTLSVersion: "1.2",
+		ForceHTTP2:    true,
+	})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	transport := client.Transport.(userAgentTransport).inner.(limitTransport).inner.(*http.Transport)
+	test.AssertEquals(t, transport.TLSClientConfig.MinVersion, uint16(tls.VersionTLS12))
+	_, h2 := transport.TLSNextProto["h2"]
+	test.Assert(t, h2, "HTTP/2 wasn't configured")
+	resp, err := client.Get(srv.URL)
+	test.AssertNotError(t, err, "Request to a server with a certificate from the configured CAs failed")
+	_ = resp.Body.Close()
+
+	_, err = NewHTTPClient(cmd.CTConfig{MinTLSVersion: "1.5"})
+	test.AssertError(t, err, "Unsupported TLS version was accepted")
+	_, err = NewHTTPClient(cmd.CTConfig{LogCACertFile: "/does/not/exist"})
+	test.AssertError(t, err, "Missing CA file was accepted")
+}
+
+// writeClientCert writes a self-signed certificate and its key to PEM files,
+// returning their names
+func writeClientCert(t *testing.T) (string, string) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &k.PublicKey, k)
+	test.AssertNotError(t, err, "Failed to create client certificate")
+	keyDER, err := x509.MarshalECPrivateKey(k)
+	test.AssertNotError(t, err, "Failed to marshal client key")
+
+	var names []string
+	for _, block := range []*pem.Block{{Type: "CERTIFICATE", Bytes: der}, {Type: "EC PRIVATE KEY", Bytes: keyDER}} {
+		f, err := ioutil.TempFile("", "ct-log-client")
+		test.AssertNotError(t, err, "Failed to create temp file")
+		test.AssertNotError(t, pem.Encode(f, block), "Failed to write PEM")
+		_ = f.Close()
+		names = append(names, f.Name())
+	}
+	return names[0], names[1]
+}
+
+func TestClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+	caFile, err := ioutil.TempFile("", "ct-log-ca")
+	test.AssertNotError(t, err, "Failed to create CA file")
+	defer func() { _ = os.Remove(caFile.Name()) }()
+	err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: srv.TLS.Certificates[0].Certificate[0]})
+	test.AssertNotError(t, err, "Failed to write CA file")
+	_ = caFile.Close()
+	certFile, keyFile := writeClientCert(t)
+	defer func() { _ = os.Remove(certFile) }()
+	defer func() { _ = os.Remove(keyFile) }()
+
+	client, err := NewHTTPClient(cmd.CTConfig{LogCACertFile: caFile.Name()})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	_, err = client.Get(srv.URL)
+	test.AssertError(t, err, "Request without a client certificate succeeded")
+
+	client, err = NewHTTPClient(cmd.CTConfig{
+		LogCACertFile:  caFile.Name(),
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	resp, err := client.Get(srv.URL)
+	test.AssertNotError(t, err, "Request with a client certificate failed")
+	_ = resp.Body.Close()
+
+	// The key has to match the certificate
+	_, otherKeyFile := writeClientCert(t)
+	defer func() { _ = os.Remove(otherKeyFile) }()
+	_, err = NewHTTPClient(cmd.CTConfig{ClientCertFile: certFile, ClientKeyFile: otherKeyFile})
+	test.AssertError(t, err, "Mismatched client key was accepted")
+	_, err = NewHTTPClient(cmd.CTConfig{ClientCertFile: certFile})
+	test.AssertError(t, err, "Client certificate without a key was accepted")
+	_, err = NewHTTPClient(cmd.CTConfig{ClientCertFile: "/does/not/exist", ClientKeyFile: keyFile})
+	test.AssertError(t, err, "Missing client certificate was accepted")
+}
@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"net/http"
+
+	"github.com/letsencrypt/boulder/core"
+)
+
+// defaultUserAgent identifies the publisher's requests to CT logs when no
+// User-Agent is configured
+func defaultUserAgent() string {
+	return "boulder-publisher/" + core.GetBuildID()
+}
+
+// userAgentTransport is an http.RoundTripper that sets the User-Agent of
+// every request, so that log operators can tell who is sending the traffic.
+// When sendRequestID is set, requests made for a submission with a request
+// ID, see WithRequestID, carry it in an X-Request-ID header as well.
+type userAgentTransport struct {
+	inner         http.RoundTripper
+	userAgent     string
+	sendRequestID bool
+}
+
+func (ut userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper mustn't modify the request it is given, so the header is
+	// set on a copy
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+2)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("User-Agent", ut.userAgent)
+	if id := RequestID(req.Context()); ut.sendRequestID && id != "" {
+		r.Header.Set(requestIDHeader, id)
+	}
+	return ut.inner.RoundTrip(r)
+}
+
+// CloseIdleConnections closes the idle connections of the inner transport, if
+// it pools connections
+func (ut userAgentTransport) CloseIdleConnections() {
+	if inner, ok := ut.inner.(closeIdler); ok {
+		inner.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,52 @@
+package publisher
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestUserAgent(t *testing.T) {
+	pub, leaf, k := setup(t)
+	sct := createSignedSCT(leaf.Raw, k)
+	var userAgents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, ct.GetEntriesPath) {
+			fmt.Fprint(w, `{"entries":[{"leaf_input":"","extra_data":""}]}`)
+			return
+		}
+		fmt.Fprint(w, sct)
+	}))
+	defer srv.Close()
+	port, err := getPort(srv)
+	test.AssertNotError(t, err, "Failed to get test server port")
+	addLog(t, pub, port, &k.PublicKey)
+
+	// Submissions and the GET methods both identify the publisher
+	result := pub.submitToLog(ctx, pub.ctLogs[0], ct.X509LogEntryType, leaf)
+	test.AssertNotError(t, result.Err, "Submission failed")
+	_, err = pub.GetEntries(ctx, pub.ctLogs[0].uri, 0, 0)
+	test.AssertNotError(t, err, "GetEntries failed")
+	test.AssertDeepEquals(t, userAgents, []string{"boulder-publisher/Unspecified", "boulder-publisher/Unspecified"})
+
+	client, err := NewHTTPClient(cmd.CTConfig{UserAgent: "example-ca-publisher"})
+	test.AssertNotError(t, err, "Failed to create HTTP client")
+	req, err := http.NewRequest("GET", srv.URL+"/ct/v1/get-sth", nil)
+	test.AssertNotError(t, err, "Failed to create request")
+	req.Header.Set("User-Agent", "overridden")
+	resp, err := client.Do(req)
+	test.AssertNotError(t, err, "Request failed")
+	resp.Body.Close()
+	test.AssertEquals(t, userAgents[2], "example-ca-publisher")
+	// The caller's request isn't modified
+	test.AssertEquals(t, req.Header.Get("User-Agent"), "overridden")
+}
@@ -0,0 +1,163 @@
+package publisher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+)
+
+// ValidateConfig checks the submission policy in config, returning an error
+// naming every invalid setting rather than only the first. Zero values are
+// valid and select the defaults documented on cmd.CTConfig, but negative
+// counts and durations, a backoff factor below 1, a backoff base above its
+// maximum and a minimum SCT count that the configured logs can't meet are
+// rejected. At least one log must be configured, and IssuerPath, if set, must
+// name a CA certificate. The log descriptions themselves are checked by
+// NewLogs, or ValidateCTConfig checks everything at once.
+func ValidateConfig(config cmd.CTConfig) error {
+	return configError(policyProblems(config))
+}
+
+// policyProblems returns the problems with the submission policy in config,
+// see ValidateConfig
+func policyProblems(config cmd.CTConfig) []string {
+	var problems []string
+	if len(config.Logs) == 0 {
+		problems = append(problems, "no CT logs are configured")
+	}
+
+	required := 0
+	for _, ld := range config.Logs {
+		if ld.Tier != cmd.BestEffortLogTier {
+			required++
+		}
+	}
+	if config.MinimumSCTCount < 0 {
+		problems = append(problems, fmt.Sprintf("MinimumSCTCount is negative: %d", config.MinimumSCTCount))
+	} else if len(config.Logs) > 0 && config.MinimumSCTCount > required {
+		problems = append(problems, fmt.Sprintf("MinimumSCTCount of %d is more than the %d required logs",
+			config.MinimumSCTCount, required))
+	}
+
+	counts := []struct {
+		name  string
+		value int64
+	}{
+		{"MinimumOperators", int64(config.MinimumOperators)},
+		{"MaxIdleConnsPerHost", int64(config.MaxIdleConnsPerHost)},
+		{"MaxResponseSize", config.MaxResponseSize},
+		{"SubmissionConcurrency", int64(config.SubmissionConcurrency)},
+		{"SubmissionQueueDepth", int64(config.SubmissionQueueDepth)},
+		{"CircuitBreakerThreshold", int64(config.CircuitBreakerThreshold)},
+		{"HealthcheckConcurrency", int64(config.HealthcheckConcurrency)},
+		{"SubmissionRetryBudget", int64(config.SubmissionRetryBudget)},
+		{"SubmissionCacheSize", int64(config.SubmissionCacheSize)},
+	}
+	for _, c := range counts {
+		if c.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s is negative: %d", c.name, c.value))
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value cmd.ConfigDuration
+	}{
+		{"RequestTimeout", config.RequestTimeout},
+		{"IdleConnTimeout", config.IdleConnTimeout},
+		{"SubmissionBackoffBase", config.SubmissionBackoffBase},
+		{"SubmissionBackoffMax", config.SubmissionBackoffMax},
+		{"SubmissionInitialDelayMax", config.SubmissionInitialDelayMax},
+		{"CircuitBreakerCooldown", config.CircuitBreakerCooldown},
+		{"MaxSCTClockSkew", config.MaxSCTClockSkew},
+		{"ClockSkewThreshold", config.ClockSkewThreshold},
+		{"HealthcheckTimeout", config.HealthcheckTimeout},
+		{"InclusionPollInterval", config.InclusionPollInterval},
+		{"InclusionTimeout", config.InclusionTimeout},
+		{"SubmissionTimeBudget", config.SubmissionTimeBudget},
+		{"SubmissionCacheTTL", config.SubmissionCacheTTL},
+		{"RootsCacheTTL", config.RootsCacheTTL},
+		{"CloseTimeout", config.CloseTimeout},
+	}
+	for _, d := range durations {
+		if d.value.Duration < 0 {
+			problems = append(problems, fmt.Sprintf("%s is negative: %s", d.name, d.value.Duration))
+		}
+	}
+
+	if config.SubmissionBackoffFactor != 0 && config.SubmissionBackoffFactor < 1 {
+		problems = append(problems, fmt.Sprintf("SubmissionBackoffFactor must be at least 1, not %g", config.SubmissionBackoffFactor))
+	}
+	base, max := config.SubmissionBackoffBase.Duration, config.SubmissionBackoffMax.Duration
+	if base > 0 && max > 0 && base > max {
+		problems = append(problems, fmt.Sprintf("SubmissionBackoffBase of %s is more than SubmissionBackoffMax of %s", base, max))
+	}
+
+	if config.IssuerPath != "" {
+		if _, err := LoadIssuer(config.IssuerPath); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	return problems
+}
+
+// ValidateCTConfig checks everything about config that is otherwise only
+// checked while the publisher is being set up, for linting configs before
+// they are deployed: the submission policy, as ValidateConfig does, each log's
+// URI, key and other settings, as NewLogs does, the TLS settings and the
+// issuer bundles. The files config names are read, but nothing is connected
+// to and nothing is logged. Every problem is named in the one error returned.
+func ValidateCTConfig(config *cmd.CTConfig) error {
+	problems := policyProblems(*config)
+	for _, ld := range config.Logs {
+		if err := validateLogDescription(ld); err != nil {
+			problems = append(problems, fmt.Sprintf("%q: %s", ld.URI, err))
+		}
+	}
+	if _, err := logTLSConfig(*config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := logProxy(*config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	bundles := config.AdditionalIntermediateBundleFilenames
+	if config.IntermediateBundleFilename != "" {
+		bundles = append([]string{config.IntermediateBundleFilename}, bundles...)
+	}
+	if config.CrossSignBundleFilename != "" {
+		bundles = append(bundles, config.CrossSignBundleFilename)
+	}
+	for _, filename := range bundles {
+		if _, err := core.LoadCertBundle(filename); err != nil {
+			problems = append(problems, fmt.Sprintf("loading bundle %s: %s", filename, err))
+		}
+	}
+	return configError(problems)
+}
+
+// validateLogDescription checks a log description as NewLog does, without
+// creating the log
+func validateLogDescription(ld cmd.LogDescription) error {
+	if err := validateLogURI(ld); err != nil {
+		return err
+	}
+	if err := checkLogDescription(ld); err != nil {
+		return err
+	}
+	if !ld.SkipSignatureVerification {
+		if _, _, _, err := parseLogKey(ld.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configError(problems []string) error {
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid CT configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
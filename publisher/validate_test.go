@@ -0,0 +1,135 @@
+package publisher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestValidateConfig(t *testing.T) {
+	logs := []cmd.LogDescription{
+		{URI: "https://a.example.com/ct"},
+		{URI: "https://b.example.com/ct"},
+		{URI: "https://c.example.com/ct", Tier: cmd.BestEffortLogTier},
+	}
+	// Zero values select the defaults
+	test.AssertNotError(t, ValidateConfig(cmd.CTConfig{Logs: logs}), "Config with defaults was rejected")
+	test.AssertNotError(t, ValidateConfig(cmd.CTConfig{
+		Logs:                    logs,
+		MinimumSCTCount:         2,
+		SubmissionBackoffBase:   cmd.ConfigDuration{Duration: time.Second},
+		SubmissionBackoffFactor: 1,
+		SubmissionBackoffMax:    cmd.ConfigDuration{Duration: time.Second},
+	}), "Valid config was rejected")
+
+	testCases := []struct {
+		name     string
+		config   cmd.CTConfig
+		expected string
+	}{
+		{
+			"no logs",
+			cmd.CTConfig{},
+			"invalid CT configuration: no CT logs are configured",
+		},
+		{
+			"negative minimum SCT count",
+			cmd.CTConfig{Logs: logs, MinimumSCTCount: -1},
+			"invalid CT configuration: MinimumSCTCount is negative: -1",
+		},
+		{
+			"minimum SCT count counting best-effort logs",
+			cmd.CTConfig{Logs: logs, MinimumSCTCount: 3},
+			"invalid CT configuration: MinimumSCTCount of 3 is more than the 2 required logs",
+		},
+		{
+			"negative count",
+			cmd.CTConfig{Logs: logs, SubmissionConcurrency: -5},
+			"invalid CT configuration: SubmissionConcurrency is negative: -5",
+		},
+		{
+			"negative duration",
+			cmd.CTConfig{Logs: logs, RequestTimeout: cmd.ConfigDuration{Duration: -time.Second}},
+			"invalid CT configuration: RequestTimeout is negative: -1s",
+		},
+		{
+			"backoff factor below 1",
+			cmd.CTConfig{Logs: logs, SubmissionBackoffFactor: 0.5},
+			"invalid CT configuration: SubmissionBackoffFactor must be at least 1, not 0.5",
+		},
+		{
+			"backoff base above max",
+			cmd.CTConfig{
+				Logs:                  logs,
+				SubmissionBackoffBase: cmd.ConfigDuration{Duration: time.Minute},
+				SubmissionBackoffMax:  cmd.ConfigDuration{Duration: time.Second},
+			},
+			"invalid CT configuration: SubmissionBackoffBase of 1m0s is more than SubmissionBackoffMax of 1s",
+		},
+		{
+			"several problems",
+			cmd.CTConfig{
+				MinimumSCTCount:         -1,
+				CircuitBreakerThreshold: -1,
+				CircuitBreakerCooldown:  cmd.ConfigDuration{Duration: -time.Minute},
+			},
+			"invalid CT configuration: no CT logs are configured; MinimumSCTCount is negative: -1; " +
+				"CircuitBreakerThreshold is negative: -1; CircuitBreakerCooldown is negative: -1m0s",
+		},
+	}
+	for _, tc := range testCases {
+		err := ValidateConfig(tc.config)
+		test.AssertError(t, err, tc.name+" was accepted")
+		test.AssertEquals(t, err.Error(), tc.expected)
+	}
+}
+
+func TestValidateCTConfig(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	key := base64.StdEncoding.EncodeToString(der)
+
+	err = ValidateCTConfig(&cmd.CTConfig{
+		Logs: []cmd.LogDescription{
+			{URI: "https://a.example.com/ct", Key: key},
+			{URI: "https://b.example.com/ct", SkipSignatureVerification: true, Tier: cmd.BestEffortLogTier},
+		},
+		IntermediateBundleFilename: "../test/test-ca.pem",
+	})
+	test.AssertNotError(t, err, "Valid config was rejected")
+
+	err = ValidateCTConfig(&cmd.CTConfig{
+		Logs: []cmd.LogDescription{
+			{URI: "http://a.example.com/ct", Key: key},
+			{URI: "https://b.example.com/ct", Key: "not base64"},
+			{URI: "https://c.example.com/ct", Key: key, Tier: "optional"},
+			{URI: "https://d.example.com/ct", Key: key, RateLimit: -1},
+		},
+		MinimumSCTCount:            -1,
+		MinTLSVersion:              "1.4",
+		IntermediateBundleFilename: "does-not-exist.pem",
+	})
+	test.AssertError(t, err, "Invalid config was accepted")
+	for _, problem := range []string{
+		"MinimumSCTCount is negative: -1",
+		`"http://a.example.com/ct": URI scheme must be https, not "http"`,
+		`"https://b.example.com/ct": Failed to decode base64 log public key`,
+		`"https://c.example.com/ct": unknown CT log tier "optional"`,
+		`"https://d.example.com/ct": CT log at https://d.example.com/ct has a negative rate limit`,
+		`unsupported minimum TLS version "1.4"`,
+		"loading bundle does-not-exist.pem: ",
+	} {
+		test.AssertContains(t, err.Error(), problem)
+	}
+	test.AssertEquals(t, strings.Count(err.Error(), "; "), 6)
+}
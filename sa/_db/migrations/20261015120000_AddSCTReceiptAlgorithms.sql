@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- Receipts stored before these columns existed have them as 0, and their
+-- algorithms are inferred from the signature
+ALTER TABLE `sctReceipts` ADD COLUMN (
+  `hashAlgorithm` tinyint(1) NOT NULL DEFAULT 0,
+  `signatureAlgorithm` tinyint(1) NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE `sctReceipts` DROP COLUMN `hashAlgorithm`, DROP COLUMN `signatureAlgorithm`;
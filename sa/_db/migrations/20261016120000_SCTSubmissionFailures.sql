@@ -0,0 +1,18 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `sctSubmissionFailures` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `certificateSerial` varchar(255) NOT NULL,
+  `logURI` varchar(255) NOT NULL,
+  `reason` mediumtext NOT NULL,
+  `attemptedAt` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `certificateSerial_logURI` (`certificateSerial`, `logURI`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `sctSubmissionFailures`;
@@ -88,7 +88,7 @@ func selectSctReceipt(s dbOneSelector, q string, args ...interface{}) (core.Sign
 	var model core.SignedCertificateTimestamp
 	err := s.SelectOne(
 		&model,
-		"SELECT id, sctVersion, logID, timestamp, extensions, signature, certificateSerial, LockCol FROM sctReceipts "+q,
+		"SELECT id, sctVersion, logID, timestamp, extensions, signature, hashAlgorithm, signatureAlgorithm, certificateSerial, LockCol FROM sctReceipts "+q,
 		args...,
 	)
 	return model, err
@@ -30,6 +30,10 @@ It has these top-level messages:
 	AddCertificateRequest
 	AddCertificateResponse
 	SignedCertificateTimestamp
+	DeleteExpiredSCTReceiptsRequest
+	SCTSubmissionFailure
+	SCTSubmissionFailures
+	DeleteSCTSubmissionFailureRequest
 	RevokeAuthorizationsByDomainRequest
 	RevokeAuthorizationsByDomainResponse
 */
@@ -622,14 +626,16 @@ func (m *AddCertificateResponse) GetDigest() string {
 }
 
 type SignedCertificateTimestamp struct {
-	Id                *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	SctVersion        *int64  `protobuf:"varint,2,opt,name=sctVersion" json:"sctVersion,omitempty"`
-	LogID             *string `protobuf:"bytes,3,opt,name=logID" json:"logID,omitempty"`
-	Timestamp         *int64  `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
-	Extensions        []byte  `protobuf:"bytes,5,opt,name=extensions" json:"extensions,omitempty"`
-	Signature         []byte  `protobuf:"bytes,6,opt,name=signature" json:"signature,omitempty"`
-	CertificateSerial *string `protobuf:"bytes,7,opt,name=certificateSerial" json:"certificateSerial,omitempty"`
-	XXX_unrecognized  []byte  `json:"-"`
+	Id                 *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	SctVersion         *int64  `protobuf:"varint,2,opt,name=sctVersion" json:"sctVersion,omitempty"`
+	LogID              *string `protobuf:"bytes,3,opt,name=logID" json:"logID,omitempty"`
+	Timestamp          *int64  `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Extensions         []byte  `protobuf:"bytes,5,opt,name=extensions" json:"extensions,omitempty"`
+	Signature          []byte  `protobuf:"bytes,6,opt,name=signature" json:"signature,omitempty"`
+	CertificateSerial  *string `protobuf:"bytes,7,opt,name=certificateSerial" json:"certificateSerial,omitempty"`
+	HashAlgorithm      *int64  `protobuf:"varint,8,opt,name=hashAlgorithm" json:"hashAlgorithm,omitempty"`
+	SignatureAlgorithm *int64  `protobuf:"varint,9,opt,name=signatureAlgorithm" json:"signatureAlgorithm,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
 }
 
 func (m *SignedCertificateTimestamp) Reset()                    { *m = SignedCertificateTimestamp{} }
@@ -686,6 +692,140 @@ func (m *SignedCertificateTimestamp) GetCertificateSerial() string {
 	return ""
 }
 
+func (m *SignedCertificateTimestamp) GetHashAlgorithm() int64 {
+	if m != nil && m.HashAlgorithm != nil {
+		return *m.HashAlgorithm
+	}
+	return 0
+}
+
+func (m *SignedCertificateTimestamp) GetSignatureAlgorithm() int64 {
+	if m != nil && m.SignatureAlgorithm != nil {
+		return *m.SignatureAlgorithm
+	}
+	return 0
+}
+
+type DeleteExpiredSCTReceiptsRequest struct {
+	Cutoff           *int64 `protobuf:"varint,1,opt,name=cutoff" json:"cutoff,omitempty"`
+	Limit            *int64 `protobuf:"varint,2,opt,name=limit" json:"limit,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *DeleteExpiredSCTReceiptsRequest) Reset()         { *m = DeleteExpiredSCTReceiptsRequest{} }
+func (m *DeleteExpiredSCTReceiptsRequest) String() string { return proto1.CompactTextString(m) }
+func (*DeleteExpiredSCTReceiptsRequest) ProtoMessage()    {}
+func (*DeleteExpiredSCTReceiptsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{21}
+}
+
+func (m *DeleteExpiredSCTReceiptsRequest) GetCutoff() int64 {
+	if m != nil && m.Cutoff != nil {
+		return *m.Cutoff
+	}
+	return 0
+}
+
+func (m *DeleteExpiredSCTReceiptsRequest) GetLimit() int64 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+type SCTSubmissionFailure struct {
+	Id                *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	CertificateSerial *string `protobuf:"bytes,2,opt,name=certificateSerial" json:"certificateSerial,omitempty"`
+	LogURI            *string `protobuf:"bytes,3,opt,name=logURI" json:"logURI,omitempty"`
+	Reason            *string `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+	AttemptedAt       *int64  `protobuf:"varint,5,opt,name=attemptedAt" json:"attemptedAt,omitempty"`
+	XXX_unrecognized  []byte  `json:"-"`
+}
+
+func (m *SCTSubmissionFailure) Reset()                    { *m = SCTSubmissionFailure{} }
+func (m *SCTSubmissionFailure) String() string            { return proto1.CompactTextString(m) }
+func (*SCTSubmissionFailure) ProtoMessage()               {}
+func (*SCTSubmissionFailure) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *SCTSubmissionFailure) GetId() int64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *SCTSubmissionFailure) GetCertificateSerial() string {
+	if m != nil && m.CertificateSerial != nil {
+		return *m.CertificateSerial
+	}
+	return ""
+}
+
+func (m *SCTSubmissionFailure) GetLogURI() string {
+	if m != nil && m.LogURI != nil {
+		return *m.LogURI
+	}
+	return ""
+}
+
+func (m *SCTSubmissionFailure) GetReason() string {
+	if m != nil && m.Reason != nil {
+		return *m.Reason
+	}
+	return ""
+}
+
+func (m *SCTSubmissionFailure) GetAttemptedAt() int64 {
+	if m != nil && m.AttemptedAt != nil {
+		return *m.AttemptedAt
+	}
+	return 0
+}
+
+type SCTSubmissionFailures struct {
+	Failures         []*SCTSubmissionFailure `protobuf:"bytes,1,rep,name=failures" json:"failures,omitempty"`
+	XXX_unrecognized []byte                  `json:"-"`
+}
+
+func (m *SCTSubmissionFailures) Reset()                    { *m = SCTSubmissionFailures{} }
+func (m *SCTSubmissionFailures) String() string            { return proto1.CompactTextString(m) }
+func (*SCTSubmissionFailures) ProtoMessage()               {}
+func (*SCTSubmissionFailures) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *SCTSubmissionFailures) GetFailures() []*SCTSubmissionFailure {
+	if m != nil {
+		return m.Failures
+	}
+	return nil
+}
+
+type DeleteSCTSubmissionFailureRequest struct {
+	Serial           *string `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	LogURI           *string `protobuf:"bytes,2,opt,name=logURI" json:"logURI,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DeleteSCTSubmissionFailureRequest) Reset()         { *m = DeleteSCTSubmissionFailureRequest{} }
+func (m *DeleteSCTSubmissionFailureRequest) String() string { return proto1.CompactTextString(m) }
+func (*DeleteSCTSubmissionFailureRequest) ProtoMessage()    {}
+func (*DeleteSCTSubmissionFailureRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{24}
+}
+
+func (m *DeleteSCTSubmissionFailureRequest) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *DeleteSCTSubmissionFailureRequest) GetLogURI() string {
+	if m != nil && m.LogURI != nil {
+		return *m.LogURI
+	}
+	return ""
+}
+
 type RevokeAuthorizationsByDomainRequest struct {
 	Domain           *string `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
@@ -695,7 +835,7 @@ func (m *RevokeAuthorizationsByDomainRequest) Reset()         { *m = RevokeAutho
 func (m *RevokeAuthorizationsByDomainRequest) String() string { return proto1.CompactTextString(m) }
 func (*RevokeAuthorizationsByDomainRequest) ProtoMessage()    {}
 func (*RevokeAuthorizationsByDomainRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{21}
+	return fileDescriptor0, []int{25}
 }
 
 func (m *RevokeAuthorizationsByDomainRequest) GetDomain() string {
@@ -715,7 +855,7 @@ func (m *RevokeAuthorizationsByDomainResponse) Reset()         { *m = RevokeAuth
 func (m *RevokeAuthorizationsByDomainResponse) String() string { return proto1.CompactTextString(m) }
 func (*RevokeAuthorizationsByDomainResponse) ProtoMessage()    {}
 func (*RevokeAuthorizationsByDomainResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{22}
+	return fileDescriptor0, []int{26}
 }
 
 func (m *RevokeAuthorizationsByDomainResponse) GetFinalized() int64 {
@@ -756,6 +896,10 @@ func init() {
 	proto1.RegisterType((*AddCertificateRequest)(nil), "sa.AddCertificateRequest")
 	proto1.RegisterType((*AddCertificateResponse)(nil), "sa.AddCertificateResponse")
 	proto1.RegisterType((*SignedCertificateTimestamp)(nil), "sa.SignedCertificateTimestamp")
+	proto1.RegisterType((*DeleteExpiredSCTReceiptsRequest)(nil), "sa.DeleteExpiredSCTReceiptsRequest")
+	proto1.RegisterType((*SCTSubmissionFailure)(nil), "sa.SCTSubmissionFailure")
+	proto1.RegisterType((*SCTSubmissionFailures)(nil), "sa.SCTSubmissionFailures")
+	proto1.RegisterType((*DeleteSCTSubmissionFailureRequest)(nil), "sa.DeleteSCTSubmissionFailureRequest")
 	proto1.RegisterType((*RevokeAuthorizationsByDomainRequest)(nil), "sa.RevokeAuthorizationsByDomainRequest")
 	proto1.RegisterType((*RevokeAuthorizationsByDomainResponse)(nil), "sa.RevokeAuthorizationsByDomainResponse")
 }
@@ -788,6 +932,7 @@ type StorageAuthorityClient interface {
 	// a given registration ID and expire in the given time range.
 	CountInvalidAuthorizations(ctx context.Context, in *CountInvalidAuthorizationsRequest, opts ...grpc.CallOption) (*Count, error)
 	GetSCTReceipt(ctx context.Context, in *GetSCTReceiptRequest, opts ...grpc.CallOption) (*SignedCertificateTimestamp, error)
+	GetSCTSubmissionFailures(ctx context.Context, in *core.Empty, opts ...grpc.CallOption) (*SCTSubmissionFailures, error)
 	CountFQDNSets(ctx context.Context, in *CountFQDNSetsRequest, opts ...grpc.CallOption) (*Count, error)
 	FQDNSetExists(ctx context.Context, in *FQDNSetExistsRequest, opts ...grpc.CallOption) (*Exists, error)
 	// Adders
@@ -799,6 +944,10 @@ type StorageAuthorityClient interface {
 	MarkCertificateRevoked(ctx context.Context, in *MarkCertificateRevokedRequest, opts ...grpc.CallOption) (*core.Empty, error)
 	AddCertificate(ctx context.Context, in *AddCertificateRequest, opts ...grpc.CallOption) (*AddCertificateResponse, error)
 	AddSCTReceipt(ctx context.Context, in *SignedCertificateTimestamp, opts ...grpc.CallOption) (*core.Empty, error)
+	DeleteExpiredSCTReceipts(ctx context.Context, in *DeleteExpiredSCTReceiptsRequest, opts ...grpc.CallOption) (*Count, error)
+	PurgeSCTReceipts(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Count, error)
+	AddSCTSubmissionFailure(ctx context.Context, in *SCTSubmissionFailure, opts ...grpc.CallOption) (*core.Empty, error)
+	DeleteSCTSubmissionFailure(ctx context.Context, in *DeleteSCTSubmissionFailureRequest, opts ...grpc.CallOption) (*core.Empty, error)
 	RevokeAuthorizationsByDomain(ctx context.Context, in *RevokeAuthorizationsByDomainRequest, opts ...grpc.CallOption) (*RevokeAuthorizationsByDomainResponse, error)
 	DeactivateRegistration(ctx context.Context, in *RegistrationID, opts ...grpc.CallOption) (*core.Empty, error)
 	DeactivateAuthorization(ctx context.Context, in *AuthorizationID, opts ...grpc.CallOption) (*core.Empty, error)
@@ -938,6 +1087,15 @@ func (c *storageAuthorityClient) GetSCTReceipt(ctx context.Context, in *GetSCTRe
 	return out, nil
 }
 
+func (c *storageAuthorityClient) GetSCTSubmissionFailures(ctx context.Context, in *core.Empty, opts ...grpc.CallOption) (*SCTSubmissionFailures, error) {
+	out := new(SCTSubmissionFailures)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/GetSCTSubmissionFailures", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) CountFQDNSets(ctx context.Context, in *CountFQDNSetsRequest, opts ...grpc.CallOption) (*Count, error) {
 	out := new(Count)
 	err := grpc.Invoke(ctx, "/sa.StorageAuthority/CountFQDNSets", in, out, c.cc, opts...)
@@ -1028,6 +1186,42 @@ func (c *storageAuthorityClient) AddSCTReceipt(ctx context.Context, in *SignedCe
 	return out, nil
 }
 
+func (c *storageAuthorityClient) DeleteExpiredSCTReceipts(ctx context.Context, in *DeleteExpiredSCTReceiptsRequest, opts ...grpc.CallOption) (*Count, error) {
+	out := new(Count)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeleteExpiredSCTReceipts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) PurgeSCTReceipts(ctx context.Context, in *Serial, opts ...grpc.CallOption) (*Count, error) {
+	out := new(Count)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/PurgeSCTReceipts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddSCTSubmissionFailure(ctx context.Context, in *SCTSubmissionFailure, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/AddSCTSubmissionFailure", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) DeleteSCTSubmissionFailure(ctx context.Context, in *DeleteSCTSubmissionFailureRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	out := new(core.Empty)
+	err := grpc.Invoke(ctx, "/sa.StorageAuthority/DeleteSCTSubmissionFailure", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) RevokeAuthorizationsByDomain(ctx context.Context, in *RevokeAuthorizationsByDomainRequest, opts ...grpc.CallOption) (*RevokeAuthorizationsByDomainResponse, error) {
 	out := new(RevokeAuthorizationsByDomainResponse)
 	err := grpc.Invoke(ctx, "/sa.StorageAuthority/RevokeAuthorizationsByDomain", in, out, c.cc, opts...)
@@ -1075,6 +1269,7 @@ type StorageAuthorityServer interface {
 	// a given registration ID and expire in the given time range.
 	CountInvalidAuthorizations(context.Context, *CountInvalidAuthorizationsRequest) (*Count, error)
 	GetSCTReceipt(context.Context, *GetSCTReceiptRequest) (*SignedCertificateTimestamp, error)
+	GetSCTSubmissionFailures(context.Context, *core.Empty) (*SCTSubmissionFailures, error)
 	CountFQDNSets(context.Context, *CountFQDNSetsRequest) (*Count, error)
 	FQDNSetExists(context.Context, *FQDNSetExistsRequest) (*Exists, error)
 	// Adders
@@ -1086,6 +1281,10 @@ type StorageAuthorityServer interface {
 	MarkCertificateRevoked(context.Context, *MarkCertificateRevokedRequest) (*core.Empty, error)
 	AddCertificate(context.Context, *AddCertificateRequest) (*AddCertificateResponse, error)
 	AddSCTReceipt(context.Context, *SignedCertificateTimestamp) (*core.Empty, error)
+	DeleteExpiredSCTReceipts(context.Context, *DeleteExpiredSCTReceiptsRequest) (*Count, error)
+	PurgeSCTReceipts(context.Context, *Serial) (*Count, error)
+	AddSCTSubmissionFailure(context.Context, *SCTSubmissionFailure) (*core.Empty, error)
+	DeleteSCTSubmissionFailure(context.Context, *DeleteSCTSubmissionFailureRequest) (*core.Empty, error)
 	RevokeAuthorizationsByDomain(context.Context, *RevokeAuthorizationsByDomainRequest) (*RevokeAuthorizationsByDomainResponse, error)
 	DeactivateRegistration(context.Context, *RegistrationID) (*core.Empty, error)
 	DeactivateAuthorization(context.Context, *AuthorizationID) (*core.Empty, error)
@@ -1347,6 +1546,24 @@ func _StorageAuthority_GetSCTReceipt_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_GetSCTSubmissionFailures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(core.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetSCTSubmissionFailures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetSCTSubmissionFailures",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetSCTSubmissionFailures(ctx, req.(*core.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageAuthority_CountFQDNSets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CountFQDNSetsRequest)
 	if err := dec(in); err != nil {
@@ -1527,6 +1744,78 @@ func _StorageAuthority_AddSCTReceipt_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_DeleteExpiredSCTReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteExpiredSCTReceiptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).DeleteExpiredSCTReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/DeleteExpiredSCTReceipts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).DeleteExpiredSCTReceipts(ctx, req.(*DeleteExpiredSCTReceiptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_PurgeSCTReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Serial)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).PurgeSCTReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/PurgeSCTReceipts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).PurgeSCTReceipts(ctx, req.(*Serial))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddSCTSubmissionFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SCTSubmissionFailure)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddSCTSubmissionFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddSCTSubmissionFailure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddSCTSubmissionFailure(ctx, req.(*SCTSubmissionFailure))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_DeleteSCTSubmissionFailure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSCTSubmissionFailureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).DeleteSCTSubmissionFailure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/DeleteSCTSubmissionFailure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).DeleteSCTSubmissionFailure(ctx, req.(*DeleteSCTSubmissionFailureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageAuthority_RevokeAuthorizationsByDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RevokeAuthorizationsByDomainRequest)
 	if err := dec(in); err != nil {
@@ -1641,6 +1930,10 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetSCTReceipt",
 			Handler:    _StorageAuthority_GetSCTReceipt_Handler,
 		},
+		{
+			MethodName: "GetSCTSubmissionFailures",
+			Handler:    _StorageAuthority_GetSCTSubmissionFailures_Handler,
+		},
 		{
 			MethodName: "CountFQDNSets",
 			Handler:    _StorageAuthority_CountFQDNSets_Handler,
@@ -1681,6 +1974,22 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddSCTReceipt",
 			Handler:    _StorageAuthority_AddSCTReceipt_Handler,
 		},
+		{
+			MethodName: "DeleteExpiredSCTReceipts",
+			Handler:    _StorageAuthority_DeleteExpiredSCTReceipts_Handler,
+		},
+		{
+			MethodName: "PurgeSCTReceipts",
+			Handler:    _StorageAuthority_PurgeSCTReceipts_Handler,
+		},
+		{
+			MethodName: "AddSCTSubmissionFailure",
+			Handler:    _StorageAuthority_AddSCTSubmissionFailure_Handler,
+		},
+		{
+			MethodName: "DeleteSCTSubmissionFailure",
+			Handler:    _StorageAuthority_DeleteSCTSubmissionFailure_Handler,
+		},
 		{
 			MethodName: "RevokeAuthorizationsByDomain",
 			Handler:    _StorageAuthority_RevokeAuthorizationsByDomain_Handler,
@@ -1701,84 +2010,97 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("sa/proto/sa.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1257 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0xdd, 0x72, 0xd3, 0x46,
-	0x14, 0xf6, 0x0f, 0x4e, 0xe2, 0xe3, 0x9f, 0xc4, 0x9b, 0xc4, 0x11, 0x82, 0xb4, 0x61, 0x69, 0x87,
-	0x70, 0x13, 0x4a, 0x66, 0x28, 0x17, 0x29, 0x1d, 0x12, 0x6c, 0xd2, 0x84, 0x92, 0xa1, 0x36, 0xd0,
-	0x4e, 0xef, 0x16, 0xe9, 0x60, 0xb6, 0xd8, 0x92, 0xaa, 0x5d, 0xc7, 0x31, 0x8f, 0xd0, 0xa7, 0xe8,
-	0xb3, 0xf5, 0x01, 0xfa, 0x0c, 0x9d, 0xdd, 0x95, 0x6d, 0x49, 0x96, 0x1d, 0x98, 0xde, 0xc9, 0xbb,
-	0xe7, 0xfb, 0xf6, 0xec, 0xd9, 0x73, 0xbe, 0x2f, 0x81, 0x86, 0x60, 0x0f, 0x82, 0xd0, 0x97, 0xfe,
-	0x03, 0xc1, 0x0e, 0xf4, 0x07, 0x29, 0x08, 0x66, 0x6f, 0x3b, 0x7e, 0x88, 0xd1, 0x86, 0xfa, 0x34,
-	0x5b, 0xf4, 0x36, 0xd4, 0x3b, 0xd8, 0xe3, 0x42, 0x86, 0x4c, 0x72, 0xdf, 0x3b, 0x6b, 0x11, 0x80,
-	0x02, 0x77, 0xad, 0xfc, 0x5e, 0x7e, 0xbf, 0x48, 0x6f, 0x02, 0x9c, 0x0b, 0xdf, 0xfb, 0x15, 0xdf,
-	0xbd, 0xc0, 0x31, 0xa9, 0x40, 0xf1, 0x8f, 0xd1, 0x47, 0xbd, 0x55, 0xa5, 0xbb, 0xb0, 0x7e, 0x3c,
-	0x94, 0x1f, 0xfc, 0x90, 0x7f, 0x9a, 0x47, 0x96, 0xe9, 0x1b, 0xd8, 0x3d, 0x45, 0xf9, 0x96, 0xf5,
-	0xb9, 0x9b, 0x08, 0x13, 0x1d, 0xfc, 0x73, 0x88, 0x42, 0x92, 0x26, 0xd4, 0xc3, 0xc4, 0xc1, 0xe6,
-	0x48, 0xb2, 0x0e, 0xab, 0xae, 0x3f, 0x60, 0xdc, 0x13, 0x56, 0x61, 0xaf, 0xb8, 0x5f, 0x56, 0xa7,
-	0x7a, 0xfe, 0xc8, 0x2a, 0xea, 0x84, 0xfe, 0xca, 0xc3, 0x66, 0x06, 0x29, 0x79, 0x08, 0xa5, 0x4b,
-	0xb5, 0x6c, 0xe5, 0xf7, 0x8a, 0xfb, 0x95, 0x43, 0x7a, 0x20, 0xd8, 0x41, 0x46, 0xdc, 0xc1, 0x4b,
-	0x16, 0xb4, 0xfb, 0x38, 0x40, 0x4f, 0xda, 0x4f, 0x01, 0x66, 0xbf, 0x48, 0x1d, 0x56, 0xcc, 0xb1,
-	0x26, 0x7f, 0x42, 0xa1, 0xc4, 0x86, 0xf2, 0xc3, 0x27, 0xab, 0xb0, 0x97, 0xdf, 0xaf, 0x1c, 0x6e,
-	0x1e, 0xe8, 0x9a, 0x25, 0xd8, 0xe8, 0xbf, 0x79, 0x68, 0x3c, 0xc3, 0x50, 0xf2, 0xf7, 0xdc, 0x61,
-	0x12, 0xbb, 0x92, 0xc9, 0xa1, 0x50, 0x4c, 0x02, 0x43, 0xce, 0xfa, 0x11, 0x93, 0x0d, 0x44, 0x0c,
-	0xdf, 0x09, 0x27, 0xe4, 0xef, 0x30, 0x3c, 0x0e, 0x82, 0xd0, 0xbf, 0x44, 0x57, 0xd3, 0xae, 0xe9,
-	0x58, 0x8d, 0xd2, 0xd7, 0x2b, 0x93, 0x1d, 0x58, 0xf7, 0x1d, 0x11, 0xfc, 0xcc, 0x84, 0x7c, 0x13,
-	0xb8, 0x4c, 0xa2, 0x6b, 0xdd, 0xd0, 0x55, 0xd9, 0x84, 0x4a, 0x88, 0x97, 0xfe, 0x47, 0x74, 0x5b,
-	0x4c, 0xa2, 0x55, 0xd2, 0x8b, 0xdb, 0x50, 0x8b, 0x16, 0x3b, 0xc8, 0x84, 0xef, 0x59, 0x2b, 0x7a,
-	0x79, 0x17, 0xb6, 0xfb, 0x4c, 0xc8, 0xf6, 0x55, 0xc0, 0x4d, 0x6d, 0x2f, 0x58, 0xaf, 0x8b, 0x9e,
-	0xb4, 0x56, 0xf5, 0xf6, 0x16, 0x54, 0xd5, 0x19, 0x1d, 0x14, 0x81, 0xef, 0x09, 0xb4, 0xd6, 0xd4,
-	0x73, 0x92, 0x0d, 0x58, 0xf3, 0x7c, 0x79, 0xfc, 0x5e, 0x62, 0x68, 0x95, 0x75, 0x5c, 0x03, 0xca,
-	0x5c, 0x68, 0x12, 0x74, 0x2d, 0x50, 0xe9, 0x52, 0x0b, 0x56, 0xba, 0xfa, 0x6a, 0xe9, 0x4b, 0xd2,
-	0xfb, 0x50, 0xea, 0x30, 0xaf, 0x87, 0x8a, 0x07, 0x59, 0xd8, 0xe7, 0x28, 0x64, 0xf4, 0xa0, 0x75,
-	0x58, 0xe9, 0x33, 0xa9, 0x7e, 0x17, 0xf4, 0x13, 0x36, 0xa1, 0xf4, 0xcc, 0x1f, 0x7a, 0x92, 0xd4,
-	0xa0, 0xe4, 0xa8, 0x8f, 0xa8, 0xd7, 0xce, 0xe1, 0x6b, 0xbd, 0x1e, 0xab, 0xa8, 0x38, 0x19, 0x5f,
-	0xb0, 0x01, 0x4e, 0x7b, 0xc6, 0x82, 0x52, 0xa8, 0x4e, 0xd1, 0x88, 0xca, 0x61, 0x59, 0xbd, 0xb2,
-	0x39, 0xb6, 0x06, 0x25, 0x4f, 0x45, 0x9a, 0x9e, 0xa1, 0x7d, 0xa8, 0x6a, 0xae, 0x08, 0x4f, 0x1e,
-	0x42, 0xd5, 0x89, 0xfd, 0x8e, 0xba, 0xe4, 0x96, 0xc2, 0xc7, 0xe3, 0xe2, 0xed, 0x71, 0x3f, 0xd1,
-	0x1e, 0x55, 0xb8, 0xa1, 0xf8, 0xa3, 0x27, 0x9d, 0x66, 0x6e, 0x6e, 0xd4, 0x86, 0x5d, 0xcd, 0x12,
-	0x1f, 0x24, 0x71, 0x32, 0x3e, 0x7b, 0x35, 0xc9, 0x5b, 0x0d, 0x46, 0x60, 0xe6, 0x66, 0x76, 0x87,
-	0x42, 0xea, 0x0e, 0xb4, 0x07, 0x77, 0x34, 0xcd, 0x99, 0x77, 0xf9, 0xe5, 0x63, 0xb3, 0x01, 0x6b,
-	0x1f, 0x7c, 0x21, 0x75, 0x92, 0x05, 0x9d, 0xe4, 0xf4, 0xa0, 0x62, 0xfa, 0xa0, 0x47, 0xb0, 0x75,
-	0x8a, 0xb2, 0xfb, 0xec, 0x75, 0x07, 0x1d, 0xe4, 0x81, 0x9c, 0x70, 0xa7, 0x3b, 0xb7, 0x06, 0xa5,
-	0xbe, 0xdf, 0x3b, 0x6b, 0x19, 0x42, 0xfa, 0x18, 0xb6, 0x74, 0x7e, 0xcf, 0x7f, 0x69, 0x5d, 0x74,
-	0x51, 0x8a, 0x18, 0x6c, 0xc4, 0x3d, 0xd7, 0x1f, 0x2d, 0x98, 0x60, 0x7a, 0x0f, 0xb6, 0x22, 0x4c,
-	0xfb, 0x8a, 0x8b, 0x19, 0x30, 0x16, 0x98, 0xd7, 0x81, 0x16, 0xac, 0x98, 0x08, 0xc5, 0x89, 0xfa,
-	0x4b, 0x73, 0xae, 0xd1, 0x27, 0xb0, 0xfb, 0x92, 0x85, 0x1f, 0x63, 0xbd, 0xd1, 0x99, 0x74, 0x7e,
-	0x76, 0xee, 0x55, 0xb8, 0xe1, 0xf8, 0x2e, 0x46, 0x2f, 0x74, 0x0c, 0xdb, 0xc7, 0xae, 0x9b, 0x40,
-	0x1b, 0x58, 0x05, 0x8a, 0x2e, 0x86, 0xd1, 0xd3, 0xd4, 0xa0, 0x14, 0xe2, 0xe4, 0xbe, 0x45, 0x45,
-	0xa1, 0x06, 0x45, 0xd7, 0xaf, 0x4a, 0xf7, 0xa1, 0x99, 0xa6, 0x30, 0x03, 0xa4, 0xa5, 0x83, 0xf7,
-	0x26, 0x0d, 0x5f, 0xa6, 0x7f, 0xe7, 0xc1, 0xee, 0xf2, 0x9e, 0x87, 0xf1, 0xe8, 0xd7, 0x7c, 0x80,
-	0x42, 0xb2, 0x41, 0x10, 0xd7, 0x57, 0x42, 0x00, 0x84, 0x23, 0xdf, 0x62, 0x28, 0xb8, 0xef, 0x45,
-	0xc7, 0x4e, 0xab, 0x6e, 0x24, 0xa1, 0x01, 0x65, 0x39, 0xc1, 0x46, 0x62, 0x40, 0x00, 0xf0, 0x4a,
-	0xa2, 0xa7, 0x40, 0x42, 0x6b, 0x41, 0x55, 0x85, 0x09, 0xde, 0xf3, 0x98, 0x1c, 0x86, 0xa8, 0x75,
-	0xa0, 0x4a, 0x6e, 0x42, 0xc3, 0x89, 0xa9, 0x93, 0xa9, 0xce, 0xaa, 0x4e, 0xf1, 0x11, 0xdc, 0x35,
-	0xf5, 0x4b, 0x36, 0xd9, 0xc9, 0xb8, 0xa5, 0xdf, 0x23, 0x56, 0xd4, 0xb8, 0x28, 0xd2, 0x73, 0xf8,
-	0x66, 0x39, 0x2c, 0xaa, 0x48, 0x03, 0xca, 0xef, 0xb9, 0xc7, 0xfa, 0xfc, 0x13, 0xba, 0xb3, 0xa6,
-	0x08, 0xd0, 0x73, 0xb9, 0xd7, 0x33, 0xd7, 0x3c, 0xfc, 0xa7, 0x0e, 0x1b, 0x5d, 0xe9, 0x87, 0xac,
-	0x37, 0x61, 0x93, 0x63, 0x72, 0x04, 0xeb, 0xa7, 0x98, 0x98, 0x23, 0x42, 0x74, 0xdf, 0x26, 0x5a,
-	0xde, 0x26, 0x46, 0x8d, 0xe3, 0xab, 0x34, 0x47, 0x7e, 0xd0, 0x6d, 0x1d, 0x5f, 0x3c, 0x19, 0x2b,
-	0xdb, 0xaa, 0x2b, 0x86, 0x99, 0x8d, 0x2d, 0x40, 0xff, 0x08, 0x1b, 0xa7, 0x28, 0x13, 0x17, 0x23,
-	0x9b, 0x0a, 0x99, 0x72, 0x39, 0x3b, 0xd3, 0x0a, 0x72, 0xe4, 0x2d, 0x34, 0xb3, 0x0d, 0x8f, 0xdc,
-	0x51, 0x2c, 0x4b, 0xcd, 0xd0, 0xde, 0x59, 0xe0, 0x57, 0x34, 0x47, 0x1e, 0x42, 0xfd, 0x14, 0xe3,
-	0xa2, 0x48, 0x40, 0x05, 0x9b, 0xe7, 0xb4, 0x1b, 0x26, 0x99, 0xd8, 0x36, 0xcd, 0x91, 0x23, 0x5d,
-	0x88, 0x79, 0x67, 0x8a, 0x03, 0xb7, 0xb5, 0xf6, 0xa5, 0x43, 0x68, 0x8e, 0x7c, 0x07, 0xcd, 0x39,
-	0x19, 0x36, 0x1a, 0x3b, 0x53, 0x10, 0xbb, 0x3c, 0x55, 0x4e, 0x9a, 0x23, 0x5d, 0xb0, 0x16, 0x09,
-	0x37, 0xb9, 0x3b, 0x0d, 0x5c, 0x2c, 0xeb, 0xf6, 0x46, 0x5a, 0x87, 0x69, 0x8e, 0xfc, 0x16, 0x69,
-	0x6a, 0x12, 0xd6, 0xbe, 0x62, 0x8e, 0xfc, 0x9f, 0xcc, 0x3f, 0x45, 0x17, 0x9c, 0x53, 0x6b, 0xf3,
-	0x50, 0x4b, 0x95, 0x3c, 0x79, 0xf1, 0x97, 0x70, 0x6b, 0x41, 0xb4, 0xae, 0xd7, 0x97, 0xd2, 0x3d,
-	0x01, 0x5b, 0x7f, 0xbe, 0x32, 0x73, 0x92, 0xea, 0xa2, 0xac, 0x39, 0x48, 0xc0, 0x5f, 0x45, 0xf0,
-	0x4c, 0xfb, 0x20, 0xdf, 0x4e, 0x43, 0x97, 0xd9, 0x4b, 0x92, 0xf1, 0x05, 0xd4, 0x12, 0x3e, 0x41,
-	0xac, 0xa8, 0x93, 0xe7, 0xac, 0xc3, 0xfe, 0x4a, 0xb7, 0xd6, 0x42, 0xd1, 0xa3, 0x39, 0xf2, 0x3d,
-	0xd4, 0x12, 0xee, 0x61, 0xc8, 0xb2, 0x0c, 0x25, 0x99, 0xc4, 0x63, 0xa8, 0x25, 0xcc, 0xc3, 0xe0,
-	0xb2, 0xfc, 0xc4, 0xd6, 0xfd, 0x6d, 0x96, 0xf4, 0x14, 0xac, 0x5f, 0xe0, 0x28, 0xa5, 0x25, 0x73,
-	0x93, 0xbf, 0x40, 0x0d, 0x1e, 0x03, 0x31, 0x7f, 0x80, 0x5d, 0x8b, 0xaf, 0x98, 0xb5, 0xf6, 0x20,
-	0x90, 0x63, 0x9a, 0x23, 0x6d, 0xd8, 0xb9, 0xc0, 0x51, 0xd6, 0x13, 0x92, 0x2c, 0xe1, 0x58, 0xa4,
-	0x26, 0x4f, 0xc1, 0x36, 0xe7, 0x7f, 0x3e, 0x53, 0x2a, 0x91, 0x23, 0xd8, 0x7e, 0x1e, 0x69, 0xf0,
-	0x97, 0x83, 0xcf, 0xa1, 0x99, 0x6d, 0xb7, 0xa6, 0xa9, 0x97, 0x5a, 0x71, 0x9a, 0xeb, 0x0c, 0xea,
-	0x49, 0xe3, 0x24, 0x37, 0xb5, 0xac, 0x66, 0xf9, 0xb1, 0x6d, 0x67, 0x6d, 0x19, 0x57, 0xd1, 0x1a,
-	0x5d, 0x3b, 0x76, 0xdd, 0x58, 0x43, 0x5e, 0xd3, 0x76, 0xe9, 0x54, 0x04, 0xdc, 0x5e, 0xe6, 0x5f,
-	0xe4, 0x9e, 0x99, 0xb1, 0x6b, 0x8d, 0xd1, 0xde, 0xbf, 0x3e, 0x70, 0x9a, 0xf4, 0x11, 0x34, 0x5b,
-	0xc8, 0x1c, 0xc9, 0x2f, 0xe7, 0xdb, 0x69, 0x7e, 0xa4, 0x53, 0x19, 0x3f, 0x81, 0x9d, 0x19, 0xf8,
-	0x33, 0xcc, 0x29, 0x09, 0x3f, 0x59, 0xfd, 0xbd, 0xa4, 0xff, 0xcd, 0xfb, 0x2f, 0x00, 0x00, 0xff,
-	0xff, 0x54, 0x1b, 0xb5, 0x9e, 0x15, 0x0e, 0x00, 0x00,
+	// 1463 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xef, 0x52, 0xdb, 0x46,
+	0x10, 0xb7, 0x31, 0x06, 0xbc, 0xfe, 0x03, 0x3e, 0x30, 0x28, 0x4a, 0x68, 0xc8, 0xa5, 0x9d, 0x90,
+	0x76, 0x86, 0x34, 0xcc, 0xa4, 0xf9, 0x40, 0xd3, 0x89, 0xc1, 0x84, 0x42, 0x12, 0x86, 0xda, 0x49,
+	0xda, 0xe9, 0xb7, 0xc3, 0x5a, 0xcc, 0x35, 0xb6, 0xa4, 0xea, 0xce, 0xfc, 0xc9, 0x23, 0xf4, 0x95,
+	0xfa, 0x18, 0x7d, 0x8f, 0x3e, 0x43, 0xe7, 0xee, 0x64, 0x5b, 0x92, 0x25, 0x48, 0xa6, 0xdf, 0xe4,
+	0xbb, 0xdd, 0xdf, 0xed, 0xde, 0xfd, 0xf6, 0xb7, 0x3b, 0x86, 0xba, 0x60, 0x4f, 0xfc, 0xc0, 0x93,
+	0xde, 0x13, 0xc1, 0xb6, 0xf4, 0x07, 0x99, 0x11, 0xcc, 0x6e, 0x74, 0xbd, 0x00, 0xc3, 0x0d, 0xf5,
+	0x69, 0xb6, 0xe8, 0x3d, 0xa8, 0xb5, 0xb1, 0xc7, 0x85, 0x0c, 0x98, 0xe4, 0x9e, 0x7b, 0xd8, 0x22,
+	0x00, 0x33, 0xdc, 0xb1, 0xf2, 0x1b, 0xf9, 0xcd, 0x02, 0xbd, 0x03, 0x70, 0x24, 0x3c, 0xf7, 0x57,
+	0x3c, 0x7d, 0x8d, 0xd7, 0xa4, 0x0c, 0x85, 0x3f, 0x2e, 0x3f, 0xea, 0xad, 0x0a, 0x5d, 0x87, 0xc5,
+	0xe6, 0x50, 0x9e, 0x7b, 0x01, 0xff, 0x34, 0xed, 0x59, 0xa2, 0xef, 0x61, 0xfd, 0x00, 0xe5, 0x07,
+	0xd6, 0xe7, 0x4e, 0xcc, 0x4c, 0xb4, 0xf1, 0xcf, 0x21, 0x0a, 0x49, 0x56, 0xa1, 0x16, 0xc4, 0x0e,
+	0x36, 0x47, 0x92, 0x45, 0x98, 0x77, 0xbc, 0x01, 0xe3, 0xae, 0xb0, 0x66, 0x36, 0x0a, 0x9b, 0x25,
+	0x75, 0xaa, 0xeb, 0x5d, 0x5a, 0x05, 0x1d, 0xd0, 0x5f, 0x79, 0x58, 0x4e, 0x01, 0x25, 0x4f, 0xa1,
+	0x78, 0xa1, 0x96, 0xad, 0xfc, 0x46, 0x61, 0xb3, 0xbc, 0x4d, 0xb7, 0x04, 0xdb, 0x4a, 0xb1, 0xdb,
+	0x7a, 0xcb, 0xfc, 0xfd, 0x3e, 0x0e, 0xd0, 0x95, 0xf6, 0x4b, 0x80, 0xc9, 0x2f, 0x52, 0x83, 0x39,
+	0x73, 0xac, 0x89, 0x9f, 0x50, 0x28, 0xb2, 0xa1, 0x3c, 0xff, 0x64, 0xcd, 0x6c, 0xe4, 0x37, 0xcb,
+	0xdb, 0xcb, 0x5b, 0xfa, 0xce, 0x62, 0x68, 0xf4, 0xdf, 0x3c, 0xd4, 0xf7, 0x30, 0x90, 0xfc, 0x8c,
+	0x77, 0x99, 0xc4, 0x8e, 0x64, 0x72, 0x28, 0x14, 0x92, 0xc0, 0x80, 0xb3, 0x7e, 0x88, 0x64, 0x03,
+	0x11, 0xc3, 0x53, 0xd1, 0x0d, 0xf8, 0x29, 0x06, 0x4d, 0xdf, 0x0f, 0xbc, 0x0b, 0x74, 0x34, 0xec,
+	0x82, 0xb6, 0xd5, 0x5e, 0x3a, 0xbd, 0x12, 0x59, 0x83, 0x45, 0xaf, 0x2b, 0xfc, 0x37, 0x4c, 0xc8,
+	0xf7, 0xbe, 0xc3, 0x24, 0x3a, 0xd6, 0xac, 0xbe, 0x95, 0x65, 0x28, 0x07, 0x78, 0xe1, 0x7d, 0x44,
+	0xa7, 0xc5, 0x24, 0x5a, 0x45, 0xbd, 0xd8, 0x80, 0x6a, 0xb8, 0xd8, 0x46, 0x26, 0x3c, 0xd7, 0x9a,
+	0xd3, 0xcb, 0xeb, 0xd0, 0xe8, 0x33, 0x21, 0xf7, 0xaf, 0x7c, 0x6e, 0xee, 0xf6, 0x98, 0xf5, 0x3a,
+	0xe8, 0x4a, 0x6b, 0x5e, 0x6f, 0xaf, 0x40, 0x45, 0x9d, 0xd1, 0x46, 0xe1, 0x7b, 0xae, 0x40, 0x6b,
+	0x41, 0x3d, 0x27, 0x59, 0x82, 0x05, 0xd7, 0x93, 0xcd, 0x33, 0x89, 0x81, 0x55, 0xd2, 0x76, 0x75,
+	0x28, 0x71, 0xa1, 0x41, 0xd0, 0xb1, 0x40, 0x85, 0x4b, 0x2d, 0x98, 0xeb, 0xe8, 0xd4, 0x92, 0x49,
+	0xd2, 0xc7, 0x50, 0x6c, 0x33, 0xb7, 0x87, 0x0a, 0x07, 0x59, 0xd0, 0xe7, 0x28, 0x64, 0xf8, 0xa0,
+	0x35, 0x98, 0xeb, 0x33, 0xa9, 0x7e, 0xcf, 0xe8, 0x27, 0x5c, 0x85, 0xe2, 0x9e, 0x37, 0x74, 0x25,
+	0xa9, 0x42, 0xb1, 0xab, 0x3e, 0x42, 0xae, 0x1d, 0xc1, 0x7d, 0xbd, 0x1e, 0xb9, 0x51, 0xb1, 0x7b,
+	0x7d, 0xcc, 0x06, 0x38, 0xe6, 0x8c, 0x05, 0xc5, 0x40, 0x9d, 0xa2, 0x3d, 0xca, 0xdb, 0x25, 0xf5,
+	0xca, 0xe6, 0xd8, 0x2a, 0x14, 0x5d, 0x65, 0x69, 0x38, 0x43, 0xfb, 0x50, 0xd1, 0x58, 0xa1, 0x3f,
+	0x79, 0x0a, 0x95, 0x6e, 0xe4, 0x77, 0xc8, 0x92, 0xbb, 0xca, 0x3f, 0x6a, 0x17, 0xa5, 0xc7, 0xe3,
+	0x18, 0x3d, 0x2a, 0x30, 0xab, 0xf0, 0xc3, 0x27, 0x1d, 0x47, 0x6e, 0x32, 0xda, 0x87, 0x75, 0x8d,
+	0x12, 0x2d, 0x24, 0xb1, 0x7b, 0x7d, 0x78, 0x32, 0x8a, 0x5b, 0x15, 0x86, 0x6f, 0xea, 0x66, 0x92,
+	0xc3, 0x4c, 0x22, 0x07, 0xda, 0x83, 0x07, 0x1a, 0xe6, 0xd0, 0xbd, 0xf8, 0xf2, 0xb2, 0x59, 0x82,
+	0x85, 0x73, 0x4f, 0x48, 0x1d, 0xe4, 0x8c, 0x0e, 0x72, 0x7c, 0x50, 0x21, 0x79, 0xd0, 0x33, 0x58,
+	0x39, 0x40, 0xd9, 0xd9, 0x7b, 0xd7, 0xc6, 0x2e, 0x72, 0x5f, 0x8e, 0xb0, 0x93, 0xcc, 0xad, 0x42,
+	0xb1, 0xef, 0xf5, 0x0e, 0x5b, 0x06, 0x90, 0x3e, 0x87, 0x15, 0x1d, 0xdf, 0xab, 0x5f, 0x5a, 0xc7,
+	0x1d, 0x94, 0x22, 0xe2, 0x76, 0xc9, 0x5d, 0xc7, 0xbb, 0xcc, 0xa8, 0x60, 0xfa, 0x08, 0x56, 0x42,
+	0x9f, 0xfd, 0x2b, 0x2e, 0x26, 0x8e, 0x11, 0xc3, 0xbc, 0x36, 0xb4, 0x60, 0xce, 0x58, 0x28, 0x4c,
+	0xd4, 0x5f, 0x1a, 0x73, 0x81, 0xbe, 0x80, 0xf5, 0xb7, 0x2c, 0xf8, 0x18, 0xe1, 0x46, 0x7b, 0xc4,
+	0xfc, 0xf4, 0xd8, 0x2b, 0x30, 0xdb, 0xf5, 0x1c, 0x0c, 0x5f, 0xa8, 0x09, 0x8d, 0xa6, 0xe3, 0xc4,
+	0xbc, 0x8d, 0x5b, 0x19, 0x0a, 0x0e, 0x06, 0xe1, 0xd3, 0x54, 0xa1, 0x18, 0xe0, 0x28, 0xdf, 0x82,
+	0x82, 0x50, 0x85, 0xa2, 0xef, 0xaf, 0x42, 0x37, 0x61, 0x35, 0x09, 0x61, 0x0a, 0x48, 0x4b, 0x07,
+	0xef, 0x8d, 0x08, 0x5f, 0xa2, 0xff, 0xe4, 0xc1, 0xee, 0xf0, 0x9e, 0x8b, 0x51, 0xeb, 0x77, 0x7c,
+	0x80, 0x42, 0xb2, 0x81, 0x1f, 0xd5, 0x57, 0x42, 0x00, 0x44, 0x57, 0x7e, 0xc0, 0x40, 0x70, 0xcf,
+	0x0d, 0x8f, 0x1d, 0xdf, 0xba, 0x91, 0x84, 0x3a, 0x94, 0xe4, 0xc8, 0x37, 0x14, 0x03, 0x02, 0x80,
+	0x57, 0x12, 0x5d, 0xe5, 0x24, 0xb4, 0x16, 0x54, 0x94, 0x99, 0xe0, 0x3d, 0x97, 0xc9, 0x61, 0x80,
+	0x5a, 0x07, 0x2a, 0xe4, 0x0e, 0xd4, 0xbb, 0x11, 0x75, 0x32, 0xb7, 0x33, 0xaf, 0x41, 0x1b, 0x50,
+	0x3d, 0x67, 0xe2, 0xbc, 0xd9, 0xef, 0x79, 0x01, 0x97, 0xe7, 0x03, 0x2d, 0x02, 0x05, 0x2d, 0x55,
+	0x23, 0x90, 0xc9, 0x9e, 0x96, 0x03, 0xfa, 0x12, 0xee, 0xb7, 0xb0, 0x8f, 0x12, 0x43, 0x49, 0x98,
+	0xd0, 0x27, 0x4a, 0x84, 0xee, 0x50, 0x7a, 0x67, 0x67, 0x56, 0x7e, 0x9c, 0x09, 0x1f, 0xf0, 0x51,
+	0x99, 0x04, 0xb0, 0xd2, 0xd9, 0x7b, 0xd7, 0x19, 0x9e, 0x0e, 0xb8, 0x50, 0xa1, 0xbf, 0x62, 0xbc,
+	0x3f, 0x0c, 0x30, 0x76, 0x21, 0xa9, 0x31, 0x1b, 0x3e, 0x2b, 0x1d, 0xf1, 0x7a, 0xef, 0xdb, 0x87,
+	0xe1, 0xc5, 0xd4, 0x60, 0x2e, 0x30, 0xb2, 0x37, 0xab, 0x7f, 0x2f, 0x43, 0x99, 0x49, 0x89, 0x03,
+	0x5f, 0xa2, 0xd3, 0x94, 0x46, 0x22, 0xe9, 0x1e, 0x34, 0xd2, 0xce, 0x14, 0xe4, 0x5b, 0x58, 0x38,
+	0x0b, 0xbf, 0x43, 0x35, 0xb0, 0x54, 0x81, 0xa4, 0x19, 0xd3, 0x3d, 0x78, 0x60, 0x52, 0x4f, 0xdb,
+	0xcd, 0x22, 0xe0, 0x24, 0x5c, 0x53, 0x3d, 0xcf, 0xe0, 0xa1, 0xa1, 0x6c, 0xbc, 0xae, 0x77, 0xaf,
+	0x5b, 0xba, 0x04, 0x22, 0x30, 0xd1, 0x3e, 0x44, 0x8f, 0xe0, 0xeb, 0x9b, 0xdd, 0x42, 0x12, 0xd6,
+	0xa1, 0x74, 0xc6, 0x5d, 0xd6, 0xe7, 0x9f, 0xd0, 0x99, 0xd4, 0xa1, 0x8f, 0xae, 0xc3, 0xdd, 0x9e,
+	0x79, 0x80, 0xed, 0xbf, 0xeb, 0xb0, 0xd4, 0x91, 0x5e, 0xc0, 0x7a, 0x23, 0x34, 0x79, 0x4d, 0x76,
+	0x60, 0xf1, 0x00, 0x63, 0xd2, 0x45, 0x88, 0x96, 0x8a, 0x98, 0xca, 0xd8, 0xc4, 0x34, 0xc0, 0xe8,
+	0x2a, 0xcd, 0x91, 0x1f, 0xb5, 0x92, 0x44, 0x17, 0x77, 0xaf, 0xd5, 0xa4, 0x50, 0x53, 0x08, 0x93,
+	0xc9, 0x21, 0xc3, 0xfb, 0x27, 0x58, 0x3a, 0x40, 0x19, 0x4b, 0x8c, 0x2c, 0x2b, 0xcf, 0xc4, 0x60,
+	0x61, 0xa7, 0x76, 0xdf, 0x1c, 0xf9, 0x00, 0xab, 0xe9, 0x33, 0x06, 0x79, 0xa0, 0x50, 0x6e, 0x9c,
+	0x3f, 0xec, 0xb5, 0x8c, 0x11, 0x81, 0xe6, 0xc8, 0x53, 0xa8, 0x1d, 0x60, 0xb4, 0x0f, 0x11, 0xd0,
+	0xdc, 0xd0, 0xcf, 0x6b, 0xd7, 0x4d, 0x30, 0x91, 0x6d, 0x9a, 0x23, 0x3b, 0xfa, 0x22, 0xa6, 0x87,
+	0x81, 0xa8, 0x63, 0x43, 0x7d, 0x4f, 0x99, 0xd0, 0x1c, 0xf9, 0x1e, 0x56, 0xa7, 0x3a, 0x9f, 0x69,
+	0x6b, 0x13, 0xd1, 0xb6, 0x4b, 0xe3, 0x66, 0x45, 0x73, 0xa4, 0x03, 0x56, 0x56, 0xaf, 0x24, 0x0f,
+	0xc7, 0x86, 0xd9, 0x9d, 0xd4, 0x5e, 0x4a, 0xb6, 0x3e, 0x9a, 0x23, 0xbf, 0xc1, 0x7a, 0x8a, 0xdb,
+	0xfe, 0x15, 0xeb, 0xca, 0xff, 0x89, 0xfc, 0x73, 0x98, 0xe0, 0x54, 0x83, 0x34, 0x0f, 0x75, 0x63,
+	0xf3, 0x8c, 0x27, 0xfe, 0x16, 0xee, 0x66, 0x58, 0xeb, 0xfb, 0xfa, 0x52, 0xb8, 0x17, 0x60, 0xeb,
+	0xcf, 0x13, 0x53, 0x27, 0x09, 0x16, 0xa5, 0xd5, 0x41, 0xcc, 0xfd, 0x04, 0xec, 0xec, 0x8e, 0x4d,
+	0xbe, 0x19, 0x9b, 0xde, 0xd4, 0xd1, 0xe3, 0x88, 0xaf, 0xa1, 0x1a, 0x6b, 0xcd, 0xc4, 0x0a, 0x99,
+	0x3c, 0xd5, 0xad, 0xed, 0xaf, 0x34, 0xb5, 0x32, 0xfb, 0x0c, 0xcd, 0x91, 0x16, 0x58, 0xc6, 0x33,
+	0x45, 0xff, 0xca, 0x86, 0xc5, 0xfb, 0x03, 0x5f, 0x5e, 0xdb, 0x77, 0xb2, 0xa4, 0x4f, 0x3d, 0xde,
+	0x0f, 0x50, 0x8d, 0xb5, 0x7d, 0x13, 0x52, 0xda, 0x24, 0x10, 0x4f, 0xe5, 0x39, 0x54, 0x63, 0x5d,
+	0xdf, 0xf8, 0xa5, 0x0d, 0x02, 0xb6, 0xae, 0x12, 0xb3, 0xa4, 0x6b, 0x69, 0xf1, 0x18, 0x2f, 0x13,
+	0x8a, 0x34, 0xa5, 0x1f, 0x19, 0x9a, 0xf2, 0x1c, 0x88, 0x99, 0x9c, 0x6f, 0xf5, 0x8f, 0xde, 0x00,
+	0xcd, 0x91, 0x7d, 0x58, 0x3b, 0xc6, 0xcb, 0x34, 0x22, 0x90, 0x34, 0xf9, 0xc9, 0xd2, 0xa4, 0x97,
+	0x60, 0x9b, 0xf3, 0x3f, 0x1f, 0x29, 0x11, 0xc8, 0x0e, 0x34, 0x5e, 0x85, 0x4a, 0xfe, 0xe5, 0xce,
+	0x47, 0xb0, 0x9a, 0x3e, 0x27, 0x99, 0xd2, 0xb8, 0x71, 0x86, 0x4a, 0x62, 0x1d, 0x42, 0x2d, 0x3e,
+	0xf1, 0x10, 0xcd, 0x93, 0xd4, 0x41, 0xca, 0xb6, 0xd3, 0xb6, 0x4c, 0x6f, 0xd2, 0x4a, 0x5f, 0x6d,
+	0x3a, 0x91, 0x91, 0x81, 0xdc, 0x42, 0xde, 0x64, 0x28, 0x6f, 0xc0, 0xca, 0x1a, 0x3e, 0x8c, 0x2a,
+	0xdd, 0x32, 0x9a, 0xc4, 0x99, 0xf9, 0x1d, 0x2c, 0x9d, 0x0c, 0x83, 0x1e, 0x46, 0x51, 0xa2, 0x42,
+	0x1d, 0x33, 0xde, 0x85, 0x35, 0x13, 0xfa, 0xf4, 0xe0, 0x92, 0x39, 0x31, 0x24, 0xc3, 0x6f, 0x83,
+	0x9d, 0x3d, 0x40, 0x18, 0x9d, 0xb8, 0x75, 0xc0, 0x48, 0x62, 0x0a, 0xb8, 0x77, 0xd3, 0x60, 0x40,
+	0x1e, 0x19, 0xf1, 0xba, 0x75, 0xe2, 0xb0, 0x37, 0x6f, 0x37, 0x1c, 0xbf, 0xe3, 0x0e, 0xac, 0xb6,
+	0x90, 0x75, 0x25, 0xbf, 0x98, 0xae, 0xb0, 0x69, 0xad, 0x4c, 0x44, 0xfc, 0x02, 0xd6, 0x26, 0xce,
+	0x9f, 0xd1, 0xf5, 0xe3, 0xee, 0xbb, 0xf3, 0xbf, 0x17, 0xf5, 0x5f, 0x16, 0xff, 0x0d, 0x00, 0x70,
+	0x10, 0x94, 0x91, 0xe1, 0x10, 0x00, 0x00,
 }
@@ -1012,24 +1012,20 @@ func (ssa *SQLStorageAuthority) CountInvalidAuthorizations(
 	return
 }
 
-// ErrNoReceipt is an error type for non-existent SCT receipt
-type ErrNoReceipt string
-
-func (e ErrNoReceipt) Error() string {
-	return string(e)
-}
-
 // GetSCTReceipt gets a specific SCT receipt for a given certificate serial and
-// CT log ID
+// CT log ID, returning a NotFound error if there isn't one
 func (ssa *SQLStorageAuthority) GetSCTReceipt(ctx context.Context, serial string, logID string) (core.SignedCertificateTimestamp, error) {
 	receipt, err := selectSctReceipt(ssa.dbMap, "WHERE certificateSerial = ? AND logID = ?", serial, logID)
 	if err == sql.ErrNoRows {
-		return receipt, ErrNoReceipt(err.Error())
+		return receipt, berrors.NotFoundError("no SCT receipt for serial %q from log %q", serial, logID)
 	}
 	return receipt, err
 }
 
-// AddSCTReceipt adds a new SCT receipt to the (append-only) sctReceipts table
+// AddSCTReceipt adds a new SCT receipt to the sctReceipts table. A receipt
+// for a certificate and log that already has one replaces it only if it has
+// an earlier timestamp, since logs may return a previously issued SCT when a
+// certificate is resubmitted.
 func (ssa *SQLStorageAuthority) AddSCTReceipt(ctx context.Context, sct core.SignedCertificateTimestamp) error {
 	err := ssa.dbMap.Insert(&sct)
 	// For AddSCTReceipt, duplicates are explicitly OK, so don't return errors
@@ -1037,8 +1033,154 @@ func (ssa *SQLStorageAuthority) AddSCTReceipt(ctx context.Context, sct core.Sign
 	// for a certificate if even one of them fails. Once https://github.com/letsencrypt/boulder/issues/891
 	// is fixed, we may want to start returning this as an error, or logging it.
 	if err != nil && strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
-		return nil
+		_, err = ssa.dbMap.Exec(
+			`UPDATE sctReceipts
+			SET sctVersion = ?, timestamp = ?, extensions = ?, signature = ?, hashAlgorithm = ?, signatureAlgorithm = ?
+			WHERE certificateSerial = ? AND logID = ? AND timestamp > ?`,
+			sct.SCTVersion,
+			sct.Timestamp,
+			sct.Extensions,
+			sct.Signature,
+			sct.HashAlgorithm,
+			sct.SignatureAlgorithm,
+			sct.CertificateSerial,
+			sct.LogID,
+			sct.Timestamp,
+		)
+	}
+	return err
+}
+
+// DeleteExpiredSCTReceipts deletes up to limit SCT receipts for certificates
+// that expired before cutoff, returning the number of receipts deleted.
+// Receipts for serials the SA has no certificate for are kept. Submission
+// failures recorded for the certificates whose receipts are deleted are
+// deleted as well.
+func (ssa *SQLStorageAuthority) DeleteExpiredSCTReceipts(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	var receipts []struct {
+		ID                int64  `db:"id"`
+		CertificateSerial string `db:"certificateSerial"`
+	}
+	_, err := ssa.dbMap.Select(
+		&receipts,
+		`SELECT sctReceipts.id, sctReceipts.certificateSerial FROM sctReceipts
+		JOIN certificates ON certificates.serial = sctReceipts.certificateSerial
+		WHERE certificates.expires < :cutoff
+		LIMIT :limit`,
+		map[string]interface{}{
+			"cutoff": cutoff,
+			"limit":  limit,
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if len(receipts) == 0 {
+		return 0, nil
+	}
+
+	qmarks := make([]string, len(receipts))
+	params := make([]interface{}, len(receipts))
+	serials := make(map[string]bool)
+	var serialQmarks []string
+	var serialParams []interface{}
+	for i, receipt := range receipts {
+		params[i] = receipt.ID
+		qmarks[i] = "?"
+		if !serials[receipt.CertificateSerial] {
+			serials[receipt.CertificateSerial] = true
+			serialParams = append(serialParams, receipt.CertificateSerial)
+			serialQmarks = append(serialQmarks, "?")
+		}
+	}
+	tx, err := ssa.dbMap.Begin()
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(
+		"DELETE FROM sctReceipts WHERE id IN ("+strings.Join(qmarks, ",")+")",
+		params...,
+	)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	_, err = tx.Exec(
+		"DELETE FROM sctSubmissionFailures WHERE certificateSerial IN ("+strings.Join(serialQmarks, ",")+")",
+		serialParams...,
+	)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	return deleted, tx.Commit()
+}
+
+// PurgeSCTReceipts deletes every SCT receipt and submission failure for the
+// certificate with the given serial, returning the number of receipts
+// deleted
+func (ssa *SQLStorageAuthority) PurgeSCTReceipts(ctx context.Context, serial string) (int64, error) {
+	tx, err := ssa.dbMap.Begin()
+	if err != nil {
+		return 0, err
 	}
+	result, err := tx.Exec("DELETE FROM sctReceipts WHERE certificateSerial = ?", serial)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	_, err = tx.Exec("DELETE FROM sctSubmissionFailures WHERE certificateSerial = ?", serial)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+	return purged, tx.Commit()
+}
+
+// GetSCTSubmissionFailures returns every recorded SCT submission failure,
+// sorted by certificate serial and then log URI
+func (ssa *SQLStorageAuthority) GetSCTSubmissionFailures(ctx context.Context) ([]core.SCTSubmissionFailure, error) {
+	var failures []core.SCTSubmissionFailure
+	_, err := ssa.dbMap.Select(
+		&failures,
+		`SELECT id, certificateSerial, logURI, reason, attemptedAt
+		FROM sctSubmissionFailures
+		ORDER BY certificateSerial, logURI`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// AddSCTSubmissionFailure records a failed submission of a certificate to a
+// CT log, replacing any failure already recorded for the certificate and log
+func (ssa *SQLStorageAuthority) AddSCTSubmissionFailure(ctx context.Context, failure core.SCTSubmissionFailure) error {
+	_, err := ssa.dbMap.Exec(
+		`INSERT INTO sctSubmissionFailures (certificateSerial, logURI, reason, attemptedAt)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE reason = VALUES(reason), attemptedAt = VALUES(attemptedAt)`,
+		failure.CertificateSerial,
+		failure.LogURI,
+		failure.Reason,
+		failure.AttemptedAt,
+	)
+	return err
+}
+
+// DeleteSCTSubmissionFailure deletes the failure recorded for the submission
+// of the certificate with the given serial to the CT log at logURI, if any,
+// e.g. once a later submission has succeeded
+func (ssa *SQLStorageAuthority) DeleteSCTSubmissionFailure(ctx context.Context, serial, logURI string) error {
+	_, err := ssa.dbMap.Exec(
+		"DELETE FROM sctSubmissionFailures WHERE certificateSerial = ? AND logURI = ?",
+		serial,
+		logURI,
+	)
 	return err
 }
 
@@ -664,17 +664,39 @@ func TestAddSCTReceipt(t *testing.T) {
 	// Append only and unique on signature and across LogID and CertificateSerial
 	err = sa.AddSCTReceipt(ctx, sct)
 	test.AssertNotError(t, err, "Incorrectly returned error on duplicate SCT receipt")
+
+	// A later SCT from the same log is ignored, while an earlier one replaces
+	// the stored receipt
+	later := sct
+	later.Timestamp = sctTimestamp + 1
+	later.Signature = []byte("later")
+	err = sa.AddSCTReceipt(ctx, later)
+	test.AssertNotError(t, err, "Failed to add later SCT receipt")
+	stored, err := sa.GetSCTReceipt(ctx, sctCertSerial, sctLogID)
+	test.AssertNotError(t, err, "Failed to get SCT receipt")
+	test.AssertEquals(t, stored.Timestamp, sct.Timestamp)
+	earlier := sct
+	earlier.Timestamp = sctTimestamp - 1
+	earlier.Signature = []byte("earlier")
+	err = sa.AddSCTReceipt(ctx, earlier)
+	test.AssertNotError(t, err, "Failed to add earlier SCT receipt")
+	stored, err = sa.GetSCTReceipt(ctx, sctCertSerial, sctLogID)
+	test.AssertNotError(t, err, "Failed to get SCT receipt")
+	test.AssertEquals(t, stored.Timestamp, earlier.Timestamp)
+	test.AssertByteEquals(t, stored.Signature, earlier.Signature)
 }
 
 func TestGetSCTReceipt(t *testing.T) {
 	sigBytes, err := base64.StdEncoding.DecodeString(sctSignature)
 	test.AssertNotError(t, err, "Failed to decode SCT signature")
 	sct := core.SignedCertificateTimestamp{
-		SCTVersion:        sctVersion,
-		LogID:             sctLogID,
-		Timestamp:         sctTimestamp,
-		Signature:         sigBytes,
-		CertificateSerial: sctCertSerial,
+		SCTVersion:         sctVersion,
+		LogID:              sctLogID,
+		Timestamp:          sctTimestamp,
+		Signature:          sigBytes,
+		HashAlgorithm:      4,
+		SignatureAlgorithm: 3,
+		CertificateSerial:  sctCertSerial,
 	}
 	sa, _, cleanup := initSA(t)
 	defer cleanup()
@@ -687,9 +709,124 @@ func TestGetSCTReceipt(t *testing.T) {
 	test.Assert(t, sqlSCT.LogID == sct.LogID, "Invalid log ID")
 	test.Assert(t, sqlSCT.Timestamp == sct.Timestamp, "Invalid timestamp")
 	test.Assert(t, bytes.Compare(sqlSCT.Signature, sct.Signature) == 0, "Invalid signature")
+	test.AssertEquals(t, sqlSCT.HashAlgorithm, sct.HashAlgorithm)
+	test.AssertEquals(t, sqlSCT.SignatureAlgorithm, sct.SignatureAlgorithm)
 	test.Assert(t, sqlSCT.CertificateSerial == sct.CertificateSerial, "Invalid certificate serial")
 }
 
+func TestDeleteExpiredSCTReceipts(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	// www.eff.org.der expires 2016-04-14
+	certDER, err := ioutil.ReadFile("www.eff.org.der")
+	test.AssertNotError(t, err, "Couldn't read example cert DER")
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil)
+	test.AssertNotError(t, err, "Couldn't add www.eff.org.der")
+	serial := "000000000000000000000000000000021bd4"
+
+	sigBytes, err := base64.StdEncoding.DecodeString(sctSignature)
+	test.AssertNotError(t, err, "Failed to decode SCT signature")
+	for _, sct := range []core.SignedCertificateTimestamp{
+		{SCTVersion: sctVersion, LogID: sctLogID, Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: serial},
+		{SCTVersion: sctVersion, LogID: "other log", Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: serial},
+		// No certificate is stored for this serial, so its receipt is kept
+		{SCTVersion: sctVersion, LogID: sctLogID, Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: sctCertSerial},
+	} {
+		err = sa.AddSCTReceipt(ctx, sct)
+		test.AssertNotError(t, err, "Failed to add SCT receipt")
+	}
+
+	beforeExpiry := time.Date(2016, 4, 14, 0, 0, 0, 0, time.UTC)
+	deleted, err := sa.DeleteExpiredSCTReceipts(ctx, beforeExpiry, 10)
+	test.AssertNotError(t, err, "Failed to delete expired SCT receipts")
+	test.AssertEquals(t, deleted, int64(0))
+
+	afterExpiry := time.Date(2016, 4, 15, 0, 0, 0, 0, time.UTC)
+	deleted, err = sa.DeleteExpiredSCTReceipts(ctx, afterExpiry, 1)
+	test.AssertNotError(t, err, "Failed to delete expired SCT receipts")
+	test.AssertEquals(t, deleted, int64(1))
+	deleted, err = sa.DeleteExpiredSCTReceipts(ctx, afterExpiry, 10)
+	test.AssertNotError(t, err, "Failed to delete expired SCT receipts")
+	test.AssertEquals(t, deleted, int64(1))
+
+	_, err = sa.GetSCTReceipt(ctx, serial, sctLogID)
+	test.AssertError(t, err, "Got an SCT receipt for an expired certificate")
+	_, err = sa.GetSCTReceipt(ctx, sctCertSerial, sctLogID)
+	test.AssertNotError(t, err, "Failed to get SCT receipt for a serial without a certificate")
+}
+
+func TestPurgeSCTReceipts(t *testing.T) {
+	sa, _, cleanUp := initSA(t)
+	defer cleanUp()
+
+	sigBytes, err := base64.StdEncoding.DecodeString(sctSignature)
+	test.AssertNotError(t, err, "Failed to decode SCT signature")
+	for _, sct := range []core.SignedCertificateTimestamp{
+		{SCTVersion: sctVersion, LogID: sctLogID, Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: sctCertSerial},
+		{SCTVersion: sctVersion, LogID: "other log", Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: sctCertSerial},
+		{SCTVersion: sctVersion, LogID: sctLogID, Timestamp: sctTimestamp, Signature: sigBytes, CertificateSerial: "kept"},
+	} {
+		err = sa.AddSCTReceipt(ctx, sct)
+		test.AssertNotError(t, err, "Failed to add SCT receipt")
+	}
+
+	purged, err := sa.PurgeSCTReceipts(ctx, sctCertSerial)
+	test.AssertNotError(t, err, "Failed to purge SCT receipts")
+	test.AssertEquals(t, purged, int64(2))
+	_, err = sa.GetSCTReceipt(ctx, sctCertSerial, sctLogID)
+	test.AssertError(t, err, "Got a purged SCT receipt")
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "Missing SCT receipt wasn't a NotFound error")
+	_, err = sa.GetSCTReceipt(ctx, "kept", sctLogID)
+	test.AssertNotError(t, err, "Failed to get SCT receipt for another serial")
+
+	purged, err = sa.PurgeSCTReceipts(ctx, sctCertSerial)
+	test.AssertNotError(t, err, "Failed to purge SCT receipts")
+	test.AssertEquals(t, purged, int64(0))
+}
+
+func TestSCTSubmissionFailures(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	attemptedAt := fc.Now().UTC().Truncate(time.Second)
+	for _, failure := range []core.SCTSubmissionFailure{
+		{CertificateSerial: "b", LogURI: "https://a.example.com", Reason: "log down", AttemptedAt: attemptedAt},
+		{CertificateSerial: "a", LogURI: "https://b.example.com", Reason: "log down", AttemptedAt: attemptedAt},
+		{CertificateSerial: "a", LogURI: "https://a.example.com", Reason: "log down", AttemptedAt: attemptedAt},
+		// A later failure for the same serial and log replaces the first
+		{CertificateSerial: "a", LogURI: "https://a.example.com", Reason: "timed out", AttemptedAt: attemptedAt.Add(time.Hour)},
+	} {
+		err := sa.AddSCTSubmissionFailure(ctx, failure)
+		test.AssertNotError(t, err, "Failed to add SCT submission failure")
+	}
+
+	failures, err := sa.GetSCTSubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get SCT submission failures")
+	test.AssertEquals(t, len(failures), 3)
+	test.AssertEquals(t, failures[0].CertificateSerial, "a")
+	test.AssertEquals(t, failures[0].LogURI, "https://a.example.com")
+	test.AssertEquals(t, failures[0].Reason, "timed out")
+	test.Assert(t, failures[0].AttemptedAt.Equal(attemptedAt.Add(time.Hour)), "Failure wasn't replaced")
+	test.AssertEquals(t, failures[1].LogURI, "https://b.example.com")
+	test.AssertEquals(t, failures[2].CertificateSerial, "b")
+
+	err = sa.DeleteSCTSubmissionFailure(ctx, "a", "https://a.example.com")
+	test.AssertNotError(t, err, "Failed to delete SCT submission failure")
+	// Deleting a failure that isn't recorded isn't an error
+	err = sa.DeleteSCTSubmissionFailure(ctx, "a", "https://a.example.com")
+	test.AssertNotError(t, err, "Failed to delete missing SCT submission failure")
+	_, err = sa.PurgeSCTReceipts(ctx, "b")
+	test.AssertNotError(t, err, "Failed to purge SCT receipts")
+
+	failures, err = sa.GetSCTSubmissionFailures(ctx)
+	test.AssertNotError(t, err, "Failed to get SCT submission failures")
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].CertificateSerial, "a")
+	test.AssertEquals(t, failures[0].LogURI, "https://b.example.com")
+}
+
 func TestMarkCertificateRevoked(t *testing.T) {
 	sa, fc, cleanUp := initSA(t)
 	defer cleanUp()
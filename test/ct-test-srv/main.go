@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
 	ct "github.com/google/certificate-transparency-go"
 	ctTLS "github.com/google/certificate-transparency-go/tls"
@@ -26,10 +27,12 @@ import (
 func createSignedSCT(leaf []byte, k *ecdsa.PrivateKey) []byte {
 	rawKey, _ := x509.MarshalPKIXPublicKey(&k.PublicKey)
 	pkHash := sha256.Sum256(rawKey)
+	// The publisher rejects SCTs with timestamps far from the current time
+	timestamp := uint64(time.Now().UnixNano() / int64(time.Millisecond))
 	sct := ct.SignedCertificateTimestamp{
 		SCTVersion: ct.V1,
 		LogID:      ct.LogID{KeyID: pkHash},
-		Timestamp:  1337,
+		Timestamp:  timestamp,
 	}
 	serialized, _ := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{
 		Leaf: ct.MerkleTreeLeaf{
@@ -64,7 +67,7 @@ func createSignedSCT(leaf []byte, k *ecdsa.PrivateKey) []byte {
 	}
 	jsonSCTObj.SCTVersion = ct.V1
 	jsonSCTObj.ID = base64.StdEncoding.EncodeToString(pkHash[:])
-	jsonSCTObj.Timestamp = 1337
+	jsonSCTObj.Timestamp = timestamp
 	jsonSCTObj.Signature, _ = ds.Base64String()
 
 	jsonSCT, _ := json.Marshal(jsonSCTObj)
@@ -108,6 +111,7 @@ func (is *integrationSrv) handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		// id is a sha256 of a random EC key. Generate your own with:
 		// openssl ecparam -name prime256v1 -genkey -outform der | openssl sha256 -binary | base64